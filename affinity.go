@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Affinity is the type affinity of a column, which determines how values are converted when stored.
+// See https://www.sqlite.org/datatype3.html#type_affinity
+type Affinity string
+
+const (
+	AffinityText    = Affinity("TEXT")
+	AffinityNumeric = Affinity("NUMERIC")
+	AffinityInteger = Affinity("INTEGER")
+	AffinityReal    = Affinity("REAL")
+	AffinityBlob    = Affinity("BLOB")
+)
+
+func (a Affinity) String() string {
+	return string(a)
+}
+
+// ColumnAffinity determines the affinity of a declared column type, using SQLite's rules:
+//
+//  1. If the declared type contains "INT", it's INTEGER.
+//  2. Otherwise, if it contains "CHAR", "CLOB", or "TEXT", it's TEXT.
+//  3. Otherwise, if it contains "BLOB" or is empty, it's BLOB.
+//  4. Otherwise, if it contains "REAL", "FLOA", or "DOUB", it's REAL.
+//  5. Otherwise, it's NUMERIC.
+//
+// The rules are applied in order and case-insensitively, so for example "FLOATING POINT" is INTEGER
+// because it contains "INT", and "STRING" is NUMERIC.
+// See https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func ColumnAffinity(declType string) Affinity {
+	t := strings.ToUpper(declType)
+	switch {
+	case strings.Contains(t, "INT"):
+		return AffinityInteger
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return AffinityText
+	case strings.Contains(t, "BLOB"), strings.TrimSpace(t) == "":
+		return AffinityBlob
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return AffinityReal
+	default:
+		return AffinityNumeric
+	}
+}
+
+// ColumnAffinities returns the affinity of each result column in rows, based on the declared types.
+// Result columns which are expressions and not table columns have no declared type, and thus BLOB affinity.
+func ColumnAffinities(rows *sql.Rows) ([]Affinity, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, wrapError("error getting column types", err)
+	}
+
+	affinities := make([]Affinity, len(types))
+	for i, t := range types {
+		affinities[i] = ColumnAffinity(t.DatabaseTypeName())
+	}
+	return affinities, nil
+}
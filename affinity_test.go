@@ -0,0 +1,73 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestColumnAffinity(t *testing.T) {
+	// See the examples in https://www.sqlite.org/datatype3.html#affinity_name_examples
+	tests := []struct {
+		declType string
+		expected sqlite.Affinity
+	}{
+		{"INT", sqlite.AffinityInteger},
+		{"integer", sqlite.AffinityInteger},
+		{"TINYINT", sqlite.AffinityInteger},
+		{"UNSIGNED BIG INT", sqlite.AffinityInteger},
+		{"INT8", sqlite.AffinityInteger},
+		{"CHARACTER(20)", sqlite.AffinityText},
+		{"VARCHAR(255)", sqlite.AffinityText},
+		{"NATIVE CHARACTER(70)", sqlite.AffinityText},
+		{"TEXT", sqlite.AffinityText},
+		{"CLOB", sqlite.AffinityText},
+		{"BLOB", sqlite.AffinityBlob},
+		{"", sqlite.AffinityBlob},
+		{"REAL", sqlite.AffinityReal},
+		{"DOUBLE PRECISION", sqlite.AffinityReal},
+		{"FLOAT", sqlite.AffinityReal},
+		{"NUMERIC", sqlite.AffinityNumeric},
+		{"DECIMAL(10,5)", sqlite.AffinityNumeric},
+		{"BOOLEAN", sqlite.AffinityNumeric},
+		{"DATETIME", sqlite.AffinityNumeric},
+		{"STRING", sqlite.AffinityNumeric},
+		// "INT" in "POINT" matches the first rule before "FLOA" is checked
+		{"FLOATING POINT", sqlite.AffinityInteger},
+		// "INT" in "CHARINT" matches the first rule before "CHAR" is checked
+		{"CHARINT", sqlite.AffinityInteger},
+	}
+
+	for _, test := range tests {
+		t.Run(test.declType, func(t *testing.T) {
+			assert.Equal(t, test.expected, sqlite.ColumnAffinity(test.declType))
+		})
+	}
+}
+
+func TestColumnAffinities(t *testing.T) {
+	t.Run("returns affinities of result columns", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (a varchar(10), b float, c, d decimal, e bigint)`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select a, b, c, d, e, 1 from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		affinities, err := sqlite.ColumnAffinities(rows)
+		assert.NoErr(t, err)
+		expected := []sqlite.Affinity{sqlite.AffinityText, sqlite.AffinityReal, sqlite.AffinityBlob,
+			sqlite.AffinityNumeric, sqlite.AffinityInteger, sqlite.AffinityBlob}
+		assert.Equal(t, len(expected), len(affinities))
+		for i := range expected {
+			assert.Equal(t, expected[i], affinities[i])
+		}
+	})
+}
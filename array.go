@@ -0,0 +1,17 @@
+package sqlite
+
+// CArray is a slice bound as a single parameter for the carray table-valued function. Create it with Array.
+type CArray struct {
+	values any
+}
+
+// Array binds v as a single parameter for the carray table-valued function,
+// so it can be used in an IN clause instead of building a list of placeholders:
+//
+//	db.Query(`select name from users where id in carray(?)`, sqlite.Array(ids))
+//
+// The values are copied when binding. An empty or nil slice matches no rows.
+// See https://www.sqlite.org/carray.html
+func Array[T int64 | float64 | string](v []T) CArray {
+	return CArray{values: v}
+}
@@ -0,0 +1,94 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdlib.h>
+#include <sqlite3.h>
+
+extern int goAutoExtension(sqlite3 *db, char **pzErrMsg, void *pThunk);
+
+static int my_auto_extension() {
+	return sqlite3_auto_extension((void (*)(void))goAutoExtension);
+}
+
+static void my_set_errmsg(char **pzErrMsg, char *msg) {
+	*pzErrMsg = sqlite3_mprintf("%s", msg);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Extension is passed to functions registered with RegisterAutoExtension, to set up each new connection.
+type Extension struct {
+	cC *C.sqlite3
+}
+
+// CreateFunction creates a scalar SQL function called name, taking nArg arguments, on the new connection.
+// If nArg is -1, the function is variadic and takes any number of arguments.
+// Set deterministic if the function always returns the same result given the same arguments,
+// so SQLite can optimize calls to it.
+func (e *Extension) CreateFunction(name string, nArg int, deterministic bool, fn Function) error {
+	return createFunction(e.cC, name, nArg, deterministic, fn)
+}
+
+// Exec runs a query without args on the new connection.
+func (e *Extension) Exec(query string) error {
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	if cCode := C.sqlite3_exec(e.cC, cQuery, nil, nil, nil); cCode != C.SQLITE_OK {
+		return wrapError(`error running query "%v"`, newError(e.cC, cCode), query)
+	}
+	return nil
+}
+
+var (
+	autoExtensions     []func(*Extension) error
+	autoExtensionsLock sync.RWMutex
+	autoExtensionsOnce sync.Once
+)
+
+// RegisterAutoExtension registers init to be run on every new connection, for all drivers in the process,
+// before any pragmas from Options are applied. If init returns an error, opening the connection fails.
+//
+// Auto extensions only apply to connections opened after registration, so call RegisterAutoExtension before
+// opening any databases, for example in main or TestMain.
+// See https://www.sqlite.org/c3ref/auto_extension.html
+func RegisterAutoExtension(init func(e *Extension) error) error {
+	var err error
+	autoExtensionsOnce.Do(func() {
+		if cCode := C.my_auto_extension(); cCode != C.SQLITE_OK {
+			err = wrapErrorCode("error registering auto extension", cCode)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	autoExtensionsLock.Lock()
+	defer autoExtensionsLock.Unlock()
+	autoExtensions = append(autoExtensions, init)
+	return nil
+}
+
+// runAutoExtensions is called from goAutoExtension whenever a connection is opened.
+func runAutoExtensions(cC *C.sqlite3, pzErrMsg **C.char) C.int {
+	autoExtensionsLock.RLock()
+	defer autoExtensionsLock.RUnlock()
+
+	e := &Extension{cC: cC}
+	for _, init := range autoExtensions {
+		if err := init(e); err != nil {
+			cMsg := C.CString(err.Error())
+			C.my_set_errmsg(pzErrMsg, cMsg)
+			C.free(unsafe.Pointer(cMsg))
+			return C.SQLITE_ERROR
+		}
+	}
+	return C.SQLITE_OK
+}
@@ -0,0 +1,69 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestRegisterAutoExtension(t *testing.T) {
+	t.Run("runs on new connections and can create functions", func(t *testing.T) {
+		err := sqlite.RegisterAutoExtension(func(e *sqlite.Extension) error {
+			return e.CreateFunction("double_it", 1, true, func(args []driver.Value) (driver.Value, error) {
+				return args[0].(int64) * 2, nil
+			})
+		})
+		assert.NoErr(t, err)
+
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err = db.QueryRow(`select double_it(21)`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 42, v)
+	})
+
+	t.Run("can create variadic functions", func(t *testing.T) {
+		err := sqlite.RegisterAutoExtension(func(e *sqlite.Extension) error {
+			return e.CreateFunction("sum_all", -1, true, func(args []driver.Value) (driver.Value, error) {
+				var sum int64
+				for _, arg := range args {
+					sum += arg.(int64)
+				}
+				return sum, nil
+			})
+		})
+		assert.NoErr(t, err)
+
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err = db.QueryRow(`select sum_all(1, 2)`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 3, v)
+
+		err = db.QueryRow(`select sum_all(1, 2, 3, 4)`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 10, v)
+	})
+
+	t.Run("returns function errors as query errors", func(t *testing.T) {
+		err := sqlite.RegisterAutoExtension(func(e *sqlite.Extension) error {
+			return e.CreateFunction("fail_it", 0, false, func(args []driver.Value) (driver.Value, error) {
+				return nil, errors.New("oh no")
+			})
+		})
+		assert.NoErr(t, err)
+
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err = db.QueryRow(`select fail_it()`).Scan(&v)
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+)
+
+// formatBigFloat formats f as decimal text with the given number of significant digits,
+// or the fewest digits that represent f exactly at its precision if digits is zero.
+func formatBigFloat(f *big.Float, digits int) string {
+	if digits == 0 {
+		digits = -1
+	}
+	return f.Text('g', digits)
+}
+
+// ScanBigFloat returns a scanner that parses a column value into f, for use with sql.Rows.Scan.
+// Values are parsed with the precision of f, so set it with f.SetPrec before scanning,
+// or it defaults to 64 bits like big.Float.Parse.
+// Store big.Float values in TEXT columns, because REAL columns convert them to float64 and lose precision.
+func ScanBigFloat(f *big.Float) sql.Scanner {
+	return bigFloatScanner{f: f}
+}
+
+type bigFloatScanner struct {
+	f *big.Float
+}
+
+// Scan satisfies sql.Scanner.
+func (s bigFloatScanner) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		return s.parse(string(src))
+	case string:
+		return s.parse(src)
+	case int64:
+		s.f.SetInt64(src)
+	case float64:
+		s.f.SetFloat64(src)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into *big.Float")
+	default:
+		return fmt.Errorf("cannot scan %T into *big.Float", src)
+	}
+	return nil
+}
+
+func (s bigFloatScanner) parse(v string) error {
+	if _, _, err := s.f.Parse(v, 10); err != nil {
+		return wrapError("error parsing %q as big.Float", err, v)
+	}
+	return nil
+}
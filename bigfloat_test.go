@@ -0,0 +1,45 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestScanBigFloat(t *testing.T) {
+	t.Run("round-trips a high-precision big.Float through a text column", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v text not null)`)
+		assert.NoErr(t, err)
+
+		const pi = "3.14159265358979323846264338327950288419716939937510582097494459"
+		expected, _, err := big.ParseFloat(pi, 10, 256, big.ToNearestEven)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t values (?)`, expected)
+		assert.NoErr(t, err)
+
+		actual := new(big.Float).SetPrec(256)
+		err = db.QueryRow(`select v from t`).Scan(sqlite.ScanBigFloat(actual))
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, expected.Cmp(actual))
+
+		// A float64 only has about 16 significant digits
+		f, _ := expected.Float64()
+		assert.Equal(t, true, big.NewFloat(f).SetPrec(256).Cmp(actual) != 0)
+	})
+
+	t.Run("binds with configured number of digits", func(t *testing.T) {
+		db := open(t, sqlite.Options{BigFloatDigits: 5})
+
+		var s string
+		err := db.QueryRow(`select ?`, big.NewFloat(1.23456789)).Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "1.2346", s)
+	})
+}
@@ -0,0 +1,9 @@
+package sqlite
+
+// ZeroBlob is bound as a blob of this many zero bytes, without allocating them in Go.
+// Use it to reserve space for a blob, and fill it later with OpenBlob.
+//
+//	db.Exec(`insert into files (data) values (?)`, sqlite.ZeroBlob(size))
+//
+// See https://www.sqlite.org/c3ref/bind_blob.html
+type ZeroBlob int
@@ -0,0 +1,116 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdlib.h>
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"unsafe"
+)
+
+// Blob is an open blob for incremental I/O, satisfying io.ReaderAt and io.WriterAt.
+// A blob can't change size, so writes must fit in the existing blob.
+// See https://www.sqlite.org/c3ref/blob_open.html
+type Blob struct {
+	cBlob *C.sqlite3_blob
+	size  int64
+}
+
+// OpenBlob opens the blob in column of the row with rowid in table in the main database, on conn,
+// for reading, and writing if write is true. Close the blob before conn.
+// If the row is changed other than through the blob, the blob expires and further reads and writes fail.
+func OpenBlob(conn *sql.Conn, table, column string, rowid int64, write bool) (*Blob, error) {
+	var b *Blob
+	err := withConnection(conn, func(c *connection) error {
+		cDB := C.CString("main")
+		defer C.free(unsafe.Pointer(cDB))
+		cTable := C.CString(table)
+		defer C.free(unsafe.Pointer(cTable))
+		cColumn := C.CString(column)
+		defer C.free(unsafe.Pointer(cColumn))
+
+		var flags C.int
+		if write {
+			flags = 1
+		}
+
+		var cBlob *C.sqlite3_blob
+		if cCode := C.sqlite3_blob_open(c.cC, cDB, cTable, cColumn, C.sqlite3_int64(rowid), flags, &cBlob); cCode != C.SQLITE_OK {
+			return c.wrapErrorCode(`error opening blob in "%v"."%v" at rowid %v`, cCode, table, column, rowid)
+		}
+		b = &Blob{cBlob: cBlob, size: int64(C.sqlite3_blob_bytes(cBlob))}
+		return nil
+	})
+	return b, err
+}
+
+// Size of the blob in bytes.
+func (b *Blob) Size() int64 {
+	return b.size
+}
+
+// ReadAt satisfies io.ReaderAt.
+// See https://www.sqlite.org/c3ref/blob_read.html
+func (b *Blob) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= b.size {
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	var err error
+	if int64(n) > b.size-off {
+		n = int(b.size - off)
+		err = io.EOF
+	}
+	if n == 0 {
+		return 0, err
+	}
+
+	if cCode := C.sqlite3_blob_read(b.cBlob, unsafe.Pointer(&p[0]), C.int(n), C.int(off)); cCode != C.SQLITE_OK {
+		return 0, wrapErrorCode("error reading blob", cCode)
+	}
+	return n, err
+}
+
+// WriteAt satisfies io.WriterAt. Writing past the end of the blob is an error, and nothing is written.
+// See https://www.sqlite.org/c3ref/blob_write.html
+func (b *Blob) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off+int64(len(p)) > b.size {
+		return 0, errors.New("write past end of blob")
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if cCode := C.sqlite3_blob_write(b.cBlob, unsafe.Pointer(&p[0]), C.int(len(p)), C.int(off)); cCode != C.SQLITE_OK {
+		return 0, wrapErrorCode("error writing blob", cCode)
+	}
+	return len(p), nil
+}
+
+// Close the blob. It's safe to call more than once.
+// See https://www.sqlite.org/c3ref/blob_close.html
+func (b *Blob) Close() error {
+	if b.cBlob == nil {
+		return nil
+	}
+	cCode := C.sqlite3_blob_close(b.cBlob)
+	b.cBlob = nil
+	if cCode != C.SQLITE_OK {
+		return wrapErrorCode("error closing blob", cCode)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestZeroBlob(t *testing.T) {
+	t.Run("reserves a blob of zeros that can be filled with OpenBlob", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table files (data blob)`)
+		assert.NoErr(t, err)
+
+		result, err := db.Exec(`insert into files (data) values (?)`, sqlite.ZeroBlob(1024))
+		assert.NoErr(t, err)
+		rowid, err := result.LastInsertId()
+		assert.NoErr(t, err)
+
+		var length int
+		var zeros bool
+		err = db.QueryRow(`select length(data), data = zeroblob(1024) from files`).Scan(&length, &zeros)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1024, length)
+		assert.Equal(t, true, zeros)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		blob, err := sqlite.OpenBlob(conn, "files", "data", rowid, true)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1024), blob.Size())
+
+		data := bytes.Repeat([]byte("abcd"), 256)
+		n, err := blob.WriteAt(data[:1000], 0)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1000, n)
+		n, err = blob.WriteAt(data[1000:], 1000)
+		assert.NoErr(t, err)
+		assert.Equal(t, 24, n)
+
+		_, err = blob.WriteAt([]byte("x"), 1024)
+		assert.Err(t, err)
+
+		read := make([]byte, 1024)
+		n, err = blob.ReadAt(read, 0)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1024, n)
+		assert.EqualBytes(t, data, read)
+
+		n, err = blob.ReadAt(read, 1000)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 24, n)
+
+		assert.NoErr(t, blob.Close())
+		assert.NoErr(t, blob.Close())
+
+		var stored []byte
+		err = conn.QueryRowContext(context.Background(), `select data from files where rowid = ?`, rowid).Scan(&stored)
+		assert.NoErr(t, err)
+		assert.EqualBytes(t, data, stored)
+	})
+
+	t.Run("errors opening a blob in a missing row", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table files (data blob)`)
+		assert.NoErr(t, err)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		_, err = sqlite.OpenBlob(conn, "files", "data", 1, false)
+		assert.Err(t, err)
+	})
+}
+
+func TestBlobLen(t *testing.T) {
+	t.Run("scans the length of blobs, text, and NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var blobLen, textLen, nullLen int
+		err := db.QueryRow(`select zeroblob(1000), 'hello', null`).Scan(sqlite.BlobLen(&blobLen), sqlite.BlobLen(&textLen), sqlite.BlobLen(&nullLen))
+		assert.NoErr(t, err)
+		assert.Equal(t, 1000, blobLen)
+		assert.Equal(t, 5, textLen)
+		assert.Equal(t, 0, nullLen)
+	})
+
+	t.Run("doesn't allocate the blob", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{ZeroCopyBlobs: true},
+			`create table t (data blob not null)`,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 10) insert into t select randomblob(1 << 20) from n`)
+
+		result := testing.Benchmark(func(b *testing.B) {
+			scanBlobs(b, db, func(n *int, _ *[]byte) any { return sqlite.BlobLen(n) })
+		})
+		assert.Equal(t, true, result.AllocedBytesPerOp() < bigBlobSize)
+	})
+}
+
+// bigBlobSize is the size of the blobs created with randomblob(1 << 20) in the tests.
+const bigBlobSize = 1 << 20
+
+func BenchmarkBlobLen(b *testing.B) {
+	db := openWith(b, sqlite.Options{ZeroCopyBlobs: true},
+		`create table t (data blob not null)`,
+		`with recursive n(i) as (select 1 union all select i + 1 from n where i < 10) insert into t select randomblob(1 << 20) from n`)
+
+	b.Run("scanning into a byte slice", func(b *testing.B) {
+		scanBlobs(b, db, func(_ *int, v *[]byte) any { return v })
+	})
+
+	b.Run("scanning the length with BlobLen", func(b *testing.B) {
+		scanBlobs(b, db, func(n *int, _ *[]byte) any { return sqlite.BlobLen(n) })
+	})
+}
+
+// scanBlobs scans all blobs in table t b.N times, into the destination from dest.
+func scanBlobs(b *testing.B, db *sql.DB, dest func(n *int, v *[]byte) any) {
+	b.ReportAllocs()
+	var n int
+	var v []byte
+	for i := 0; i < b.N; i++ {
+		rows, err := db.Query(`select data from t`)
+		assert.NoErr(b, err)
+		for rows.Next() {
+			err := rows.Scan(dest(&n, &v))
+			assert.NoErr(b, err)
+		}
+		assert.NoErr(b, rows.Close())
+	}
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// BlobLen returns a scanner that reads the length in bytes of a BLOB or TEXT column into n, for use with sql.Rows.Scan,
+// without copying the value into Go memory like scanning into a *[]byte does, if Options.ZeroCopyBlobs is set.
+// NULL has length 0.
+// Note that SQLite still reads the value from the database file, so to avoid that as well,
+// select length(column) for text, or length(cast(column as blob)) for blobs, instead.
+func BlobLen(n *int) sql.Scanner {
+	return blobLenScanner{n: n}
+}
+
+type blobLenScanner struct {
+	n *int
+}
+
+// Scan satisfies sql.Scanner.
+func (s blobLenScanner) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		*s.n = len(src)
+	case string:
+		*s.n = len(src)
+	case nil:
+		*s.n = 0
+	default:
+		return fmt.Errorf("cannot scan the length of %T into *int", src)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"unicode/utf8"
+)
+
+// BlobAsString returns a scanner that reads a BLOB or TEXT column into s, for use with sql.Rows.Scan,
+// for columns where UTF-8 text has been stored as BLOB. Scanning fails if the bytes are not valid UTF-8,
+// so binary data isn't silently turned into a broken string.
+func BlobAsString(s *string) sql.Scanner {
+	return blobStringScanner{s: s}
+}
+
+type blobStringScanner struct {
+	s *string
+}
+
+// Scan satisfies sql.Scanner.
+func (b blobStringScanner) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		if !utf8.Valid(src) {
+			return fmt.Errorf("cannot scan BLOB into *string: not valid UTF-8")
+		}
+		*b.s = string(src)
+	case string:
+		*b.s = src
+	case nil:
+		return fmt.Errorf("cannot scan NULL into *string")
+	default:
+		return fmt.Errorf("cannot scan %T into *string", src)
+	}
+	return nil
+}
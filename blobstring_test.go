@@ -0,0 +1,39 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestBlobAsString(t *testing.T) {
+	t.Run("scans a BLOB containing UTF-8 text into a string", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v blob not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (?)`, []byte("blåbærgrød"))
+		assert.NoErr(t, err)
+
+		var typ string
+		err = db.QueryRow(`select typeof(v) from t`).Scan(&typ)
+		assert.NoErr(t, err)
+		assert.Equal(t, "blob", typ)
+
+		var s string
+		err = db.QueryRow(`select v from t`).Scan(sqlite.BlobAsString(&s))
+		assert.NoErr(t, err)
+		assert.Equal(t, "blåbærgrød", s)
+	})
+
+	t.Run("errors on invalid UTF-8", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var s string
+		err := db.QueryRow(`select x'ff00fe'`).Scan(sqlite.BlobAsString(&s))
+		assert.Err(t, err)
+	})
+}
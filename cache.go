@@ -0,0 +1,28 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"database/sql"
+)
+
+// CacheFlush writes all dirty pages in the page cache of conn to the database file (or WAL file),
+// without committing the current transaction or checkpointing.
+//
+// This is only useful in very large write transactions, to free memory held by dirty pages mid-transaction.
+// It doesn't make anything durable: the changes are still rolled back if the transaction is.
+// If another connection holds a lock that prevents writing, the pages are left in the cache and an SQLITE_BUSY error is returned.
+// See https://www.sqlite.org/c3ref/db_cacheflush.html
+func CacheFlush(conn *sql.Conn) error {
+	return withConnection(conn, func(c *connection) error {
+		if cCode := C.sqlite3_db_cacheflush(c.cC); cCode != C.SQLITE_OK {
+			return c.wrapErrorCode("error flushing cache", cCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestCacheFlush(t *testing.T) {
+	t.Run("flushes mid-transaction and commits successfully", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v blob not null)`)
+		assert.NoErr(t, err)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		_, err = conn.ExecContext(context.Background(), `begin`)
+		assert.NoErr(t, err)
+
+		insert := `with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t select randomblob(1024) from n`
+		_, err = conn.ExecContext(context.Background(), insert)
+		assert.NoErr(t, err)
+
+		err = sqlite.CacheFlush(conn)
+		assert.NoErr(t, err)
+
+		_, err = conn.ExecContext(context.Background(), insert)
+		assert.NoErr(t, err)
+
+		_, err = conn.ExecContext(context.Background(), `commit`)
+		assert.NoErr(t, err)
+
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2000, count)
+	})
+}
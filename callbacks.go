@@ -0,0 +1,36 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+// This file contains the Go functions called from C. Files with //export directives can't have definitions
+// in their preamble, so they are kept separate from the rest of the code.
+
+//export goFunction
+func goFunction(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	callFunction(ctx, argc, argv)
+}
+
+//export goFunctionDestroy
+func goFunctionDestroy(p unsafe.Pointer) {
+	cgo.Handle(uintptr(p)).Delete()
+}
+
+//export goCollation
+func goCollation(p unsafe.Pointer, n1 C.int, s1 unsafe.Pointer, n2 C.int, s2 unsafe.Pointer) C.int {
+	return callCollation(p, n1, s1, n2, s2)
+}
+
+//export goAutoExtension
+func goAutoExtension(cC *C.sqlite3, pzErrMsg **C.char, pThunk unsafe.Pointer) C.int {
+	return runAutoExtensions(cC, pzErrMsg)
+}
@@ -0,0 +1,199 @@
+//go:build cgo
+
+// This is a cut-down version of the carray extension from the SQLite source tree (ext/misc/carray.c),
+// which is in the public domain. It only supports the single-argument form carray(?), with the parameter
+// bound using my_carray_bind_*, and integer, float, and text arrays.
+// See https://www.sqlite.org/carray.html
+
+#include <string.h>
+#include "sqlite3.h"
+#include "carray.h"
+
+// carray_bind is the pointer bound to the parameter, allocated in one block together with its values.
+typedef struct carray_bind {
+	int eType;
+	int nData;
+	void *aData;  // sqlite3_int64*, double*, or char** depending on eType
+	int *anText;  // Byte lengths of the strings, for CARRAY_TEXT
+} carray_bind;
+
+typedef struct carray_cursor {
+	sqlite3_vtab_cursor base;
+	sqlite3_int64 iRowid;
+	carray_bind *pBind;
+} carray_cursor;
+
+#define CARRAY_COLUMN_VALUE   0
+#define CARRAY_COLUMN_POINTER 1
+
+static int carrayConnect(sqlite3 *db, void *pAux, int argc, const char *const *argv, sqlite3_vtab **ppVtab, char **pzErr) {
+	int rc = sqlite3_declare_vtab(db, "CREATE TABLE x(value, pointer HIDDEN)");
+	if (rc != SQLITE_OK) {
+		return rc;
+	}
+	sqlite3_vtab *pNew = sqlite3_malloc(sizeof(*pNew));
+	if (pNew == 0) {
+		return SQLITE_NOMEM;
+	}
+	memset(pNew, 0, sizeof(*pNew));
+	*ppVtab = pNew;
+	return SQLITE_OK;
+}
+
+static int carrayDisconnect(sqlite3_vtab *pVtab) {
+	sqlite3_free(pVtab);
+	return SQLITE_OK;
+}
+
+static int carrayOpen(sqlite3_vtab *p, sqlite3_vtab_cursor **ppCursor) {
+	carray_cursor *pCur = sqlite3_malloc(sizeof(*pCur));
+	if (pCur == 0) {
+		return SQLITE_NOMEM;
+	}
+	memset(pCur, 0, sizeof(*pCur));
+	*ppCursor = &pCur->base;
+	return SQLITE_OK;
+}
+
+static int carrayClose(sqlite3_vtab_cursor *cur) {
+	sqlite3_free(cur);
+	return SQLITE_OK;
+}
+
+static int carrayNext(sqlite3_vtab_cursor *cur) {
+	((carray_cursor *)cur)->iRowid++;
+	return SQLITE_OK;
+}
+
+static int carrayColumn(sqlite3_vtab_cursor *cur, sqlite3_context *ctx, int i) {
+	carray_cursor *pCur = (carray_cursor *)cur;
+	if (i != CARRAY_COLUMN_VALUE) {
+		return SQLITE_OK;
+	}
+	carray_bind *pBind = pCur->pBind;
+	sqlite3_int64 n = pCur->iRowid - 1;
+	switch (pBind->eType) {
+	case CARRAY_INT64:
+		sqlite3_result_int64(ctx, ((sqlite3_int64 *)pBind->aData)[n]);
+		break;
+	case CARRAY_DOUBLE:
+		sqlite3_result_double(ctx, ((double *)pBind->aData)[n]);
+		break;
+	case CARRAY_TEXT:
+		sqlite3_result_text(ctx, ((char **)pBind->aData)[n], pBind->anText[n], SQLITE_TRANSIENT);
+		break;
+	}
+	return SQLITE_OK;
+}
+
+static int carrayRowid(sqlite3_vtab_cursor *cur, sqlite_int64 *pRowid) {
+	*pRowid = ((carray_cursor *)cur)->iRowid;
+	return SQLITE_OK;
+}
+
+static int carrayEof(sqlite3_vtab_cursor *cur) {
+	carray_cursor *pCur = (carray_cursor *)cur;
+	return pCur->pBind == 0 || pCur->iRowid > pCur->pBind->nData;
+}
+
+static int carrayFilter(sqlite3_vtab_cursor *cur, int idxNum, const char *idxStr, int argc, sqlite3_value **argv) {
+	carray_cursor *pCur = (carray_cursor *)cur;
+	pCur->pBind = 0;
+	if (idxNum == 1) {
+		pCur->pBind = sqlite3_value_pointer(argv[0], "carray-bind");
+	}
+	pCur->iRowid = 1;
+	return SQLITE_OK;
+}
+
+// carrayBestIndex requires an equality constraint on the hidden pointer column, which is what carray(?) gives.
+static int carrayBestIndex(sqlite3_vtab *tab, sqlite3_index_info *pIdxInfo) {
+	int iPtr = -1;
+	const struct sqlite3_index_constraint *pConstraint = pIdxInfo->aConstraint;
+	for (int i = 0; i < pIdxInfo->nConstraint; i++, pConstraint++) {
+		if (pConstraint->usable && pConstraint->op == SQLITE_INDEX_CONSTRAINT_EQ &&
+		    pConstraint->iColumn == CARRAY_COLUMN_POINTER) {
+			iPtr = i;
+		}
+	}
+	if (iPtr < 0) {
+		// Without a pointer there are no rows, so make this plan very unattractive
+		pIdxInfo->estimatedCost = (double)2147483647;
+		pIdxInfo->estimatedRows = 2147483647;
+		pIdxInfo->idxNum = 0;
+		return SQLITE_OK;
+	}
+	pIdxInfo->aConstraintUsage[iPtr].argvIndex = 1;
+	pIdxInfo->aConstraintUsage[iPtr].omit = 1;
+	pIdxInfo->estimatedCost = (double)1;
+	pIdxInfo->estimatedRows = 100;
+	pIdxInfo->idxNum = 1;
+	return SQLITE_OK;
+}
+
+static sqlite3_module carrayModule = {
+	0,                 // iVersion
+	0,                 // xCreate, which is null so the table is eponymous-only
+	carrayConnect,     // xConnect
+	carrayBestIndex,   // xBestIndex
+	carrayDisconnect,  // xDisconnect
+	0,                 // xDestroy
+	carrayOpen,        // xOpen
+	carrayClose,       // xClose
+	carrayFilter,      // xFilter
+	carrayNext,        // xNext
+	carrayEof,         // xEof
+	carrayColumn,      // xColumn
+	carrayRowid,       // xRowid
+};
+
+int my_carray_init(sqlite3 *db) {
+	return sqlite3_create_module(db, "carray", &carrayModule, 0);
+}
+
+// my_carray_bind_numbers binds a copy of the nData 8-byte integers or floats at aData to parameter i.
+int my_carray_bind_numbers(sqlite3_stmt *pStmt, int i, void *aData, int nData, int eType) {
+	size_t sz = (size_t)nData * 8;
+	carray_bind *pBind = sqlite3_malloc64(sizeof(carray_bind) + sz);
+	if (pBind == 0) {
+		return SQLITE_NOMEM;
+	}
+	pBind->eType = eType;
+	pBind->nData = nData;
+	pBind->aData = (void *)&pBind[1];
+	pBind->anText = 0;
+	if (sz > 0) {
+		memcpy(pBind->aData, aData, sz);
+	}
+	return sqlite3_bind_pointer(pStmt, i, pBind, "carray-bind", sqlite3_free);
+}
+
+// my_carray_bind_text binds a copy of nData strings to parameter i. The strings are concatenated in zData,
+// with their byte lengths in anData, so they can contain NUL bytes.
+int my_carray_bind_text(sqlite3_stmt *pStmt, int i, char *zData, int *anData, int nData) {
+	size_t szText = 0;
+	for (int j = 0; j < nData; j++) {
+		szText += (size_t)anData[j];
+	}
+	size_t sz = sizeof(carray_bind) + (size_t)nData * (sizeof(char *) + sizeof(int)) + szText;
+	carray_bind *pBind = sqlite3_malloc64(sz);
+	if (pBind == 0) {
+		return SQLITE_NOMEM;
+	}
+	pBind->eType = CARRAY_TEXT;
+	pBind->nData = nData;
+	char **azText = (char **)&pBind[1];
+	pBind->aData = azText;
+	pBind->anText = (int *)&azText[nData];
+	char *z = (char *)&pBind->anText[nData];
+	for (int j = 0; j < nData; j++) {
+		azText[j] = z;
+		pBind->anText[j] = anData[j];
+		if (anData[j] > 0) {
+			memcpy(z, zData, (size_t)anData[j]);
+		}
+		z += anData[j];
+		zData += anData[j];
+	}
+	return sqlite3_bind_pointer(pStmt, i, pBind, "carray-bind", sqlite3_free);
+}
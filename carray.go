@@ -0,0 +1,47 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+#include "carray.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// createCArray creates the carray table-valued function on the connection.
+func createCArray(cC *C.sqlite3) error {
+	if cCode := C.my_carray_init(cC); cCode != C.SQLITE_OK {
+		return wrapError("error creating carray module", newError(cC, cCode))
+	}
+	return nil
+}
+
+// bindArray binds a copy of the values in a to the parameter at idx.
+func bindArray(cStatement *C.sqlite3_stmt, idx C.int, a CArray) (C.int, error) {
+	switch v := a.values.(type) {
+	case []int64:
+		return C.my_carray_bind_numbers(cStatement, idx, unsafe.Pointer(unsafe.SliceData(v)), C.int(len(v)), C.CARRAY_INT64), nil
+	case []float64:
+		return C.my_carray_bind_numbers(cStatement, idx, unsafe.Pointer(unsafe.SliceData(v)), C.int(len(v)), C.CARRAY_DOUBLE), nil
+	case []string:
+		// Concatenate the strings, so C gets one buffer and a slice of lengths. These are Go pointers,
+		// which cgo only allows because the memory they point to holds no Go pointers itself, unlike a []string.
+		var b strings.Builder
+		lengths := make([]C.int, len(v))
+		for i, s := range v {
+			b.WriteString(s)
+			lengths[i] = C.int(len(s))
+		}
+		text := b.String()
+		return C.my_carray_bind_text(cStatement, idx, (*C.char)(unsafe.Pointer(unsafe.StringData(text))),
+			(*C.int)(unsafe.SliceData(lengths)), C.int(len(v))), nil
+	default:
+		return C.SQLITE_OK, fmt.Errorf("unsupported array type %T", v)
+	}
+}
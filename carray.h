@@ -0,0 +1,10 @@
+#include "sqlite3.h"
+
+// Value types of a bound carray.
+#define CARRAY_INT64  1
+#define CARRAY_DOUBLE 2
+#define CARRAY_TEXT   3
+
+int my_carray_init(sqlite3 *db);
+int my_carray_bind_numbers(sqlite3_stmt *pStmt, int i, void *aData, int nData, int eType);
+int my_carray_bind_text(sqlite3_stmt *pStmt, int i, char *zData, int *anData, int nData);
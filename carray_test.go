@@ -0,0 +1,82 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestArray(t *testing.T) {
+	t.Run("binds integers for an IN clause", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table users (id integer primary key, name text not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into users (name) values ('a'), ('b'), ('c'), ('d')`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select name from users where id in carray(?) order by id`, sqlite.Array([]int64{1, 3, 5}))
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var names []string
+		for rows.Next() {
+			var name string
+			assert.NoErr(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, 2, len(names))
+		assert.Equal(t, "a", names[0])
+		assert.Equal(t, "c", names[1])
+	})
+
+	t.Run("binds floats and strings", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var sum float64
+		err := db.QueryRow(`select sum(value) from carray(?)`, sqlite.Array([]float64{0.5, 1.25})).Scan(&sum)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1.75, sum)
+
+		var joined string
+		err = db.QueryRow(`select group_concat(value, ',') from carray(?)`, sqlite.Array([]string{"a", "", "b\x00c"})).Scan(&joined)
+		assert.NoErr(t, err)
+		assert.Equal(t, "a,,b\x00c", joined)
+	})
+
+	t.Run("matches nothing for an empty slice", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var count int
+		err := db.QueryRow(`select count(*) from carray(?)`, sqlite.Array([]string(nil))).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+
+		err = db.QueryRow(`select count(*) where 1 in carray(?)`, sqlite.Array([]int64{})).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("can be rebound in a prepared statement", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		stmt, err := db.Prepare(`select count(*) from carray(?)`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = stmt.Close()
+		}()
+
+		for _, n := range []int{1, 3, 2} {
+			var count int
+			err := stmt.QueryRow(sqlite.Array(make([]int64, n))).Scan(&count)
+			assert.NoErr(t, err)
+			assert.Equal(t, n, count)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// CheckpointMode is the mode of a WAL checkpoint.
+// See https://www.sqlite.org/pragma.html#pragma_wal_checkpoint
+type CheckpointMode string
+
+const (
+	CheckpointPassive  = CheckpointMode("PASSIVE")
+	CheckpointFull     = CheckpointMode("FULL")
+	CheckpointRestart  = CheckpointMode("RESTART")
+	CheckpointTruncate = CheckpointMode("TRUNCATE")
+)
+
+func (m CheckpointMode) String() string {
+	return string(m)
+}
+
+// CheckpointResult is the result of a WAL checkpoint.
+type CheckpointResult struct {
+	// Busy is true if the checkpoint could not complete because of other connections reading or writing.
+	Busy bool
+	// LogFrames is the number of frames in the WAL.
+	LogFrames int
+	// CheckpointedFrames is the number of frames in the WAL that have been written back to the database file.
+	CheckpointedFrames int
+}
+
+// Checkpoint runs a WAL checkpoint with the given mode.
+func Checkpoint(ctx context.Context, db *sql.DB, mode CheckpointMode) (CheckpointResult, error) {
+	var r CheckpointResult
+	var busy int
+	if err := db.QueryRowContext(ctx, "pragma wal_checkpoint("+mode.String()+")").Scan(&busy, &r.LogFrames, &r.CheckpointedFrames); err != nil {
+		return r, wrapError("error running %v checkpoint", err, mode)
+	}
+	r.Busy = busy == 1
+	return r, nil
+}
+
+// CheckpointCoordinator runs checkpoints on the single writer connection of a database,
+// and coordinates with readers so that a RESTART checkpoint can occasionally succeed.
+//
+// Checkpoints run through the writer, which should have db.SetMaxOpenConns(1), so they only run
+// when no write transaction is open. Passive checkpoints can't write back WAL frames that readers still need,
+// and can't restart the WAL from the beginning while any reader is active, so under continuous reads the WAL grows without bound.
+// To prevent that, readers run through Read, and ForceRestart briefly holds back new readers until the
+// current ones are done, and then restarts and truncates the WAL.
+type CheckpointCoordinator struct {
+	writer  *sql.DB
+	readers sync.RWMutex
+}
+
+// NewCheckpointCoordinator for the given single writer.
+func NewCheckpointCoordinator(writer *sql.DB) *CheckpointCoordinator {
+	return &CheckpointCoordinator{writer: writer}
+}
+
+// Read runs fn, which should do its reads and return when done.
+// It waits while a forced restart checkpoint is running.
+func (c *CheckpointCoordinator) Read(fn func() error) error {
+	c.readers.RLock()
+	defer c.readers.RUnlock()
+	return fn()
+}
+
+// Checkpoint runs a passive checkpoint, which never waits for readers or blocks them.
+func (c *CheckpointCoordinator) Checkpoint(ctx context.Context) (CheckpointResult, error) {
+	return Checkpoint(ctx, c.writer, CheckpointPassive)
+}
+
+// ForceRestart waits for current readers to finish while holding back new ones, and runs a TRUNCATE checkpoint,
+// which writes back all frames, restarts the WAL from the beginning, and truncates the WAL file.
+func (c *CheckpointCoordinator) ForceRestart(ctx context.Context) (CheckpointResult, error) {
+	c.readers.Lock()
+	defer c.readers.Unlock()
+	return Checkpoint(ctx, c.writer, CheckpointTruncate)
+}
+
+// Run passive checkpoints every interval, and a forced restart every restartEvery checkpoints, until ctx is done.
+// Errors are returned immediately, except for the context being done, which returns nil.
+func (c *CheckpointCoordinator) Run(ctx context.Context, interval time.Duration, restartEvery int) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 1; ; n++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var err error
+		if restartEvery > 0 && n%restartEvery == 0 {
+			_, err = c.ForceRestart(ctx)
+		} else {
+			_, err = c.Checkpoint(ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,83 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestCheckpointCoordinator(t *testing.T) {
+	t.Run("forced restart succeeds and truncates the WAL under continuous reads", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		writer := openPath(t, sqlite.Options{}, p)
+		writer.SetMaxOpenConns(1)
+		readers := openPath(t, sqlite.Options{}, p)
+
+		_, err := writer.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+		for i := 0; i < 100; i++ {
+			_, err = writer.Exec(`insert into t values (?)`, "some data to grow the WAL")
+			assert.NoErr(t, err)
+		}
+
+		c := sqlite.NewCheckpointCoordinator(writer)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		var reads atomic.Int64
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					err := c.Read(func() error {
+						rows, err := readers.Query(`select v from t`)
+						if err != nil {
+							return err
+						}
+						for rows.Next() {
+						}
+						reads.Add(1)
+						return rows.Close()
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+
+		// Let the readers get going
+		for reads.Load() < 10 {
+			time.Sleep(time.Millisecond)
+		}
+
+		info, err := os.Stat(p + "-wal")
+		assert.NoErr(t, err)
+		assert.Equal(t, true, info.Size() > 0)
+
+		_, err = c.Checkpoint(context.Background())
+		assert.NoErr(t, err)
+
+		r, err := c.ForceRestart(context.Background())
+		assert.NoErr(t, err)
+		assert.Equal(t, false, r.Busy)
+
+		info, err = os.Stat(p + "-wal")
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(0), info.Size())
+
+		cancel()
+		wg.Wait()
+	})
+}
@@ -0,0 +1,97 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+#include <sqlite3.h>
+
+extern int goCollation(void *p, int n1, void *s1, int n2, void *s2);
+extern void goFunctionDestroy(void *p);
+
+static int my_create_collation(sqlite3 *db, char *name, uintptr_t handle) {
+	return sqlite3_create_collation_v2(db, name, SQLITE_UTF8, (void *)handle,
+		(int (*)(void *, int, const void *, int, const void *))goCollation, goFunctionDestroy);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+var (
+	collations     = map[string]map[string]func(a, b string) int{}
+	collationsLock sync.RWMutex
+)
+
+// RegisterCollation registers a collation called name for all connections opened by the driver registered
+// as driverName with RegisterDriver, for use in COLLATE clauses and column definitions.
+// compare must return a negative number if a sorts before b, zero if they are equal, and a positive number otherwise,
+// and must be safe for concurrent use.
+//
+// Collations only apply to connections opened after registration, so call RegisterCollation before
+// opening any databases.
+// See https://www.sqlite.org/c3ref/create_collation.html
+func RegisterCollation(driverName, name string, compare func(a, b string) int) error {
+	if name == "" {
+		return errors.New("collation name cannot be empty")
+	}
+	if compare == nil {
+		return errors.New("collation compare function cannot be nil")
+	}
+
+	collationsLock.Lock()
+	defer collationsLock.Unlock()
+	if collations[driverName] == nil {
+		collations[driverName] = map[string]func(a, b string) int{}
+	}
+	collations[driverName][name] = compare
+	return nil
+}
+
+// createCollations creates the collations registered for driverName on a new connection.
+func createCollations(cC *C.sqlite3, driverName string) error {
+	collationsLock.RLock()
+	defer collationsLock.RUnlock()
+
+	for name, compare := range collations[driverName] {
+		if err := createCollation(cC, name, compare); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createCollation(cC *C.sqlite3, name string, compare func(a, b string) int) error {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	// The handle is deleted by SQLite calling goFunctionDestroy when the collation is replaced or the connection is closed,
+	// also if creating the collation fails.
+	h := cgo.NewHandle(compare)
+	if cCode := C.my_create_collation(cC, cName, C.uintptr_t(h)); cCode != C.SQLITE_OK {
+		return wrapError("error creating collation %v", newError(cC, cCode), name)
+	}
+	return nil
+}
+
+// callCollation is called from goCollation to compare two strings.
+func callCollation(p unsafe.Pointer, n1 C.int, s1 unsafe.Pointer, n2 C.int, s2 unsafe.Pointer) C.int {
+	compare := cgo.Handle(uintptr(p)).Value().(func(a, b string) int)
+	a := C.GoStringN((*C.char)(s1), n1)
+	b := C.GoStringN((*C.char)(s2), n2)
+
+	switch c := compare(a, b); {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	default:
+		return 0
+	}
+}
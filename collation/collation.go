@@ -0,0 +1,33 @@
+// Package collation provides locale-aware collations for the sqlite driver, using golang.org/x/text/collate.
+// It's a separate package so the golang.org/x/text dependency is only compiled in when needed.
+package collation
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"github.com/maragudk/sqlite"
+)
+
+// RegisterCollateLocale registers a collation called name for the driver registered as driverName,
+// which sorts text according to the rules of locale, given as a BCP 47 language tag such as "sv" or "de-DE".
+// See sqlite.RegisterCollation.
+func RegisterCollateLocale(driverName, name, locale string) error {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return fmt.Errorf("error parsing locale %v: %w", locale, err)
+	}
+
+	// Collators are not safe for concurrent use, so guard it
+	c := collate.New(tag)
+	var lock sync.Mutex
+
+	return sqlite.RegisterCollation(driverName, name, func(a, b string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return c.CompareString(a, b)
+	})
+}
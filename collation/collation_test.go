@@ -0,0 +1,56 @@
+//go:build cgo
+
+package collation_test
+
+import (
+	"database/sql"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/collation"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestRegisterCollateLocale(t *testing.T) {
+	t.Run("sorts Swedish letters after z", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{Name: name})
+
+		err := collation.RegisterCollateLocale(name, "swedish", "sv")
+		assert.NoErr(t, err)
+
+		db, err := sql.Open(name, path.Join(t.TempDir(), "app.db"))
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`create table words (w text not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into words values ('ö'), ('a'), ('ä'), ('z'), ('å'), ('o')`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select w from words order by w collate swedish`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var words []string
+		for rows.Next() {
+			var w string
+			err := rows.Scan(&w)
+			assert.NoErr(t, err)
+			words = append(words, w)
+		}
+		assert.NoErr(t, rows.Err())
+
+		assert.Equal(t, "a o z å ä ö", strings.Join(words, " "))
+	})
+
+	t.Run("errors on invalid locale", func(t *testing.T) {
+		err := collation.RegisterCollateLocale("sqlite", "nope", "not a locale!")
+		assert.Err(t, err)
+	})
+}
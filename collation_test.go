@@ -0,0 +1,53 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestRegisterCollation(t *testing.T) {
+	t.Run("creates the collation on connections of the driver", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{Name: name})
+
+		// Sorts by length, then alphabetically
+		err := sqlite.RegisterCollation(name, "length", func(a, b string) int {
+			if len(a) != len(b) {
+				return len(a) - len(b)
+			}
+			return strings.Compare(a, b)
+		})
+		assert.NoErr(t, err)
+
+		db, err := sql.Open(name, path.Join(t.TempDir(), "app.db"))
+		assert.NoErr(t, err)
+
+		var words string
+		err = db.QueryRow(`
+			with words(w) as (values ('ccc'), ('a'), ('bb'), ('b'))
+			select group_concat(w, ' ') from (select w from words order by w collate length)`).Scan(&words)
+		assert.NoErr(t, err)
+		assert.Equal(t, "a b bb ccc", words)
+	})
+
+	t.Run("is not available on connections of other drivers", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`select 'a' = 'b' collate length`)
+		assert.Err(t, err)
+	})
+
+	t.Run("errors on empty name", func(t *testing.T) {
+		err := sqlite.RegisterCollation("sqlite", "", strings.Compare)
+		assert.Err(t, err)
+	})
+}
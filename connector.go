@@ -0,0 +1,80 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// OpenDB opens the database at path with opts, without registering a driver, so databases with different options
+// can be opened without coming up with unique driver names. Options.Name is only used to find the collations
+// registered with RegisterCollation. A connection is opened to check the options, so errors are returned early.
+func OpenDB(path string, opts Options) (*sql.DB, error) {
+	db := sql.OpenDB(NewConnector(path, opts))
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, wrapError("error opening database %v", err, path)
+	}
+	return db, nil
+}
+
+// NewConnector returns a connector to the database at name with opts, for use with sql.OpenDB,
+// without registering a driver. Options.Name is only used to find the collations registered with RegisterCollation.
+func NewConnector(name string, opts Options) driver.Connector {
+	return &connector{d: newDriver(withDefaults(opts)), name: name}
+}
+
+// OpenConnector returns a connector to the database at name, so that database/sql
+// doesn't pass the name to Open for every new connection.
+//
+// Query parameters in name starting with an underscore override the driver options for this database only:
+// _busy_timeout in milliseconds, _cell_size_check, _foreign_keys, _journal_mode, _locking_mode, and _transaction_mode.
+// Booleans are 1, on, true, or yes, or 0, off, false, or no. For example, "file:app.db?_journal_mode=wal&_foreign_keys=on".
+// Other query parameters are passed on to SQLite. The options share the Options.MaxWriters slots of the driver.
+func (d *d) OpenConnector(name string) (driver.Connector, error) {
+	name, opts, ok, err := parseDSN(name, d.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each database gets its own copy of the driver, so that Shutdown only affects that database
+	dbDriver := *d
+	dbDriver.inFlight = &inFlight{}
+	if ok {
+		dbDriver.opts = opts
+		dbDriver.pragmas = openPragmas(opts)
+	}
+	return &connector{d: &dbDriver, name: name}, nil
+}
+
+// connector opens connections to a single database,
+// running extra pragmas on each new connection.
+// connector satisfies driver.Connector.
+type connector struct {
+	d       *d
+	name    string
+	pragmas []string
+}
+
+// Connect returns a connection to the database.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.d.open(c.name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pragma := range c.pragmas {
+		if err := conn.(*connection).exec("pragma %v", pragma); err != nil {
+			_ = conn.Close()
+			return nil, wrapError("error setting pragma", err)
+		}
+	}
+	return conn, nil
+}
+
+// Driver returns the underlying Driver of the Connector.
+func (c *connector) Driver() driver.Driver {
+	return c.d
+}
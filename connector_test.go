@@ -0,0 +1,83 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"path"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestOpenDB(t *testing.T) {
+	t.Run("opens databases with different options", func(t *testing.T) {
+		dir := t.TempDir()
+
+		db1, err := sqlite.OpenDB(path.Join(dir, "one.db"), sqlite.Options{})
+		assert.NoErr(t, err)
+		defer func() {
+			_ = db1.Close()
+		}()
+
+		noForeignKeys := false
+		db2, err := sqlite.OpenDB(path.Join(dir, "two.db"), sqlite.Options{ForeignKeys: &noForeignKeys})
+		assert.NoErr(t, err)
+		defer func() {
+			_ = db2.Close()
+		}()
+
+		var foreignKeys bool
+		err = db1.QueryRow(`pragma foreign_keys`).Scan(&foreignKeys)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, foreignKeys)
+
+		err = db2.QueryRow(`pragma foreign_keys`).Scan(&foreignKeys)
+		assert.NoErr(t, err)
+		assert.Equal(t, false, foreignKeys)
+	})
+
+	t.Run("errors if the database can't be opened", func(t *testing.T) {
+		_, err := sqlite.OpenDB(path.Join(t.TempDir(), "nope", "app.db"), sqlite.Options{})
+		assert.Err(t, err)
+	})
+}
+
+func TestNewConnector(t *testing.T) {
+	t.Run("opens a database with options without registering a driver", func(t *testing.T) {
+		db := sql.OpenDB(sqlite.NewConnector(path.Join(t.TempDir(), "app.db"), sqlite.Options{JournalMode: sqlite.JournalModeTruncate}))
+		defer func() {
+			_ = db.Close()
+		}()
+
+		var journalMode string
+		err := db.QueryRow(`pragma journal_mode`).Scan(&journalMode)
+		assert.NoErr(t, err)
+		assert.Equal(t, "truncate", journalMode)
+
+		var foreignKeys bool
+		err = db.QueryRow(`pragma foreign_keys`).Scan(&foreignKeys)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, foreignKeys)
+	})
+}
+
+func TestDriver_OpenConnector(t *testing.T) {
+	t.Run("returns a connector for the database", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		c, ok := db.Driver().(driver.DriverContext)
+		assert.Equal(t, true, ok)
+
+		connector, err := c.OpenConnector(path.Join(t.TempDir(), "other.db"))
+		assert.NoErr(t, err)
+
+		other := sql.OpenDB(connector)
+		defer func() {
+			_ = other.Close()
+		}()
+		assert.NoErr(t, other.Ping())
+	})
+}
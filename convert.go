@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+var (
+	bindConverters     = map[reflect.Type]func(v any) (driver.Value, error){}
+	bindConvertersLock sync.RWMutex
+)
+
+// RegisterBindConverter registers convert to be used when binding values of type T as query arguments,
+// for all drivers in the process. It takes precedence over T implementing driver.Valuer, so it can be used to
+// bind types from other packages in a specific way. convert must return one of the types a driver.Value can be.
+// Registering a converter for the same type again replaces it.
+//
+// Scanning is not affected, so use a sql.Scanner for that.
+func RegisterBindConverter[T any](convert func(v T) (driver.Value, error)) {
+	bindConvertersLock.Lock()
+	defer bindConvertersLock.Unlock()
+	bindConverters[reflect.TypeOf((*T)(nil)).Elem()] = func(v any) (driver.Value, error) {
+		return convert(v.(T))
+	}
+}
+
+// convertBindValue converts v with a converter registered with RegisterBindConverter,
+// and returns false if there is none for the type of v.
+func convertBindValue(v any) (driver.Value, bool, error) {
+	if v == nil {
+		return nil, false, nil
+	}
+
+	bindConvertersLock.RLock()
+	convert, ok := bindConverters[reflect.TypeOf(v)]
+	bindConvertersLock.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	value, err := convert(v)
+	return value, true, err
+}
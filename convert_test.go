@@ -0,0 +1,29 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+type shout string
+
+func TestRegisterBindConverter(t *testing.T) {
+	t.Run("converts values of the type when binding", func(t *testing.T) {
+		sqlite.RegisterBindConverter(func(v shout) (driver.Value, error) {
+			return strings.ToUpper(string(v)) + "!", nil
+		})
+
+		db := open(t, sqlite.Options{})
+
+		var s string
+		err := db.QueryRow(`select ?`, shout("hello")).Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "HELLO!", s)
+	})
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default is the declared DEFAULT of a table column.
+type Default struct {
+	// Expr is the default expression as declared in the schema, from the dflt_value column of pragma table_info.
+	Expr string
+	// Valid is false if the column has no declared default.
+	Valid bool
+}
+
+// ColumnDefault returns the declared default of the given column in table.
+// See https://www.sqlite.org/pragma.html#pragma_table_info
+func ColumnDefault(ctx context.Context, db *sql.DB, table, column string) (Default, error) {
+	var expr sql.NullString
+	query := `select dflt_value from pragma_table_info(?) where name = ?`
+	if err := db.QueryRowContext(ctx, query, table, column).Scan(&expr); err != nil {
+		if err == sql.ErrNoRows {
+			return Default{}, fmt.Errorf("no column %v in table %v", column, table)
+		}
+		return Default{}, wrapError("error getting default for column %v in table %v", err, column, table)
+	}
+	return Default{Expr: expr.String, Valid: expr.Valid}, nil
+}
+
+// Eval evaluates a constant default to a Go value, without involving the database.
+// Integers are returned as int64, reals as float64, strings as string, blobs as []byte,
+// and NULL (or no default at all) as nil.
+// CURRENT_TIMESTAMP and CURRENT_DATE are returned as the current UTC time.Time,
+// truncated to the second or day like SQLite does, and CURRENT_TIME as the current UTC time as "HH:MM:SS" text.
+// Only a single literal, optionally signed if it's a number and in parentheses, is a constant.
+// Other expressions, such as (random()) or 'a' || 'b', return an error.
+func (d Default) Eval() (any, error) {
+	if !d.Valid {
+		return nil, nil
+	}
+
+	expr := strings.TrimSpace(d.Expr)
+	for len(expr) >= 2 && expr[0] == '(' && expr[len(expr)-1] == ')' {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+
+	switch strings.ToUpper(expr) {
+	case "NULL":
+		return nil, nil
+	case "TRUE":
+		return int64(1), nil
+	case "FALSE":
+		return int64(0), nil
+	case "CURRENT_TIMESTAMP":
+		return time.Now().UTC().Truncate(time.Second), nil
+	case "CURRENT_DATE":
+		now := time.Now().UTC()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	case "CURRENT_TIME":
+		return time.Now().UTC().Format("15:04:05"), nil
+	}
+
+	if s, ok := unquote(expr); ok {
+		return s, nil
+	}
+
+	if len(expr) >= 1 && (expr[0] == 'x' || expr[0] == 'X') {
+		if s, ok := unquote(expr[1:]); ok {
+			b, err := hex.DecodeString(s)
+			if err != nil {
+				return nil, wrapError("error decoding blob default %v", err, d.Expr)
+			}
+			return b, nil
+		}
+	}
+
+	if numberLiteral.MatchString(expr) {
+		if i, err := parseInt(expr); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(expr, 64); err == nil {
+			return f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("default %v is not a constant", d.Expr)
+}
+
+// parseInt parses decimal and hexadecimal integer literals the way SQLite does.
+// In particular, a leading zero does not mean octal, and hexadecimal literals are 64-bit two's complement,
+// so for example 0xffffffffffffffff is -1.
+func parseInt(s string) (int64, error) {
+	unsigned := strings.TrimLeft(s, "+-")
+	if len(unsigned) > 2 && (unsigned[:2] == "0x" || unsigned[:2] == "0X") {
+		u, err := strconv.ParseUint(unsigned[2:], 16, 64)
+		if err != nil {
+			return 0, err
+		}
+		i := int64(u)
+		if strings.HasPrefix(s, "-") {
+			i = -i
+		}
+		return i, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// numberLiteral matches an optionally signed decimal or hexadecimal number literal.
+// See https://www.sqlite.org/syntax/numeric-literal.html
+var numberLiteral = regexp.MustCompile(`^[+-]?(0[xX][0-9a-fA-F]+|([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?)$`)
+
+// unquote a single-quoted string literal, where quotes are escaped by doubling them.
+// It returns false if s is not a single string literal, such as 'a' || 'b'.
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", false
+	}
+	s = s[1 : len(s)-1]
+	if strings.Contains(strings.ReplaceAll(s, "''", ""), "'") {
+		return "", false
+	}
+	return strings.ReplaceAll(s, "''", "'"), true
+}
@@ -0,0 +1,114 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestColumnDefault(t *testing.T) {
+	db := open(t, sqlite.Options{})
+
+	_, err := db.Exec(`create table t (
+		i int default 42,
+		n int default -0x10,
+		r real default -1.5,
+		s text default 'it''s',
+		b blob default x'0102',
+		c text default current_timestamp,
+		ct text default current_time,
+		h int default 0xffffffffffffffff,
+		p int default (-(1)),
+		cat text default ('a' || 'b'),
+		e int default (random()),
+		x text
+	)`)
+	assert.NoErr(t, err)
+
+	t.Run("evaluates literal defaults", func(t *testing.T) {
+		tests := []struct {
+			column   string
+			expected any
+		}{
+			{column: "i", expected: int64(42)},
+			{column: "n", expected: int64(-16)},
+			{column: "r", expected: -1.5},
+			{column: "s", expected: "it's"},
+			{column: "h", expected: int64(-1)},
+			{column: "x", expected: nil},
+		}
+
+		for _, test := range tests {
+			t.Run(test.column, func(t *testing.T) {
+				d, err := sqlite.ColumnDefault(context.Background(), db, "t", test.column)
+				assert.NoErr(t, err)
+				v, err := d.Eval()
+				assert.NoErr(t, err)
+				if v != test.expected {
+					t.Fatalf("Not equal, expected %#v, got %#v", test.expected, v)
+				}
+			})
+		}
+	})
+
+	t.Run("evaluates blob default", func(t *testing.T) {
+		d, err := sqlite.ColumnDefault(context.Background(), db, "t", "b")
+		assert.NoErr(t, err)
+		assert.Equal(t, "x'0102'", d.Expr)
+		v, err := d.Eval()
+		assert.NoErr(t, err)
+		assert.EqualBytes(t, []byte{1, 2}, v.([]byte))
+	})
+
+	t.Run("evaluates current_timestamp to the current time", func(t *testing.T) {
+		d, err := sqlite.ColumnDefault(context.Background(), db, "t", "c")
+		assert.NoErr(t, err)
+		assert.Equal(t, true, d.Valid)
+		v, err := d.Eval()
+		assert.NoErr(t, err)
+		ts := v.(time.Time)
+		assert.Equal(t, true, time.Since(ts) < time.Minute)
+	})
+
+	t.Run("evaluates current_time to the current time as text like SQLite", func(t *testing.T) {
+		d, err := sqlite.ColumnDefault(context.Background(), db, "t", "ct")
+		assert.NoErr(t, err)
+		v, err := d.Eval()
+		assert.NoErr(t, err)
+		_, err = time.Parse("15:04:05", v.(string))
+		assert.NoErr(t, err)
+	})
+
+	t.Run("errors on eval for expressions of literals", func(t *testing.T) {
+		for _, column := range []string{"p", "cat"} {
+			d, err := sqlite.ColumnDefault(context.Background(), db, "t", column)
+			assert.NoErr(t, err)
+			_, err = d.Eval()
+			assert.Err(t, err)
+		}
+	})
+
+	t.Run("returns the expression but errors on eval for non-constant default", func(t *testing.T) {
+		d, err := sqlite.ColumnDefault(context.Background(), db, "t", "e")
+		assert.NoErr(t, err)
+		assert.Equal(t, "random()", d.Expr)
+		_, err = d.Eval()
+		assert.Err(t, err)
+	})
+
+	t.Run("reports no default", func(t *testing.T) {
+		d, err := sqlite.ColumnDefault(context.Background(), db, "t", "x")
+		assert.NoErr(t, err)
+		assert.Equal(t, false, d.Valid)
+	})
+
+	t.Run("errors on unknown column", func(t *testing.T) {
+		_, err := sqlite.ColumnDefault(context.Background(), db, "t", "nope")
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDSN returns the database name without driver options, and opts with the driver options in name applied.
+// Driver options are query parameters starting with an underscore, such as
+// "file:app.db?_journal_mode=wal&_busy_timeout=10000&_foreign_keys=on", and are named after the pragma
+// or option they set. Other query parameters are left for SQLite, which only reads them in "file:" URIs.
+// The second return value is false if name has no driver options.
+// See https://www.sqlite.org/uri.html
+func parseDSN(name string, opts Options) (string, Options, bool, error) {
+	base, rawQuery, ok := strings.Cut(name, "?")
+	if !ok || !strings.Contains(rawQuery, "_") {
+		return name, opts, false, nil
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", opts, false, wrapError("error parsing query parameters of %v", err, name)
+	}
+
+	var found bool
+	for key, values := range query {
+		if !strings.HasPrefix(key, "_") {
+			continue
+		}
+		found = true
+		value := values[len(values)-1]
+		delete(query, key)
+
+		if err := applyDSNOption(&opts, strings.TrimPrefix(key, "_"), value); err != nil {
+			return "", opts, false, wrapError("error parsing option %v of %v", err, key, name)
+		}
+	}
+	if !found {
+		return name, opts, false, nil
+	}
+
+	if len(query) > 0 {
+		base += "?" + query.Encode()
+	}
+	return base, opts, true, nil
+}
+
+// applyDSNOption sets the option called key to value in opts.
+func applyDSNOption(opts *Options, key, value string) error {
+	switch key {
+	case "busy_timeout":
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms < 0 {
+			return fmt.Errorf("invalid busy timeout %q, must be a non-negative number of milliseconds", value)
+		}
+		opts.BusyTimeout = ptr(time.Duration(ms) * time.Millisecond)
+
+	case "cell_size_check":
+		v, err := parseDSNBool(value)
+		if err != nil {
+			return err
+		}
+		opts.CellSizeCheck = &v
+
+	case "foreign_keys":
+		v, err := parseDSNBool(value)
+		if err != nil {
+			return err
+		}
+		opts.ForeignKeys = &v
+
+	case "journal_mode":
+		mode := JournalMode(strings.ToLower(value))
+		switch mode {
+		case JournalModeDelete, JournalModeTruncate, JournalModePersist, JournalModeMemory, JournalModeWAL, JournalModeOff:
+			opts.JournalMode = mode
+		default:
+			return fmt.Errorf("unknown journal mode %q", value)
+		}
+
+	case "locking_mode":
+		mode := LockingMode(strings.ToLower(value))
+		switch mode {
+		case LockingModeNormal, LockingModeExclusive:
+			opts.LockingMode = mode
+		default:
+			return fmt.Errorf("unknown locking mode %q", value)
+		}
+
+	case "transaction_mode":
+		mode := TransactionMode(strings.ToLower(value))
+		switch mode {
+		case TransactionModeDeferred, TransactionModeImmediate, TransactionModeExclusive:
+			opts.TransactionMode = mode
+		default:
+			return fmt.Errorf("unknown transaction mode %q", value)
+		}
+
+	default:
+		return fmt.Errorf("unknown option")
+	}
+	return nil
+}
+
+// parseDSNBool parses a boolean option value like SQLite parses boolean pragma values.
+// See https://www.sqlite.org/pragma.html#syntax
+func parseDSNBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "on", "true", "yes":
+		return true, nil
+	case "0", "off", "false", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean %q", value)
+	}
+}
@@ -0,0 +1,96 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestDriver_Open_dsnOptions(t *testing.T) {
+	t.Run("overrides driver options with query parameters", func(t *testing.T) {
+		db := openDSN(t, sqlite.Options{}, "?_journal_mode=truncate&_busy_timeout=10000&_foreign_keys=off")
+
+		var journalMode string
+		err := db.QueryRow(`pragma journal_mode`).Scan(&journalMode)
+		assert.NoErr(t, err)
+		assert.Equal(t, "truncate", journalMode)
+
+		var busyTimeout int
+		err = db.QueryRow(`pragma busy_timeout`).Scan(&busyTimeout)
+		assert.NoErr(t, err)
+		assert.Equal(t, 10000, busyTimeout)
+
+		var foreignKeys bool
+		err = db.QueryRow(`pragma foreign_keys`).Scan(&foreignKeys)
+		assert.NoErr(t, err)
+		assert.Equal(t, false, foreignKeys)
+	})
+
+	t.Run("uses driver options for databases without query parameters", func(t *testing.T) {
+		db := openDSN(t, sqlite.Options{JournalMode: sqlite.JournalModeTruncate}, "")
+
+		var journalMode string
+		err := db.QueryRow(`pragma journal_mode`).Scan(&journalMode)
+		assert.NoErr(t, err)
+		assert.Equal(t, "truncate", journalMode)
+	})
+
+	t.Run("passes other query parameters in file URIs on to SQLite", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		opts := sqlite.Options{Name: strconv.Itoa(int(time.Now().UnixNano()))}
+		sqlite.RegisterDriver(opts)
+
+		db, err := sql.Open(opts.Name, "file:"+p+"?mode=rwc&_journal_mode=truncate")
+		assert.NoErr(t, err)
+		defer func() {
+			_ = db.Close()
+		}()
+
+		var journalMode string
+		err = db.QueryRow(`pragma journal_mode`).Scan(&journalMode)
+		assert.NoErr(t, err)
+		assert.Equal(t, "truncate", journalMode)
+
+		_, err = os.Stat(p)
+		assert.NoErr(t, err)
+	})
+
+	t.Run("errors on open with an unknown option", func(t *testing.T) {
+		opts := sqlite.Options{Name: strconv.Itoa(int(time.Now().UnixNano()))}
+		sqlite.RegisterDriver(opts)
+
+		_, err := sql.Open(opts.Name, path.Join(t.TempDir(), "app.db")+"?_nope=1")
+		assert.Err(t, err)
+	})
+
+	t.Run("errors on open with an invalid option value", func(t *testing.T) {
+		opts := sqlite.Options{Name: strconv.Itoa(int(time.Now().UnixNano()))}
+		sqlite.RegisterDriver(opts)
+
+		_, err := sql.Open(opts.Name, path.Join(t.TempDir(), "app.db")+"?_foreign_keys=maybe")
+		assert.Err(t, err)
+	})
+}
+
+// openDSN opens a database in a temporary directory with the query appended to its path.
+func openDSN(t *testing.T, opts sqlite.Options, query string) *sql.DB {
+	t.Helper()
+
+	opts.Name = strconv.Itoa(int(time.Now().UnixNano()))
+	sqlite.RegisterDriver(opts)
+
+	db, err := sql.Open(opts.Name, path.Join(t.TempDir(), "app.db")+query)
+	assert.NoErr(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidEnum is wrapped by errors from BindEnum and ScanEnum when a value is not in the valid set.
+var ErrInvalidEnum = errors.New("invalid enum value")
+
+// BindEnum returns a value that binds v as text, and fails binding if v is not one of valid.
+func BindEnum[T ~string](v T, valid ...T) driver.Valuer {
+	return enumValuer[T]{v: v, valid: valid}
+}
+
+type enumValuer[T ~string] struct {
+	v     T
+	valid []T
+}
+
+// Value satisfies driver.Valuer.
+func (e enumValuer[T]) Value() (driver.Value, error) {
+	if err := checkEnum(e.v, e.valid); err != nil {
+		return nil, err
+	}
+	return string(e.v), nil
+}
+
+// ScanEnum returns a scanner that reads a text column into v, for use with sql.Rows.Scan,
+// and fails if the value is not one of valid, for example because of data corruption.
+func ScanEnum[T ~string](v *T, valid ...T) sql.Scanner {
+	return enumScanner[T]{v: v, valid: valid}
+}
+
+type enumScanner[T ~string] struct {
+	v     *T
+	valid []T
+}
+
+// Scan satisfies sql.Scanner.
+func (e enumScanner[T]) Scan(src any) error {
+	var v T
+	switch src := src.(type) {
+	case string:
+		v = T(src)
+	case []byte:
+		v = T(src)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into %T", e.v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, e.v)
+	}
+
+	if err := checkEnum(v, e.valid); err != nil {
+		return err
+	}
+	*e.v = v
+	return nil
+}
+
+func checkEnum[T ~string](v T, valid []T) error {
+	for _, value := range valid {
+		if v == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q for %T, must be one of %q", ErrInvalidEnum, string(v), v, valid)
+}
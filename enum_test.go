@@ -0,0 +1,55 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+type status string
+
+const (
+	statusActive   = status("active")
+	statusInactive = status("inactive")
+)
+
+var statuses = []status{statusActive, statusInactive}
+
+func TestScanEnum(t *testing.T) {
+	t.Run("round-trips a valid enum value", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (s text not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (?)`, sqlite.BindEnum(statusActive, statuses...))
+		assert.NoErr(t, err)
+
+		var s status
+		err = db.QueryRow(`select s from t`).Scan(sqlite.ScanEnum(&s, statuses...))
+		assert.NoErr(t, err)
+		assert.Equal(t, statusActive, s)
+	})
+
+	t.Run("errors on scanning an invalid enum value", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var s status
+		err := db.QueryRow(`select 'deleted'`).Scan(sqlite.ScanEnum(&s, statuses...))
+		assert.Err(t, err)
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrInvalidEnum))
+		assert.Equal(t, true, strings.Contains(err.Error(), `invalid enum value "deleted" for sqlite_test.status, must be one of ["active" "inactive"]`))
+		assert.Equal(t, status(""), s)
+	})
+
+	t.Run("errors on binding an invalid enum value", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`select ?`, sqlite.BindEnum(status("deleted"), statuses...))
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrInvalidEnum))
+	})
+}
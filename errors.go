@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCgoRequired is returned when opening a database, if the package was built without cgo.
+// SQLite is compiled from C, so this package only works with cgo enabled (CGO_ENABLED=1 and a C compiler).
+var ErrCgoRequired = errors.New("sqlite requires cgo, but was built with CGO_ENABLED=0")
+
+// ErrShutdown is returned for new statements and transactions after Shutdown has been called.
+var ErrShutdown = errors.New("database is shutting down")
+
+// Result codes for use with Error and Options.MapError.
+// They're plain ints with the values from sqlite3.h, so they're also available without cgo.
+// See https://www.sqlite.org/rescode.html
+const (
+	CodeError                = 1
+	CodeInternal             = 2
+	CodePerm                 = 3
+	CodeAbort                = 4
+	CodeBusy                 = 5
+	CodeLocked               = 6
+	CodeNoMem                = 7
+	CodeReadOnly             = 8
+	CodeInterrupt            = 9
+	CodeIOErr                = 10
+	CodeCorrupt              = 11
+	CodeFull                 = 13
+	CodeCantOpen             = 14
+	CodeConstraint           = 19
+	CodeMismatch             = 20
+	CodeMisuse               = 21
+	CodeRange                = 25
+	CodeNotADB               = 26
+	CodeBusySnapshot         = CodeBusy | 2<<8
+	CodeConstraintCheck      = CodeConstraint | 1<<8
+	CodeConstraintForeignKey = CodeConstraint | 3<<8
+	CodeConstraintNotNull    = CodeConstraint | 5<<8
+	CodeConstraintPrimaryKey = CodeConstraint | 6<<8
+	CodeConstraintUnique     = CodeConstraint | 8<<8
+)
+
+// Error is an error returned by SQLite.
+type Error struct {
+	// Code is the primary result code, such as CodeConstraint.
+	Code int
+	// ExtendedCode is the extended result code, such as CodeConstraintUnique.
+	ExtendedCode int
+	// Msg is the English-language error message from SQLite.
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// mappedError is an error returned by Options.MapError, which keeps the *Error it was mapped from,
+// so that both can be found with errors.Is and errors.As.
+type mappedError struct {
+	err       error
+	sqliteErr *Error
+}
+
+func (e *mappedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *mappedError) Unwrap() []error {
+	return []error{e.err, e.sqliteErr}
+}
+
+func wrapError(format string, err error, args ...any) error {
+	args = append(args, err)
+	return fmt.Errorf(format+": %w", args...)
+}
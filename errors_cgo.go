@@ -0,0 +1,46 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+// newError for a result code. If cC is not nil, the extended result code and message are taken from the connection.
+// See https://www.sqlite.org/c3ref/errcode.html
+func newError(cC *C.sqlite3, cCode C.int) *Error {
+	if cC == nil {
+		return &Error{
+			Code:         int(cCode) & 0xff,
+			ExtendedCode: int(cCode),
+			Msg:          C.GoString(C.sqlite3_errstr(cCode)),
+		}
+	}
+
+	return &Error{
+		Code:         int(cCode) & 0xff,
+		ExtendedCode: int(C.sqlite3_extended_errcode(cC)),
+		Msg:          C.GoString(C.sqlite3_errmsg(cC)),
+	}
+}
+
+func wrapErrorCode(format string, cCode C.int, args ...any) error {
+	return wrapError(format, newError(nil, cCode), args...)
+}
+
+// wrapErrorCode for an error on the connection, passing it through Options.MapError.
+// Errors that may leave the connection in a bad state mark it as broken, see connection.IsValid.
+func (c *connection) wrapErrorCode(format string, cCode C.int, args ...any) error {
+	e := newError(c.cC, cCode)
+	switch e.Code {
+	case CodeNoMem, CodeIOErr, CodeCorrupt, CodeNotADB:
+		c.broken = true
+	}
+	if c.opts.MapError != nil {
+		if err := c.opts.MapError(e.Code, e.ExtendedCode, e.Msg); err != nil {
+			return wrapError(format, &mappedError{err: err, sqliteErr: e}, args...)
+		}
+	}
+	return wrapError(format, e, args...)
+}
@@ -0,0 +1,125 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+var (
+	errApp       = errors.New("app")
+	errDuplicate = errors.New("duplicate")
+)
+
+func TestError(t *testing.T) {
+	t.Run("returns typed error with codes and message", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int unique)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1)`)
+
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeConstraint, sqliteErr.Code)
+		assert.Equal(t, sqlite.CodeConstraintUnique, sqliteErr.ExtendedCode)
+		assert.Equal(t, "UNIQUE constraint failed: t.v", sqliteErr.Msg)
+	})
+
+	t.Run("returns connection error message on prepare", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`select * from nope`)
+
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeError, sqliteErr.Code)
+		assert.Equal(t, "no such table: nope", sqliteErr.Msg)
+	})
+}
+
+func TestOptions_MapError(t *testing.T) {
+	mapError := func(code, extendedCode int, msg string) error {
+		if extendedCode == sqlite.CodeConstraintUnique {
+			return errDuplicate
+		}
+		return nil
+	}
+
+	t.Run("maps constraint error to custom sentinel", func(t *testing.T) {
+		db := open(t, sqlite.Options{MapError: mapError})
+
+		_, err := db.Exec(`create table t (v int unique)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1)`)
+		assert.Equal(t, true, errors.Is(err, errDuplicate))
+
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeConstraintUnique, sqliteErr.ExtendedCode)
+	})
+
+	t.Run("falls back to typed error if mapping returns nil", func(t *testing.T) {
+		db := open(t, sqlite.Options{MapError: mapError})
+
+		_, err := db.Exec(`select * from nope`)
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+	})
+
+	t.Run("reports interrupted statements as interrupted if the interrupt is mapped", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{MapError: func(code, extendedCode int, msg string) error {
+			return errApp
+		}})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (0), (0), (0), (0), (0), (0), (0), (0), (0), (0)`)
+		assert.NoErr(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = db.ExecContext(ctx, `update t set v = sleep_ms(10)`)
+		assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("retries transactions as immediate if the busy error is mapped", func(t *testing.T) {
+		mapEverything := func(code, extendedCode int, msg string) error {
+			return errApp
+		}
+		db := openWith(t, sqlite.Options{MapError: mapEverything},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		var attempts int
+		err := sqlite.Transaction(context.Background(), db, func(tx *sql.Tx) error {
+			attempts++
+
+			var n int
+			if err := tx.QueryRow(`select n from counter`).Scan(&n); err != nil {
+				return err
+			}
+			if attempts == 1 {
+				if _, err := db.Exec(`update counter set n = n + 10`); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec(`update counter set n = ?`, n+1)
+			return err
+		})
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+}
@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EstimateRows returns a rough estimate of the number of rows query with args reads, without running it,
+// for example to refuse to run unexpectedly expensive queries.
+//
+// The estimate is made from the loops in the EXPLAIN QUERY PLAN output: a full table scan reads all rows of the table,
+// a search by rowid or on all columns of a unique index reads one row, a search on other index equality constraints
+// reads ten rows per lookup, and a range constraint reads a quarter of the rows. Nested loops, as in joins, multiply.
+// If ANALYZE has been run, the statistics in sqlite_stat1 are used for table sizes and index equality constraints.
+// Otherwise, table sizes are estimated from the largest rowid.
+// It's only an estimate, and can be far off, especially for complex queries with subqueries or compound selects.
+// See https://www.sqlite.org/eqp.html
+func EstimateRows(ctx context.Context, q Querier, query string, args ...any) (int64, error) {
+	rows, err := q.QueryContext(ctx, "explain query plan "+query, args...)
+	if err != nil {
+		return 0, wrapError("error explaining query plan", err)
+	}
+
+	var details []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			_ = rows.Close()
+			return 0, wrapError("error scanning query plan", err)
+		}
+		details = append(details, detail)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, wrapError("error reading query plan", err)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, wrapError("error closing query plan rows", err)
+	}
+
+	e := estimator{ctx: ctx, q: q, tokens: tokenize(query)}
+	estimate := 1.0
+	for _, detail := range details {
+		n, err := e.loopRows(detail)
+		if err != nil {
+			return 0, err
+		}
+		estimate *= n
+	}
+
+	if estimate >= math.MaxInt64 {
+		return math.MaxInt64, nil
+	}
+	return int64(math.Ceil(estimate)), nil
+}
+
+// estimator estimates the rows read by loops in a query plan.
+type estimator struct {
+	ctx    context.Context
+	q      Querier
+	tokens []token
+}
+
+// loopRows estimates the rows read by a SCAN or SEARCH line of a query plan, and 1 for other lines.
+func (e estimator) loopRows(detail string) (float64, error) {
+	fields := strings.Fields(detail)
+	if len(fields) < 2 || fields[0] != "SCAN" && fields[0] != "SEARCH" || fields[1] == "CONSTANT" {
+		return 1, nil
+	}
+
+	table := e.resolveAlias(fields[1])
+	tableRows, err := e.tableRows(table)
+	if err != nil {
+		return 0, err
+	}
+
+	if fields[0] == "SCAN" {
+		return tableRows, nil
+	}
+
+	// The constraints are at the end, in parentheses, like "(a=? AND b>?)"
+	var constraints []string
+	if start, end := strings.LastIndexByte(detail, '('), strings.LastIndexByte(detail, ')'); start >= 0 && end > start {
+		constraints = strings.Split(detail[start+1:end], " AND ")
+	}
+	var equalities int
+	var hasRange bool
+	for _, c := range constraints {
+		if strings.HasSuffix(c, "=?") && !strings.HasSuffix(c, ">=?") && !strings.HasSuffix(c, "<=?") {
+			equalities++
+		} else {
+			hasRange = true
+		}
+	}
+
+	estimate := tableRows
+	switch {
+	case strings.Contains(detail, "USING INTEGER PRIMARY KEY") && !hasRange:
+		return 1, nil
+	case equalities > 0:
+		index := ""
+		// Automatic indexes have no name, so the constraints come right after INDEX
+		if i := indexOf(fields, "INDEX"); i >= 0 && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "(") {
+			index = fields[i+1]
+		}
+		estimate, err = e.equalityRows(table, index, equalities, tableRows)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if hasRange {
+		estimate /= 4
+	}
+	return math.Max(estimate, 1), nil
+}
+
+// resolveAlias returns the table name for name if it's an alias in the query, like "t" in "from things as t".
+func (e estimator) resolveAlias(name string) string {
+	for j, t := range e.tokens {
+		if j == 0 || t.kind != tokenIdent || t.text != name {
+			continue
+		}
+		prev := e.tokens[j-1]
+		if prev.is("as") && j >= 2 {
+			prev = e.tokens[j-2]
+		}
+		if prev.kind == tokenIdent && !isKeyword(prev.text) {
+			return prev.text
+		}
+	}
+	return name
+}
+
+// tableRows estimates the number of rows in table, from sqlite_stat1 if available, otherwise from the largest rowid.
+// If neither works, for example for a subquery, it returns 1.
+func (e estimator) tableRows(table string) (float64, error) {
+	if stats, ok := e.stat(table, ""); ok {
+		return stats[0], nil
+	}
+
+	var maxRowID *int64
+	if err := e.q.QueryRowContext(e.ctx, "select max(rowid) from "+quoteIdentifier(table)).Scan(&maxRowID); err != nil {
+		return 1, nil
+	}
+	if maxRowID == nil {
+		return 0, nil
+	}
+	return float64(*maxRowID), nil
+}
+
+// equalityRows estimates the rows read by a lookup on the first n columns of index.
+func (e estimator) equalityRows(table, index string, n int, tableRows float64) (float64, error) {
+	if index == "" {
+		return math.Min(10, tableRows), nil
+	}
+
+	var unique bool
+	var columns int
+	err := e.q.QueryRowContext(e.ctx, `select coalesce((select "unique" from pragma_index_list(?) where name = ?), 0), (select count(*) from pragma_index_info(?))`,
+		table, index, index).Scan(&unique, &columns)
+	if err != nil {
+		return 0, wrapError("error getting index info for %v", err, index)
+	}
+	if unique && n >= columns {
+		return 1, nil
+	}
+
+	if stats, ok := e.stat(table, index); ok && n < len(stats) {
+		return stats[n], nil
+	}
+	return math.Min(10, tableRows), nil
+}
+
+// stat returns the numbers in the sqlite_stat1 stat column for table and index, if any.
+// With an empty index, any row for the table is used, as they all start with the number of rows in the table.
+// See https://www.sqlite.org/fileformat2.html#stat1tab
+func (e estimator) stat(table, index string) ([]float64, bool) {
+	query := `select stat from sqlite_stat1 where tbl = ? and (idx = ? or ? = '') limit 1`
+	var stat string
+	if err := e.q.QueryRowContext(e.ctx, query, table, index, index).Scan(&stat); err != nil {
+		return nil, false
+	}
+
+	var stats []float64
+	for _, field := range strings.Fields(stat) {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			// Options like "unordered" come after the numbers
+			break
+		}
+		stats = append(stats, n)
+	}
+	return stats, len(stats) > 0
+}
+
+func indexOf(values []string, v string) int {
+	for i, value := range values {
+		if value == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// isKeyword reports whether s is one of the keywords that can come before a table name or alias in a query.
+func isKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "from", "join", "on", "where", "select", "update", "into", "using", "and", "or", "not", "by", "set", "table":
+		return true
+	}
+	return false
+}
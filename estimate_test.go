@@ -0,0 +1,71 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestEstimateRows(t *testing.T) {
+	t.Run("estimates fewer rows for an indexed query than an unindexed one", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, a int not null, b int not null)`,
+			`create index t_a on t (a)`,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t (a, b) select i % 100, i from n`)
+
+		indexed, err := sqlite.EstimateRows(context.Background(), db, `select * from t where a = ?`, 1)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(10), indexed)
+
+		unindexed, err := sqlite.EstimateRows(context.Background(), db, `select * from t x where b = ?`, 1)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1000), unindexed)
+
+		byRowID, err := sqlite.EstimateRows(context.Background(), db, `select * from t where id = ?`, 1)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1), byRowID)
+
+		byRange, err := sqlite.EstimateRows(context.Background(), db, `select * from t where a > ?`, 1)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(250), byRange)
+	})
+
+	t.Run("uses sqlite_stat1 after analyze", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, a int not null, b int not null)`,
+			`create index t_a on t (a)`,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t (a, b) select i % 100, i from n`)
+
+		_, err := db.Exec(`analyze`)
+		assert.NoErr(t, err)
+
+		// There are 100 distinct values of a, so 10 rows per value
+		indexed, err := sqlite.EstimateRows(context.Background(), db, `select * from t where a = ?`, 1)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(10), indexed)
+
+		unindexed, err := sqlite.EstimateRows(context.Background(), db, `select * from t where b = ?`, 1)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1000), unindexed)
+	})
+
+	t.Run("multiplies nested loops in joins", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, a int not null, b int not null)`,
+			`create index t_a on t (a)`,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t (a, b) select i % 100, i from n`)
+
+		_, err := db.Exec(`create table u (id integer primary key)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into u values (1), (2), (3), (4), (5)`)
+		assert.NoErr(t, err)
+
+		n, err := sqlite.EstimateRows(context.Background(), db, `select * from t, u`)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(5000), n)
+	})
+}
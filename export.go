@@ -0,0 +1,204 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ColumnChunk is a column-major chunk of query results, suitable for writing to columnar formats such as Parquet or Arrow.
+type ColumnChunk struct {
+	// Columns are the result column names.
+	Columns []string
+	// Data has one slice per column, in the same order as Columns.
+	// Each slice is a []int64, []float64, []bool, []string, [][]byte, []time.Time, or []any, see ExportColumnChunks.
+	Data []any
+	// Nulls has one slice per column, reporting which values are NULL.
+	// NULL values have the zero value in Data.
+	Nulls [][]bool
+	// Rows is the number of rows in this chunk.
+	Rows int
+	// Err is set on the last chunk sent if an error occurred while reading results.
+	Err error
+}
+
+// ExportColumnChunks runs query with args and streams the results as column-major chunks of at most chunkRows rows.
+// The channel is closed when all results have been sent, an error occurs, or ctx is done.
+// Errors after the query has started are sent in the Err field of a final chunk.
+//
+// The type of each column is the same in all chunks. It's the scan type of the declared column type, see
+// sql.ColumnType.ScanType, or for columns without one, such as expressions, the type of the first non-NULL value
+// in the first chunk, and any if there is none. Integers in float64 columns are converted, and other values
+// of the wrong type are an error.
+//
+// Results are read in a goroutine that holds a connection from db until the channel is closed.
+// Call stop to stop reading results early, which makes the goroutine release the connection and close the channel.
+// It's safe to call stop more than once, and after all results have been received.
+func ExportColumnChunks(ctx context.Context, db *sql.DB, query string, chunkRows int, args ...any) (
+	chunks <-chan ColumnChunk, stop func(), err error) {
+	if chunkRows < 1 {
+		return nil, nil, fmt.Errorf("chunkRows must be positive, got %v", chunkRows)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, nil, wrapError("error running query", err)
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		_ = rows.Close()
+		cancel()
+		return nil, nil, wrapError("error getting column types", err)
+	}
+
+	columns := make([]string, len(columnTypes))
+	types := make([]reflect.Type, len(columnTypes))
+	for i, t := range columnTypes {
+		columns[i] = t.Name()
+		if t.ScanType() != anyType {
+			types[i] = t.ScanType()
+		}
+	}
+
+	ch := make(chan ColumnChunk)
+
+	go func() {
+		defer close(ch)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		send := func(chunk ColumnChunk) bool {
+			select {
+			case ch <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		values := make([][]any, len(columns))
+		row := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+
+		n := 0
+		for rows.Next() {
+			if err := rows.Scan(ptrs...); err != nil {
+				send(ColumnChunk{Columns: columns, Err: wrapError("error scanning row", err)})
+				return
+			}
+			for i, v := range row {
+				values[i] = append(values[i], v)
+			}
+			n++
+
+			if n == chunkRows {
+				chunk := newColumnChunk(columns, types, values, n)
+				if !send(chunk) || chunk.Err != nil {
+					return
+				}
+				values = make([][]any, len(columns))
+				n = 0
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			send(ColumnChunk{Columns: columns, Err: wrapError("error reading rows", err)})
+			return
+		}
+
+		if n > 0 {
+			send(newColumnChunk(columns, types, values, n))
+		}
+	}()
+
+	return ch, cancel, nil
+}
+
+// newColumnChunk with the values of n rows, by column. Columns without a type in types get the type of their first
+// non-NULL value, or any if there is none, which is then used for the following chunks.
+func newColumnChunk(columns []string, types []reflect.Type, values [][]any, n int) ColumnChunk {
+	chunk := ColumnChunk{
+		Columns: columns,
+		Data:    make([]any, len(columns)),
+		Nulls:   make([][]bool, len(columns)),
+		Rows:    n,
+	}
+
+	for i, column := range values {
+		chunk.Nulls[i] = make([]bool, n)
+		for j, v := range column {
+			chunk.Nulls[i][j] = v == nil
+			if types[i] == nil && v != nil {
+				types[i] = reflect.TypeOf(v)
+			}
+		}
+		if types[i] == nil {
+			types[i] = anyType
+		}
+
+		data, err := narrowColumn(column, types[i])
+		if err != nil {
+			return ColumnChunk{Columns: columns, Err: wrapError("error converting column %v", err, columns[i])}
+		}
+		chunk.Data[i] = data
+	}
+
+	return chunk
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// narrowColumn converts the values of a column to a slice of typ, or []any if there is no typed slice for typ.
+func narrowColumn(values []any, typ reflect.Type) (any, error) {
+	if typ == anyType {
+		return values, nil
+	}
+
+	switch reflect.Zero(typ).Interface().(type) {
+	case int64:
+		return narrow[int64](values)
+	case float64:
+		return narrow[float64](values)
+	case bool:
+		return narrow[bool](values)
+	case string:
+		return narrow[string](values)
+	case []byte:
+		return narrow[[]byte](values)
+	case time.Time:
+		return narrow[time.Time](values)
+	default:
+		return values, nil
+	}
+}
+
+func narrow[T any](values []any) ([]T, error) {
+	typed := make([]T, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case T:
+			typed[i] = v
+		case int64:
+			// Integers in a float64 column are converted, like SQLite converts them when storing them in a REAL column
+			f, ok := any(&typed[i]).(*float64)
+			if !ok {
+				return nil, fmt.Errorf("cannot convert %T to %T", v, typed[i])
+			}
+			*f = float64(v)
+		default:
+			return nil, fmt.Errorf("cannot convert %T to %T", v, typed[i])
+		}
+	}
+	return typed, nil
+}
@@ -0,0 +1,148 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestExportColumnChunks(t *testing.T) {
+	t.Run("streams results in column-major chunks", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, score real, name text)`)
+		assert.NoErr(t, err)
+		for i := 1; i <= 5; i++ {
+			var name any = "n"
+			if i == 3 {
+				name = nil
+			}
+			_, err = db.Exec(`insert into t (score, name) values (?, ?)`, float64(i)/2, name)
+			assert.NoErr(t, err)
+		}
+
+		ch, stop, err := sqlite.ExportColumnChunks(context.Background(), db, `select id, score, name from t where id > ? order by id`, 2, 0)
+		assert.NoErr(t, err)
+		defer stop()
+
+		var ids []int64
+		var scores []float64
+		var nulls []bool
+		var chunks int
+		for chunk := range ch {
+			assert.NoErr(t, chunk.Err)
+			assert.Equal(t, 3, len(chunk.Columns))
+			assert.Equal(t, "score", chunk.Columns[1])
+			ids = append(ids, chunk.Data[0].([]int64)...)
+			scores = append(scores, chunk.Data[1].([]float64)...)
+			nulls = append(nulls, chunk.Nulls[2]...)
+			chunks++
+		}
+
+		assert.Equal(t, 3, chunks)
+		assert.Equal(t, 5, len(ids))
+		for i := range ids {
+			assert.Equal(t, int64(i+1), ids[i])
+			assert.Equal(t, float64(i+1)/2, scores[i])
+			assert.Equal(t, i == 2, nulls[i])
+		}
+	})
+
+	t.Run("uses the same column types in all chunks", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, name text, created datetime)`,
+			`insert into t (name, created) values (null, null), ('a', '2024-01-02T03:04:05Z')`)
+
+		ch, stop, err := sqlite.ExportColumnChunks(context.Background(), db,
+			`select name, created, case when id = 2 then 1.5 end from t order by id`, 1)
+		assert.NoErr(t, err)
+		defer stop()
+
+		var chunks []sqlite.ColumnChunk
+		for chunk := range ch {
+			assert.NoErr(t, chunk.Err)
+			chunks = append(chunks, chunk)
+		}
+		assert.Equal(t, 2, len(chunks))
+		for _, chunk := range chunks {
+			_, ok := chunk.Data[0].([]string)
+			assert.Equal(t, true, ok)
+			_, ok = chunk.Data[1].([]time.Time)
+			assert.Equal(t, true, ok)
+			// The expression has no declared type, and only NULL in the first chunk
+			_, ok = chunk.Data[2].([]any)
+			assert.Equal(t, true, ok)
+		}
+		assert.Equal(t, "a", chunks[1].Data[0].([]string)[0])
+		assert.Equal(t, 2024, chunks[1].Data[1].([]time.Time)[0].Year())
+	})
+
+	t.Run("exports columns declared with a time type as time.Time", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (created datetime)`,
+			`insert into t values ('2024-01-02T03:04:05Z'), (null)`)
+
+		ch, stop, err := sqlite.ExportColumnChunks(context.Background(), db, `select created from t order by rowid`, 10)
+		assert.NoErr(t, err)
+		defer stop()
+
+		chunk := <-ch
+		assert.NoErr(t, chunk.Err)
+		created := chunk.Data[0].([]time.Time)
+		assert.Equal(t, 2024, created[0].Year())
+		assert.Equal(t, true, created[1].IsZero())
+		assert.Equal(t, true, chunk.Nulls[0][1])
+	})
+
+	t.Run("converts integers in float columns and errors on other values of the wrong type", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		ch, stop, err := sqlite.ExportColumnChunks(context.Background(), db,
+			`select 1.5 union all select 2 union all select 'three'`, 1)
+		assert.NoErr(t, err)
+		defer stop()
+
+		chunk := <-ch
+		assert.NoErr(t, chunk.Err)
+		assert.Equal(t, 1.5, chunk.Data[0].([]float64)[0])
+		chunk = <-ch
+		assert.NoErr(t, chunk.Err)
+		assert.Equal(t, 2.0, chunk.Data[0].([]float64)[0])
+		chunk = <-ch
+		assert.Err(t, chunk.Err)
+		_, ok := <-ch
+		assert.Equal(t, false, ok)
+	})
+
+	t.Run("releases the connection when stopped early", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		db.SetMaxOpenConns(1)
+
+		ch, stop, err := sqlite.ExportColumnChunks(context.Background(), db,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 100) select i from n`, 1)
+		assert.NoErr(t, err)
+
+		chunk := <-ch
+		assert.NoErr(t, chunk.Err)
+		stop()
+		stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		var v int
+		err = db.QueryRowContext(ctx, `select 1`).Scan(&v)
+		assert.NoErr(t, err)
+	})
+
+	t.Run("errors on invalid query", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, _, err := sqlite.ExportColumnChunks(context.Background(), db, `select * from nope`, 10)
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,28 @@
+package sqlite
+
+// Features are the optional SQLite features compiled into the driver.
+// See https://www.sqlite.org/compile.html
+type Features struct {
+	// ColumnMetadata is whether the column metadata APIs are available (SQLITE_ENABLE_COLUMN_METADATA).
+	ColumnMetadata bool
+	// DBStat is whether the dbstat virtual table is available (SQLITE_ENABLE_DBSTAT_VTAB).
+	DBStat bool
+	// FTS3 is whether the FTS3 full-text search extension is available (SQLITE_ENABLE_FTS3).
+	FTS3 bool
+	// FTS4 is whether the FTS4 full-text search extension is available (SQLITE_ENABLE_FTS4).
+	FTS4 bool
+	// FTS5 is whether the FTS5 full-text search extension is available (SQLITE_ENABLE_FTS5).
+	FTS5 bool
+	// Geopoly is whether the Geopoly extension is available (SQLITE_ENABLE_GEOPOLY), see the geopoly build tag.
+	Geopoly bool
+	// JSON1 is whether the JSON functions are available. They are built in unless SQLITE_OMIT_JSON is set.
+	JSON1 bool
+	// MathFunctions is whether the built-in math functions are available (SQLITE_ENABLE_MATH_FUNCTIONS).
+	MathFunctions bool
+	// PreupdateHook is whether the preupdate hook is available (SQLITE_ENABLE_PREUPDATE_HOOK).
+	PreupdateHook bool
+	// RTree is whether the R*Tree extension is available (SQLITE_ENABLE_RTREE), which the geopoly build tag includes.
+	RTree bool
+	// Session is whether the session extension is available (SQLITE_ENABLE_SESSION), see the session build tag.
+	Session bool
+}
@@ -0,0 +1,41 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdlib.h>
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// CompiledFeatures returns the optional features compiled into the driver,
+// so apps can check for them at startup instead of failing on first use.
+func CompiledFeatures() Features {
+	return Features{
+		ColumnMetadata: CompileOptionUsed("ENABLE_COLUMN_METADATA"),
+		DBStat:         CompileOptionUsed("ENABLE_DBSTAT_VTAB"),
+		FTS3:           CompileOptionUsed("ENABLE_FTS3"),
+		FTS4:           CompileOptionUsed("ENABLE_FTS4"),
+		FTS5:           CompileOptionUsed("ENABLE_FTS5"),
+		Geopoly:        CompileOptionUsed("ENABLE_GEOPOLY"),
+		JSON1:          !CompileOptionUsed("OMIT_JSON"),
+		MathFunctions:  CompileOptionUsed("ENABLE_MATH_FUNCTIONS"),
+		PreupdateHook:  CompileOptionUsed("ENABLE_PREUPDATE_HOOK"),
+		RTree:          CompileOptionUsed("ENABLE_RTREE"),
+		Session:        CompileOptionUsed("ENABLE_SESSION"),
+	}
+}
+
+// CompileOptionUsed returns whether the compile-time option name was used when building SQLite.
+// The "SQLITE_" prefix is optional, so "ENABLE_FTS5" and "SQLITE_ENABLE_FTS5" are the same. Unknown options are false.
+// See https://www.sqlite.org/c3ref/compileoption_get.html
+func CompileOptionUsed(name string) bool {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	return C.sqlite3_compileoption_used(cName) == 1
+}
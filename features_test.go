@@ -0,0 +1,36 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestCompiledFeatures(t *testing.T) {
+	t.Run("reports JSON1, which is built in", func(t *testing.T) {
+		features := sqlite.CompiledFeatures()
+		assert.Equal(t, true, features.JSON1)
+	})
+
+	t.Run("matches whether the JSON functions work", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v string
+		err := db.QueryRow(`select json_extract('{"a":1}', '$.a')`).Scan(&v)
+		assert.Equal(t, sqlite.CompiledFeatures().JSON1, err == nil)
+	})
+}
+
+func TestCompileOptionUsed(t *testing.T) {
+	t.Run("returns false for an unknown option", func(t *testing.T) {
+		assert.Equal(t, false, sqlite.CompileOptionUsed("ENABLE_DOES_NOT_EXIST"))
+	})
+
+	t.Run("accepts the option with and without the SQLITE_ prefix", func(t *testing.T) {
+		assert.Equal(t, true, sqlite.CompileOptionUsed("THREADSAFE"))
+		assert.Equal(t, true, sqlite.CompileOptionUsed("SQLITE_THREADSAFE"))
+	})
+}
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMissingFKTarget is wrapped by errors from CheckFKTargets when a referenced parent row doesn't exist.
+var ErrMissingFKTarget = errors.New("foreign key target missing")
+
+// foreignKey is a foreign key constraint on a table, from columns in the child table to columns in the parent table.
+type foreignKey struct {
+	parent string
+	from   []string
+	to     []string
+}
+
+// CheckFKTargets checks that for each foreign key on table, the parent row referenced by row exists,
+// where row maps column names to the values about to be inserted.
+// If not, an error wrapping ErrMissingFKTarget is returned, naming the constraint columns and the missing values,
+// which is more descriptive than the generic error from SQLite on insert.
+// Foreign keys where any of the child columns are missing from row or NULL are skipped, as SQLite doesn't enforce them.
+//
+// It runs a query per foreign key, so it's meant as a development aid, not for every insert in production.
+func CheckFKTargets(ctx context.Context, q Querier, table string, row map[string]any) error {
+	fks, err := foreignKeys(ctx, q, table)
+	if err != nil {
+		return err
+	}
+
+FKs:
+	for _, fk := range fks {
+		var conditions []string
+		var args []any
+		for i, from := range fk.from {
+			v, ok := lookupColumn(row, from)
+			if !ok || v == nil {
+				continue FKs
+			}
+			conditions = append(conditions, quoteIdentifier(fk.to[i])+" = ?")
+			args = append(args, v)
+		}
+
+		query := fmt.Sprintf("select exists (select 1 from %v where %v)", quoteIdentifier(fk.parent), strings.Join(conditions, " and "))
+		var exists bool
+		if err := q.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+			return wrapError(`error checking foreign key target in "%v"`, err, fk.parent)
+		}
+		if !exists {
+			return fmt.Errorf("%w: %v(%v) references %v(%v), but there is no row with values %v",
+				ErrMissingFKTarget, table, strings.Join(fk.from, ", "), fk.parent, strings.Join(fk.to, ", "), formatArgs(args))
+		}
+	}
+	return nil
+}
+
+// foreignKeys returns the foreign keys on table.
+// If a foreign key doesn't name the parent columns, they are the primary key columns of the parent table.
+// See https://www.sqlite.org/pragma.html#pragma_foreign_key_list
+func foreignKeys(ctx context.Context, q Querier, table string) ([]foreignKey, error) {
+	rows, err := q.QueryContext(ctx, `select id, "table", "from", "to" from pragma_foreign_key_list(?) order by id, seq`, table)
+	if err != nil {
+		return nil, wrapError(`error getting foreign keys of "%v"`, err, table)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var fks []foreignKey
+	lastID := -1
+	for rows.Next() {
+		var id int
+		var parent, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &parent, &from, &to); err != nil {
+			return nil, wrapError(`error scanning foreign keys of "%v"`, err, table)
+		}
+		if id != lastID {
+			fks = append(fks, foreignKey{parent: parent})
+			lastID = id
+		}
+		fk := &fks[len(fks)-1]
+		fk.from = append(fk.from, from)
+		fk.to = append(fk.to, to.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(`error getting foreign keys of "%v"`, err, table)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	for i := range fks {
+		if fks[i].to[0] != "" {
+			continue
+		}
+		pk, err := primaryKey(ctx, q, fks[i].parent)
+		if err != nil {
+			return nil, err
+		}
+		if len(pk) != len(fks[i].from) {
+			return nil, fmt.Errorf(`foreign key from "%v" references the primary key of "%v", which has %v columns instead of %v`,
+				table, fks[i].parent, len(pk), len(fks[i].from))
+		}
+		fks[i].to = pk
+	}
+
+	return fks, nil
+}
+
+// primaryKey returns the primary key columns of table, in key order.
+func primaryKey(ctx context.Context, q Querier, table string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `select name from pragma_table_info(?) where pk > 0 order by pk`, table)
+	if err != nil {
+		return nil, wrapError(`error getting primary key of "%v"`, err, table)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, wrapError(`error scanning primary key of "%v"`, err, table)
+		}
+		columns = append(columns, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(`error getting primary key of "%v"`, err, table)
+	}
+	return columns, nil
+}
+
+// lookupColumn returns the value for column in row, matching the name case-insensitively like SQLite does
+// if there's no exact match.
+func lookupColumn(row map[string]any, column string) (any, bool) {
+	if v, ok := row[column]; ok {
+		return v, true
+	}
+	for k, v := range row {
+		if strings.EqualFold(k, column) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// formatArgs formats args for an error message, like (1, "a").
+func formatArgs(args []any) string {
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case string:
+			formatted[i] = fmt.Sprintf("%q", arg)
+		default:
+			formatted[i] = fmt.Sprintf("%v", arg)
+		}
+	}
+	return "(" + strings.Join(formatted, ", ") + ")"
+}
@@ -0,0 +1,68 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestCheckFKTargets(t *testing.T) {
+	t.Run("returns nil when all parent rows exist", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table authors (id integer primary key, name text)`,
+			`create table editions (book text, year int, primary key (book, year))`,
+			`create table reviews (author_id int references authors (id), book text, year int, foreign key (book, year) references editions)`,
+			`insert into authors values (1, 'Alice')`,
+			`insert into editions values ('Dune', 1965)`)
+
+		err := sqlite.CheckFKTargets(context.Background(), db, "reviews", map[string]any{"author_id": 1, "book": "Dune", "year": 1965})
+		assert.NoErr(t, err)
+	})
+
+	t.Run("returns a descriptive error for a missing parent row", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table authors (id integer primary key, name text)`,
+			`create table editions (book text, year int, primary key (book, year))`,
+			`create table reviews (author_id int references authors (id), book text, year int, foreign key (book, year) references editions)`,
+			`insert into authors values (1, 'Alice')`,
+			`insert into editions values ('Dune', 1965)`)
+
+		err := sqlite.CheckFKTargets(context.Background(), db, "reviews", map[string]any{"author_id": 2, "book": "Dune", "year": 1965})
+		assert.Err(t, err)
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrMissingFKTarget))
+		assert.Equal(t, "foreign key target missing: reviews(author_id) references authors(id), but there is no row with values (2)", err.Error())
+
+		_, err = db.Exec(`insert into reviews values (2, 'Dune', 1965)`)
+		assert.Err(t, err)
+	})
+
+	t.Run("resolves composite foreign keys to the parent primary key", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table authors (id integer primary key, name text)`,
+			`create table editions (book text, year int, primary key (book, year))`,
+			`create table reviews (author_id int references authors (id), book text, year int, foreign key (book, year) references editions)`,
+			`insert into authors values (1, 'Alice')`,
+			`insert into editions values ('Dune', 1965)`)
+
+		err := sqlite.CheckFKTargets(context.Background(), db, "reviews", map[string]any{"author_id": 1, "book": "Dune", "year": 1984})
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrMissingFKTarget))
+		assert.Equal(t, `foreign key target missing: reviews(book, year) references editions(book, year), but there is no row with values ("Dune", 1984)`, err.Error())
+	})
+
+	t.Run("skips foreign keys with missing or null columns", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table authors (id integer primary key, name text)`,
+			`create table editions (book text, year int, primary key (book, year))`,
+			`create table reviews (author_id int references authors (id), book text, year int, foreign key (book, year) references editions)`,
+			`insert into authors values (1, 'Alice')`,
+			`insert into editions values ('Dune', 1965)`)
+
+		err := sqlite.CheckFKTargets(context.Background(), db, "reviews", map[string]any{"author_id": nil, "book": "Dune"})
+		assert.NoErr(t, err)
+	})
+}
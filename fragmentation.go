@@ -0,0 +1,22 @@
+package sqlite
+
+import "context"
+
+// FragmentationStats returns the number of free pages, the total number of pages, and their ratio,
+// which can be used to decide when to VACUUM the database.
+// See https://www.sqlite.org/pragma.html#pragma_freelist_count
+func FragmentationStats(ctx context.Context, q Querier) (freePages, totalPages int64, ratio float64, err error) {
+	if err := q.QueryRowContext(ctx, `pragma freelist_count`).Scan(&freePages); err != nil {
+		return 0, 0, 0, wrapError("error getting freelist count", err)
+	}
+
+	if err := q.QueryRowContext(ctx, `pragma page_count`).Scan(&totalPages); err != nil {
+		return 0, 0, 0, wrapError("error getting page count", err)
+	}
+
+	if totalPages > 0 {
+		ratio = float64(freePages) / float64(totalPages)
+	}
+
+	return freePages, totalPages, ratio, nil
+}
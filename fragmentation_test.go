@@ -0,0 +1,47 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestFragmentationStats(t *testing.T) {
+	t.Run("reports free pages after deletes and none after vacuum", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+		for i := 0; i < 100; i++ {
+			_, err = db.Exec(`insert into t values (?)`, strings.Repeat("x", 4096))
+			assert.NoErr(t, err)
+		}
+
+		freePages, totalPages, ratio, err := sqlite.FragmentationStats(context.Background(), db)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(0), freePages)
+		assert.Equal(t, true, totalPages > 100)
+		assert.Equal(t, 0.0, ratio)
+
+		_, err = db.Exec(`delete from t where rowid % 2 = 0`)
+		assert.NoErr(t, err)
+
+		freePages, _, ratio, err = sqlite.FragmentationStats(context.Background(), db)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, freePages > 0)
+		assert.Equal(t, true, ratio > 0.2)
+
+		_, err = db.Exec(`vacuum`)
+		assert.NoErr(t, err)
+
+		freePages, _, ratio, err = sqlite.FragmentationStats(context.Background(), db)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(0), freePages)
+		assert.Equal(t, true, ratio < 0.01)
+	})
+}
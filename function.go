@@ -0,0 +1,10 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+)
+
+// Function is a Go implementation of an SQL scalar function.
+// It receives the arguments as int64, float64, string, []byte, or nil, and returns one of those types,
+// or a bool or int.
+type Function func(args []driver.Value) (driver.Value, error)
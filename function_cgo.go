@@ -0,0 +1,133 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+#include <sqlite3.h>
+
+extern void goFunction(sqlite3_context *ctx, int argc, sqlite3_value **argv);
+extern void goFunctionDestroy(void *p);
+
+// See the comment in sqlite.go on why these wrappers are necessary.
+static void my_result_text(sqlite3_context *ctx, char *p, int np) {
+	sqlite3_result_text(ctx, p, np, SQLITE_TRANSIENT);
+}
+static void my_result_blob(sqlite3_context *ctx, void *p, int np) {
+	sqlite3_result_blob(ctx, p, np, SQLITE_TRANSIENT);
+}
+
+static int my_create_function(sqlite3 *db, char *name, int nArg, int flags, uintptr_t handle) {
+	return sqlite3_create_function_v2(db, name, nArg, flags, (void *)handle, goFunction, 0, 0, goFunctionDestroy);
+}
+*/
+import "C"
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// createFunction registers fn as a scalar function called name, taking nArg arguments, or any number if nArg is -1.
+// See https://www.sqlite.org/c3ref/create_function.html
+func createFunction(cC *C.sqlite3, name string, nArg int, deterministic bool, fn Function) error {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	flags := C.SQLITE_UTF8
+	if deterministic {
+		flags |= C.SQLITE_DETERMINISTIC
+	}
+
+	// The handle is deleted by SQLite calling goFunctionDestroy when the function is replaced or the connection is closed,
+	// also if creating the function fails.
+	h := cgo.NewHandle(fn)
+	if cCode := C.my_create_function(cC, cName, C.int(nArg), C.int(flags), C.uintptr_t(h)); cCode != C.SQLITE_OK {
+		return wrapError("error creating function %v", newError(cC, cCode), name)
+	}
+	return nil
+}
+
+// callFunction is called from goFunction with the arguments of an SQL function call.
+func callFunction(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	fn := cgo.Handle(uintptr(C.sqlite3_user_data(ctx))).Value().(Function)
+
+	cArgs := unsafe.Slice(argv, int(argc))
+	args := make([]driver.Value, len(cArgs))
+	for i, cArg := range cArgs {
+		args[i] = valueFromC(cArg)
+	}
+
+	result, err := fn(args)
+	if err != nil {
+		setResultError(ctx, err)
+		return
+	}
+	if err := setResult(ctx, result); err != nil {
+		setResultError(ctx, err)
+	}
+}
+
+// valueFromC converts an SQL function argument to a Go value.
+// See https://www.sqlite.org/c3ref/value_blob.html
+func valueFromC(v *C.sqlite3_value) driver.Value {
+	switch C.sqlite3_value_type(v) {
+	case C.SQLITE_INTEGER:
+		return int64(C.sqlite3_value_int64(v))
+	case C.SQLITE_FLOAT:
+		return float64(C.sqlite3_value_double(v))
+	case C.SQLITE_TEXT:
+		n := C.sqlite3_value_bytes(v)
+		return C.GoStringN((*C.char)(unsafe.Pointer(C.sqlite3_value_text(v))), n)
+	case C.SQLITE_BLOB:
+		n := C.sqlite3_value_bytes(v)
+		return C.GoBytes(C.sqlite3_value_blob(v), n)
+	default:
+		return nil
+	}
+}
+
+// setResult sets the result of an SQL function call.
+// See https://www.sqlite.org/c3ref/result_blob.html
+func setResult(ctx *C.sqlite3_context, v driver.Value) error {
+	switch v := v.(type) {
+	case nil:
+		C.sqlite3_result_null(ctx)
+	case bool:
+		if v {
+			C.sqlite3_result_int64(ctx, 1)
+		} else {
+			C.sqlite3_result_int64(ctx, 0)
+		}
+	case int:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case int64:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case float64:
+		C.sqlite3_result_double(ctx, C.double(v))
+	case string:
+		C.my_result_text(ctx, textPointer(v), C.int(len(v)))
+	case []byte:
+		switch {
+		case v == nil:
+			C.sqlite3_result_null(ctx)
+		case len(v) == 0:
+			C.sqlite3_result_zeroblob(ctx, 0)
+		default:
+			C.my_result_blob(ctx, unsafe.Pointer(&v[0]), C.int(len(v)))
+		}
+	default:
+		return fmt.Errorf("unsupported function result type %T", v)
+	}
+	return nil
+}
+
+func setResultError(ctx *C.sqlite3_context, err error) {
+	msg := err.Error()
+	cMsg := C.CString(msg)
+	C.sqlite3_result_error(ctx, cMsg, C.int(len(msg)))
+	C.free(unsafe.Pointer(cMsg))
+}
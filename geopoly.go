@@ -0,0 +1,155 @@
+//go:build cgo && geopoly
+
+package sqlite
+
+/*
+#cgo CFLAGS: -DSQLITE_ENABLE_RTREE -DSQLITE_ENABLE_GEOPOLY
+*/
+import "C"
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Point is a vertex of a Polygon, such as a longitude X and latitude Y.
+type Point struct {
+	X, Y float64
+}
+
+// Polygon is a simple polygon for the geopoly extension, with its vertices in counter-clockwise order.
+// The closing vertex is implied, so don't repeat the first vertex at the end.
+// Geopoly stores coordinates as 32-bit floats, so scanned coordinates are rounded to float32 precision.
+//
+// It is bound as the GeoJSON-like text geopoly accepts, such as [[0,0],[1,0],[1,1],[0,0]],
+// and can be scanned from both that text and the geopoly binary format, for example from the _shape column.
+// See https://www.sqlite.org/geopoly.html
+type Polygon []Point
+
+// Value satisfies driver.Valuer.
+func (p Polygon) Value() (driver.Value, error) {
+	if len(p) < 3 {
+		return nil, fmt.Errorf("polygon must have at least 3 vertices, has %v", len(p))
+	}
+
+	coords := make([][2]float64, 0, len(p)+1)
+	for _, v := range p {
+		coords = append(coords, [2]float64{v.X, v.Y})
+	}
+	coords = append(coords, coords[0])
+
+	b, err := json.Marshal(coords)
+	if err != nil {
+		return nil, wrapError("error marshalling polygon", err)
+	}
+	return string(b), nil
+}
+
+// Scan satisfies sql.Scanner.
+func (p *Polygon) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return p.parseJSON([]byte(src))
+	case []byte:
+		if strings.HasPrefix(strings.TrimSpace(string(src)), "[") {
+			return p.parseJSON(src)
+		}
+		return p.parseBinary(src)
+	case nil:
+		*p = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into *Polygon", src)
+	}
+}
+
+func (p *Polygon) parseJSON(src []byte) error {
+	var coords [][2]float64
+	if err := json.Unmarshal(src, &coords); err != nil {
+		return wrapError("error parsing polygon", err)
+	}
+	if len(coords) < 4 || coords[0] != coords[len(coords)-1] {
+		return errors.New("polygon must have at least 4 vertices, with the last the same as the first")
+	}
+
+	poly := make(Polygon, 0, len(coords)-1)
+	for _, c := range coords[:len(coords)-1] {
+		poly = append(poly, Point{X: c[0], Y: c[1]})
+	}
+	*p = poly
+	return nil
+}
+
+// parseBinary parses the geopoly binary format: a byte for the byte order (1 for little-endian),
+// a 3-byte big-endian vertex count, and then pairs of 32-bit float coordinates.
+func (p *Polygon) parseBinary(src []byte) error {
+	if len(src) < 4 || src[0] > 1 {
+		return errors.New("invalid geopoly polygon blob")
+	}
+	n := int(src[1])<<16 | int(src[2])<<8 | int(src[3])
+	if len(src) != 4+n*8 {
+		return errors.New("invalid geopoly polygon blob")
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if src[0] == 1 {
+		order = binary.LittleEndian
+	}
+
+	poly := make(Polygon, n)
+	for i := range poly {
+		b := src[4+i*8:]
+		poly[i] = Point{
+			X: float64(math.Float32frombits(order.Uint32(b))),
+			Y: float64(math.Float32frombits(order.Uint32(b[4:]))),
+		}
+	}
+	*p = poly
+	return nil
+}
+
+// CreateGeopolyTable creates a geopoly virtual table with the given auxiliary columns,
+// in addition to the _shape column geopoly adds.
+// See https://www.sqlite.org/geopoly.html#using_the_geopoly_extension
+func CreateGeopolyTable(ctx context.Context, q Querier, table string, columns ...string) error {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdentifier(c)
+	}
+
+	query := "create virtual table " + quoteIdentifier(table) + " using geopoly(" + strings.Join(quoted, ", ") + ")"
+	if _, err := q.ExecContext(ctx, query); err != nil {
+		return wrapError("error creating geopoly table %v", err, table)
+	}
+	return nil
+}
+
+// GeopolyContainsPoint returns the rowids of the polygons in the geopoly table that contain p,
+// including on their boundary.
+// See https://www.sqlite.org/geopoly.html#the_geopoly_contains_point_p_x_y_function
+func GeopolyContainsPoint(ctx context.Context, q Querier, table string, p Point) ([]int64, error) {
+	query := "select rowid from " + quoteIdentifier(table) + " where geopoly_contains_point(_shape, ?, ?) order by rowid"
+	rowids, err := QueryScalars[int64](ctx, q, query, p.X, p.Y)
+	if err != nil {
+		return nil, wrapError("error querying polygons containing point in %v", err, table)
+	}
+	return rowids, nil
+}
+
+// GeopolyOverlap returns the rowids of the polygons in the geopoly table that overlap poly.
+// The query uses the R*Tree index of the table.
+// See https://www.sqlite.org/geopoly.html#the_geopoly_overlap_p1_p2_function
+func GeopolyOverlap(ctx context.Context, q Querier, table string, poly Polygon) ([]int64, error) {
+	query := "select rowid from " + quoteIdentifier(table) + " where geopoly_overlap(_shape, ?) order by rowid"
+	rowids, err := QueryScalars[int64](ctx, q, query, poly)
+	if err != nil {
+		return nil, wrapError("error querying polygons overlapping in %v", err, table)
+	}
+	return rowids, nil
+}
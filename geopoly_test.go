@@ -0,0 +1,78 @@
+//go:build cgo && geopoly
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestGeopoly(t *testing.T) {
+	t.Run("creates a table, inserts polygons, and queries containment and overlap", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		ctx := context.Background()
+
+		err := sqlite.CreateGeopolyTable(ctx, db, "areas", "name")
+		assert.NoErr(t, err)
+
+		small := sqlite.Polygon{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+		large := sqlite.Polygon{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+		far := sqlite.Polygon{{X: 20, Y: 20}, {X: 30, Y: 20}, {X: 25, Y: 30}}
+		_, err = db.Exec(`insert into areas (_shape, name) values (?, 'small'), (?, 'large'), (?, 'far')`, small, large, far)
+		assert.NoErr(t, err)
+
+		var names []string
+		rows, err := db.Query(`select name from areas where geopoly_contains_point(_shape, ?, ?) order by rowid`, 0.5, 0.5)
+		assert.NoErr(t, err)
+		for rows.Next() {
+			var name string
+			assert.NoErr(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, 2, len(names))
+		assert.Equal(t, "small", names[0])
+		assert.Equal(t, "large", names[1])
+
+		rowids, err := sqlite.GeopolyContainsPoint(ctx, db, "areas", sqlite.Point{X: 5, Y: 5})
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, len(rowids))
+		assert.Equal(t, int64(2), rowids[0])
+
+		rowids, err = sqlite.GeopolyOverlap(ctx, db, "areas", sqlite.Polygon{{X: 9, Y: 9}, {X: 21, Y: 9}, {X: 21, Y: 21}, {X: 9, Y: 21}})
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, len(rowids))
+		assert.Equal(t, int64(2), rowids[0])
+		assert.Equal(t, int64(3), rowids[1])
+	})
+
+	t.Run("scans polygons from the binary format and from text", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		ctx := context.Background()
+
+		err := sqlite.CreateGeopolyTable(ctx, db, "areas")
+		assert.NoErr(t, err)
+
+		triangle := sqlite.Polygon{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 1, Y: 1.5}}
+		_, err = db.Exec(`insert into areas (_shape) values (?)`, triangle)
+		assert.NoErr(t, err)
+
+		var fromBlob, fromText sqlite.Polygon
+		err = db.QueryRow(`select _shape, geopoly_json(_shape) from areas`).Scan(&fromBlob, &fromText)
+		assert.NoErr(t, err)
+		for _, p := range []sqlite.Polygon{fromBlob, fromText} {
+			assert.Equal(t, 3, len(p))
+			for i := range triangle {
+				assert.Equal(t, triangle[i], p[i])
+			}
+		}
+	})
+
+	t.Run("errors on a polygon with fewer than 3 vertices", func(t *testing.T) {
+		_, err := sqlite.Polygon{{X: 0, Y: 0}, {X: 1, Y: 1}}.Value()
+		assert.Err(t, err)
+	})
+}
@@ -1,3 +1,8 @@
 module github.com/maragudk/sqlite
 
-go 1.19
+go 1.23
+
+require (
+	github.com/shopspring/decimal v1.4.0
+	golang.org/x/text v0.14.0
+)
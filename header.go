@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const headerMagic = "SQLite format 3\x00"
+
+type TextEncoding int
+
+const (
+	TextEncodingUTF8    = TextEncoding(1)
+	TextEncodingUTF16LE = TextEncoding(2)
+	TextEncodingUTF16BE = TextEncoding(3)
+)
+
+func (t TextEncoding) String() string {
+	switch t {
+	case TextEncodingUTF8:
+		return "UTF-8"
+	case TextEncodingUTF16LE:
+		return "UTF-16le"
+	case TextEncodingUTF16BE:
+		return "UTF-16be"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// DBHeader holds the fields of the 100-byte database file header.
+// See https://www.sqlite.org/fileformat.html#the_database_header
+type DBHeader struct {
+	Magic              string
+	PageSize           int
+	WriteVersion       int
+	ReadVersion        int
+	ReservedBytes      int
+	FileChangeCounter  uint32
+	PageCount          uint32
+	FreelistPageCount  uint32
+	SchemaCookie       uint32
+	SchemaFormat       uint32
+	TextEncoding       TextEncoding
+	UserVersion        int32
+	ApplicationID      int32
+	VersionValidFor    uint32
+	SQLiteVersion      uint32
+	IncrementalVacuum  bool
+	DefaultCacheSize   int32
+	LargestRootBTree   uint32
+	FirstFreelistTrunk uint32
+}
+
+// Header reads the database file header at path directly from the file, without opening it with SQLite.
+// Note that the header on disk may be out of date with changes still in the WAL file.
+func Header(path string) (DBHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return DBHeader{}, wrapError("error opening database file", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	b := make([]byte, 100)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return DBHeader{}, wrapError("error reading database header", err)
+	}
+
+	if string(b[:16]) != headerMagic {
+		return DBHeader{}, errors.New("not a SQLite database file: invalid header magic string")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(b[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+
+	return DBHeader{
+		Magic:              string(b[:15]),
+		PageSize:           pageSize,
+		WriteVersion:       int(b[18]),
+		ReadVersion:        int(b[19]),
+		ReservedBytes:      int(b[20]),
+		FileChangeCounter:  binary.BigEndian.Uint32(b[24:28]),
+		PageCount:          binary.BigEndian.Uint32(b[28:32]),
+		FirstFreelistTrunk: binary.BigEndian.Uint32(b[32:36]),
+		FreelistPageCount:  binary.BigEndian.Uint32(b[36:40]),
+		SchemaCookie:       binary.BigEndian.Uint32(b[40:44]),
+		SchemaFormat:       binary.BigEndian.Uint32(b[44:48]),
+		DefaultCacheSize:   int32(binary.BigEndian.Uint32(b[48:52])),
+		LargestRootBTree:   binary.BigEndian.Uint32(b[52:56]),
+		TextEncoding:       TextEncoding(binary.BigEndian.Uint32(b[56:60])),
+		UserVersion:        int32(binary.BigEndian.Uint32(b[60:64])),
+		IncrementalVacuum:  binary.BigEndian.Uint32(b[64:68]) != 0,
+		ApplicationID:      int32(binary.BigEndian.Uint32(b[68:72])),
+		VersionValidFor:    binary.BigEndian.Uint32(b[92:96]),
+		SQLiteVersion:      binary.BigEndian.Uint32(b[96:100]),
+	}, nil
+}
@@ -0,0 +1,60 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestHeader(t *testing.T) {
+	t.Run("reads the header of a database file", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{Name: name})
+
+		p := path.Join(t.TempDir(), "app.db")
+		db, err := sql.Open(name, p)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`pragma user_version = 7`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`pragma application_id = 123`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		var pageSize int
+		err = db.QueryRow(`pragma page_size`).Scan(&pageSize)
+		assert.NoErr(t, err)
+
+		// Closing the last connection checkpoints the WAL into the main file
+		assert.NoErr(t, db.Close())
+
+		h, err := sqlite.Header(p)
+		assert.NoErr(t, err)
+		assert.Equal(t, "SQLite format 3", h.Magic)
+		assert.Equal(t, pageSize, h.PageSize)
+		assert.Equal(t, 2, h.WriteVersion)
+		assert.Equal(t, 2, h.ReadVersion)
+		assert.Equal(t, sqlite.TextEncodingUTF8, h.TextEncoding)
+		assert.Equal(t, int32(7), h.UserVersion)
+		assert.Equal(t, int32(123), h.ApplicationID)
+		assert.Equal(t, true, h.SchemaCookie > 0)
+	})
+
+	t.Run("errors on non-database file", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		err := os.WriteFile(p, make([]byte, 100), 0600)
+		assert.NoErr(t, err)
+
+		_, err = sqlite.Header(p)
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are used by NewHistogram if no buckets are given.
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram counts query durations in buckets. It is safe for concurrent use.
+// Set it in Options.LatencyHistogram to record the duration of every query run through the driver.
+type Histogram struct {
+	buckets []time.Duration
+	counts  []atomic.Uint64
+	sum     atomic.Int64
+}
+
+// NewHistogram with the given bucket upper bounds. Durations larger than the largest bound
+// are counted in an extra overflow bucket.
+func NewHistogram(buckets ...time.Duration) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]atomic.Uint64, len(buckets)+1),
+	}
+}
+
+// Observe records a duration.
+func (h *Histogram) Observe(d time.Duration) {
+	i := sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Buckets returns the bucket upper bounds.
+func (h *Histogram) Buckets() []time.Duration {
+	return append([]time.Duration(nil), h.buckets...)
+}
+
+// Counts returns the number of observations in each bucket. The counts are not cumulative,
+// and the last count is the overflow bucket for durations larger than the largest bound.
+func (h *Histogram) Counts() []uint64 {
+	counts := make([]uint64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+	}
+	return counts
+}
+
+// Count returns the total number of observations.
+func (h *Histogram) Count() uint64 {
+	var count uint64
+	for i := range h.counts {
+		count += h.counts[i].Load()
+	}
+	return count
+}
+
+// Sum returns the sum of all observed durations.
+func (h *Histogram) Sum() time.Duration {
+	return time.Duration(h.sum.Load())
+}
@@ -0,0 +1,66 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	t.Run("counts durations in buckets", func(t *testing.T) {
+		h := sqlite.NewHistogram(10*time.Millisecond, time.Millisecond)
+
+		var wg sync.WaitGroup
+		for _, d := range []time.Duration{time.Microsecond, 2 * time.Millisecond, 5 * time.Millisecond, time.Second} {
+			wg.Add(1)
+			go func(d time.Duration) {
+				defer wg.Done()
+				h.Observe(d)
+			}(d)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 2, len(h.Buckets()))
+		assert.Equal(t, time.Millisecond, h.Buckets()[0])
+		counts := h.Counts()
+		assert.Equal(t, uint64(1), counts[0])
+		assert.Equal(t, uint64(2), counts[1])
+		assert.Equal(t, uint64(1), counts[2])
+		assert.Equal(t, uint64(4), h.Count())
+	})
+}
+
+func TestOptions_LatencyHistogram(t *testing.T) {
+	t.Run("records query durations", func(t *testing.T) {
+		registerTestFunctions(t)
+
+		h := sqlite.NewHistogram(10*time.Millisecond, time.Hour)
+		db := open(t, sqlite.Options{LatencyHistogram: h})
+
+		actual, ok := sqlite.LatencyHistogram(db)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, h, actual)
+
+		// Make sure the connection is opened before counting
+		assert.NoErr(t, db.Ping())
+		before := h.Count()
+
+		_, err := db.Exec(`select 1`)
+		assert.NoErr(t, err)
+		var v any
+		err = db.QueryRow(`select sleep_ms(20)`).Scan(&v)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`select sleep_ms(30)`)
+		assert.NoErr(t, err)
+
+		counts := h.Counts()
+		assert.Equal(t, before+3, h.Count())
+		assert.Equal(t, uint64(2), counts[1])
+		assert.Equal(t, true, h.Sum() >= 50*time.Millisecond)
+	})
+}
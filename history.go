@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"sync"
+	"time"
+)
+
+// StatementRecord is a statement run on a connection, kept if Options.StatementHistory is set.
+type StatementRecord struct {
+	Query    string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// statementHistory is a fixed-size ring buffer of the most recent statements.
+// It's safe for concurrent use, so it can be read when warning about Options.WriteLockTimeout.
+type statementHistory struct {
+	lock    sync.Mutex
+	records []StatementRecord
+	next    int
+	full    bool
+}
+
+func newStatementHistory(size int) *statementHistory {
+	return &statementHistory{records: make([]StatementRecord, size)}
+}
+
+func (h *statementHistory) add(r StatementRecord) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.records[h.next] = r
+	h.next++
+	if h.next == len(h.records) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// list the records, oldest first.
+func (h *statementHistory) list() []StatementRecord {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.full {
+		return append([]StatementRecord(nil), h.records[:h.next]...)
+	}
+	records := make([]StatementRecord, 0, len(h.records))
+	records = append(records, h.records[h.next:]...)
+	return append(records, h.records[:h.next]...)
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"fmt"
+	"math"
+)
+
+// intValue returns v as an int64 if it's one of Go's integer types, and false if it's not.
+// SQLite integers are signed 64-bit, so unsigned values larger than math.MaxInt64 are an error,
+// instead of wrapping around to negative numbers or losing precision as REAL.
+// Bind such values as text or blob if they must be stored.
+func intValue(v any) (int64, bool, error) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true, nil
+	case int8:
+		return int64(v), true, nil
+	case int16:
+		return int64(v), true, nil
+	case int32:
+		return int64(v), true, nil
+	case int64:
+		return v, true, nil
+	case uint:
+		return uintValue(uint64(v))
+	case uint8:
+		return int64(v), true, nil
+	case uint16:
+		return int64(v), true, nil
+	case uint32:
+		return int64(v), true, nil
+	case uint64:
+		return uintValue(v)
+	default:
+		return 0, false, nil
+	}
+}
+
+func uintValue(v uint64) (int64, bool, error) {
+	if v > math.MaxInt64 {
+		return 0, true, fmt.Errorf("unsigned integer %v overflows SQLite's signed 64-bit integers", v)
+	}
+	return int64(v), true, nil
+}
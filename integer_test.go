@@ -0,0 +1,83 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestDB_Exec_integers(t *testing.T) {
+	t.Run("binds all integer types as integers", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v)`)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t values (?), (?), (?), (?), (?), (?), (?), (?), (?), (?)`,
+			int(-1), int8(-2), int16(-3), int32(-4), int64(math.MinInt64),
+			uint(1), uint8(2), uint16(3), uint32(math.MaxUint32), uint64(math.MaxInt64))
+		assert.NoErr(t, err)
+
+		var count int
+		err = db.QueryRow(`select count(*) from t where typeof(v) = 'integer'`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 10, count)
+
+		var v uint64
+		err = db.QueryRow(`select max(v) from t`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, uint64(math.MaxInt64), v)
+	})
+
+	t.Run("errors on unsigned integers larger than the maximum int64", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`select ?`, uint64(math.MaxInt64)+1)
+		assert.Err(t, err)
+	})
+
+	t.Run("binds integer types through the driver directly", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		err = conn.Raw(func(driverConn any) error {
+			s, err := driverConn.(driver.Conn).Prepare(`select ? + ? + ?`)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = s.Close()
+			}()
+
+			_, err = s.Query([]driver.Value{uint64(math.MaxUint64), 0, 0})
+			assert.Err(t, err)
+
+			rows, err := s.Query([]driver.Value{int32(1), uint16(2), uint(3)})
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = rows.Close()
+			}()
+
+			dest := make([]driver.Value, 1)
+			if err := rows.Next(dest); err != nil {
+				return err
+			}
+			assert.Equal(t, int64(6), dest[0].(int64))
+			return nil
+		})
+		assert.NoErr(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// IntegrityProblem is a problem found by IntegrityCheck.
+// Page and Table are parsed from the message on a best-effort basis, and are zero if SQLite doesn't mention them.
+type IntegrityProblem struct {
+	// Message is the message as returned by SQLite.
+	Message string
+	// Database is the schema name the problem is in, such as "main", if given.
+	Database string
+	// Page is the database page number the problem is on.
+	Page int
+	// Table is the name of the table or index the problem is in.
+	Table string
+}
+
+var (
+	integrityDatabase = regexp.MustCompile(`^\*\*\* in database (\S+) \*\*\*$`)
+	integrityPage     = regexp.MustCompile(`(?:^On tree page|^Page|reference to page|of page) (\d+)`)
+	integrityTable    = regexp.MustCompile(`(?:in index|NULL value in|CHECK constraint failed in|NOT NULL constraint failed in) ([^\s.]+)`)
+)
+
+// IntegrityCheck runs PRAGMA integrity_check, returning the problems found, or nil if the database is ok.
+// If the corruption is so bad that SQLite stops checking, the problems found so far are returned,
+// followed by a problem with the error message.
+// See https://www.sqlite.org/pragma.html#pragma_integrity_check
+func IntegrityCheck(ctx context.Context, q Querier) ([]IntegrityProblem, error) {
+	rows, err := q.QueryContext(ctx, `pragma integrity_check`)
+	if err != nil {
+		return nil, wrapError("error running integrity check", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var problems []IntegrityProblem
+	var database string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, wrapError("error scanning integrity check result", err)
+		}
+		if result == "ok" {
+			continue
+		}
+
+		// A result can have several problems on separate lines, with a line naming the database before the first
+		for _, msg := range strings.Split(result, "\n") {
+			if m := integrityDatabase.FindStringSubmatch(msg); m != nil {
+				database = m[1]
+				continue
+			}
+			problems = append(problems, parseIntegrityProblem(database, msg))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		var e *Error
+		if len(problems) == 0 || !errors.As(err, &e) || e.Code != CodeCorrupt {
+			return nil, wrapError("error running integrity check", err)
+		}
+		problems = append(problems, IntegrityProblem{Message: e.Msg, Database: database})
+	}
+	return problems, nil
+}
+
+// parseIntegrityProblem parses msg from PRAGMA integrity_check, such as "On tree page 6 cell 0: Extends off end of page".
+func parseIntegrityProblem(database, msg string) IntegrityProblem {
+	p := IntegrityProblem{Message: msg, Database: database}
+
+	if m := integrityPage.FindStringSubmatch(p.Message); m != nil {
+		p.Page, _ = strconv.Atoi(m[1])
+	}
+
+	if m := integrityTable.FindStringSubmatch(p.Message); m != nil {
+		p.Table = m[1]
+	}
+
+	return p
+}
@@ -0,0 +1,62 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestIntegrityCheck(t *testing.T) {
+	t.Run("returns nil for an ok database", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+
+		problems, err := sqlite.IntegrityCheck(context.Background(), db)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("parses the database and page of a corrupted page", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		opts := sqlite.Options{JournalMode: sqlite.JournalModeDelete}
+		db := openPath(t, opts, p)
+
+		_, err := db.Exec(`create table t (v blob)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t select randomblob(100) from (with recursive n(i) as (select 1 union all select i + 1 from n where i < 500) select i from n)`)
+		assert.NoErr(t, err)
+
+		var pageSize int
+		err = db.QueryRow(`select page_size from pragma_page_size`).Scan(&pageSize)
+		assert.NoErr(t, err)
+		assert.NoErr(t, db.Close())
+
+		// Overwrite the cell count and content offset in the b-tree page header of page 5, a leaf page of t
+		f, err := os.OpenFile(p, os.O_RDWR, 0)
+		assert.NoErr(t, err)
+		_, err = f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, int64(pageSize*4+3))
+		assert.NoErr(t, err)
+		assert.NoErr(t, f.Close())
+
+		db = openPath(t, opts, p)
+		problems, err := sqlite.IntegrityCheck(context.Background(), db)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, len(problems))
+
+		assert.Equal(t, "Page 5: btreeInitPage() returns error code 11", problems[0].Message)
+		assert.Equal(t, "main", problems[0].Database)
+		assert.Equal(t, 5, problems[0].Page)
+
+		// SQLite stops checking after this corruption, which is reported as the last problem
+		assert.Equal(t, "database disk image is malformed", problems[1].Message)
+		assert.Equal(t, 0, problems[1].Page)
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 )
 
-func Err(t *testing.T, err error) {
+func Err(t testing.TB, err error) {
 	t.Helper()
 
 	if err == nil {
@@ -13,7 +13,7 @@ func Err(t *testing.T, err error) {
 	}
 }
 
-func NoErr(t *testing.T, err error) {
+func NoErr(t testing.TB, err error) {
 	t.Helper()
 
 	if err != nil {
@@ -21,7 +21,7 @@ func NoErr(t *testing.T, err error) {
 	}
 }
 
-func Equal[T comparable](t *testing.T, expected, actual T) {
+func Equal[T comparable](t testing.TB, expected, actual T) {
 	t.Helper()
 
 	if expected != actual {
@@ -29,7 +29,7 @@ func Equal[T comparable](t *testing.T, expected, actual T) {
 	}
 }
 
-func EqualBytes(t *testing.T, expected, actual []byte) {
+func EqualBytes(t testing.TB, expected, actual []byte) {
 	t.Helper()
 
 	if !bytes.Equal(expected, actual) {
@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// QueryScan runs query with args and returns an iterator over the result rows, scanned into values of type T.
+//
+// If T is a struct (other than time.Time or one implementing sql.Scanner), each result column is scanned into
+// the field with the matching db tag, see Insert for the tag format. Otherwise, the query must return a single column,
+// which is scanned into T. Scanning uses the usual conversions of database/sql, so T and struct fields can be
+// sql.Scanner implementations such as JSONObject.
+//
+// Errors while iterating, such as scan errors, are yielded with the zero value of T, after which iteration stops.
+// The iterator can only be ranged over once, and must be ranged over to release the connection.
+func QueryScan[T any](ctx context.Context, q Querier, query string, args ...any) (iter.Seq2[T, error], error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("error running query", err)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return nil, wrapError("error getting columns", err)
+	}
+
+	var zero T
+	fields, err := scanFields(reflect.TypeOf(&zero).Elem(), columns)
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+
+	return func(yield func(T, error) bool) {
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		dest := make([]any, len(columns))
+		for rows.Next() {
+			var v T
+			rv := reflect.ValueOf(&v).Elem()
+			if fields == nil {
+				dest[0] = &v
+			} else {
+				for i, f := range fields {
+					dest[i] = rv.Field(f).Addr().Interface()
+				}
+			}
+
+			if err := rows.Scan(dest...); err != nil {
+				yield(zero, wrapError("error scanning row", err))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, wrapError("error reading rows", err))
+		}
+	}, nil
+}
+
+// QueryScalars runs query with args and returns the values of its single result column, scanned into values of type T.
+// Unlike QueryScan, T is always scanned into as a whole, even if it's a struct, and the query must return exactly one column.
+// Scanning uses the usual conversions of database/sql, including time.Time for time columns and
+// sql.Scanner implementations.
+func QueryScalars[T any](ctx context.Context, q Querier, query string, args ...any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("error running query", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, wrapError("error getting columns", err)
+	}
+	if len(columns) != 1 {
+		var zero T
+		return nil, fmt.Errorf("query must return exactly one column to scan into %T, got %v", zero, len(columns))
+	}
+
+	var vs []T
+	for rows.Next() {
+		var v T
+		if err := rows.Scan(&v); err != nil {
+			return nil, wrapError("error scanning row", err)
+		}
+		vs = append(vs, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError("error reading rows", err)
+	}
+	return vs, nil
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// scanFields returns the struct field indexes to scan columns into, by column, if t is a struct to scan by db tag.
+// Otherwise, it returns nil, and there must be exactly one column.
+func scanFields(t reflect.Type, columns []string) ([]int, error) {
+	if t.Kind() != reflect.Struct || t == timeType || reflect.PointerTo(t).Implements(scannerType) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("query must return exactly one column to scan into %v, got %v", t, len(columns))
+		}
+		return nil, nil
+	}
+
+	byName := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("db")
+		if !f.IsExported() || !ok || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		byName[name] = i
+	}
+
+	fields := make([]int, len(columns))
+	for i, column := range columns {
+		f, ok := byName[column]
+		if !ok {
+			return nil, fmt.Errorf("no db-tagged field in %v for column %v", t, column)
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
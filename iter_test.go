@@ -0,0 +1,136 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestQueryScan(t *testing.T) {
+	t.Run("ranges over rows scanned into structs", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table people (id integer primary key, name text not null, age int not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into people (name, age) values ('Alice', 30), ('Bob', 25)`)
+		assert.NoErr(t, err)
+
+		people, err := sqlite.QueryScan[person](context.Background(), db, `select name, age from people where age > ? order by id`, 20)
+		assert.NoErr(t, err)
+
+		var names []string
+		var ages int
+		for p, err := range people {
+			assert.NoErr(t, err)
+			names = append(names, p.Name)
+			ages += p.Age
+		}
+		assert.Equal(t, 2, len(names))
+		assert.Equal(t, "Alice", names[0])
+		assert.Equal(t, "Bob", names[1])
+		assert.Equal(t, 55, ages)
+	})
+
+	t.Run("ranges over single column rows", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		values, err := sqlite.QueryScan[int](context.Background(), db, `values (1), (2), (3)`)
+		assert.NoErr(t, err)
+
+		var sum int
+		for v, err := range values {
+			assert.NoErr(t, err)
+			sum += v
+		}
+		assert.Equal(t, 6, sum)
+	})
+
+	t.Run("yields an error mid-iteration and stops", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		values, err := sqlite.QueryScan[int](context.Background(), db, `values (1), ('not a number'), (3)`)
+		assert.NoErr(t, err)
+
+		var got []int
+		var iterErr error
+		for v, err := range values {
+			if err != nil {
+				iterErr = err
+				continue
+			}
+			got = append(got, v)
+		}
+		assert.Err(t, iterErr)
+		assert.Equal(t, 1, len(got))
+		assert.Equal(t, 1, got[0])
+	})
+
+	t.Run("can stop early", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		db.SetMaxOpenConns(1)
+
+		values, err := sqlite.QueryScan[int](context.Background(), db, `values (1), (2), (3)`)
+		assert.NoErr(t, err)
+
+		for range values {
+			break
+		}
+
+		// The connection has been released, or this would block
+		assert.NoErr(t, db.Ping())
+	})
+
+	t.Run("errors on columns without a matching field", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := sqlite.QueryScan[person](context.Background(), db, `select 1 as nope`)
+		assert.Err(t, err)
+	})
+}
+
+func TestQueryScalars(t *testing.T) {
+	t.Run("collects a column of times", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table events (id integer primary key, happened datetime not null)`)
+		assert.NoErr(t, err)
+
+		t1 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		t2 := time.Date(2024, 6, 7, 8, 9, 10, 123000000, time.UTC)
+		_, err = db.Exec(`insert into events (happened) values (?), (?)`, t1, t2)
+		assert.NoErr(t, err)
+
+		times, err := sqlite.QueryScalars[time.Time](context.Background(), db, `select happened from events order by id`)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, len(times))
+		assert.Equal(t, true, t1.Equal(times[0]))
+		assert.Equal(t, true, t2.Equal(times[1]))
+	})
+
+	t.Run("returns nil for no rows", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		values, err := sqlite.QueryScalars[int](context.Background(), db, `select 1 where false`)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, len(values))
+	})
+
+	t.Run("errors if the query doesn't return exactly one column", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := sqlite.QueryScalars[int](context.Background(), db, `select 1, 2`)
+		assert.Err(t, err)
+	})
+
+	t.Run("errors if a value can't be scanned", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := sqlite.QueryScalars[int](context.Background(), db, `values (1), ('not a number')`)
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// JSONObject is a map bound as JSON object text, for use with SQLite's JSON functions.
+// A nil JSONObject is bound as NULL.
+// Use a *JSONObject as a scan destination to unmarshal a JSON object column.
+//
+//	db.Exec(`insert into docs (v) values (?)`, sqlite.JSONObject(m))
+//	var o sqlite.JSONObject
+//	db.QueryRow(`select v from docs`).Scan(&o)
+type JSONObject map[string]any
+
+// Value satisfies driver.Valuer.
+func (o JSONObject) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(map[string]any(o))
+	if err != nil {
+		return nil, wrapError("error marshalling JSON object", err)
+	}
+	return string(b), nil
+}
+
+// Scan satisfies sql.Scanner. NULL scans into a nil map.
+func (o *JSONObject) Scan(src any) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		*o = nil
+		return nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONObject", src)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return wrapError("error unmarshalling JSON object", err)
+	}
+	*o = m
+	return nil
+}
+
+// JSON returns a scanner that unmarshals a JSON TEXT or BLOB column value into dest, which must be a pointer.
+// It's the scanning counterpart to binding maps, slices, and structs, which are bound as JSON text:
+//
+//	db.Exec(`insert into docs (tags) values (?)`, []string{"a", "b"})
+//	var tags []string
+//	db.QueryRow(`select tags from docs`).Scan(sqlite.JSON(&tags))
+//
+// NULL is unmarshalled like the JSON null, so it sets maps, slices, and pointers to nil and leaves other values unchanged.
+func JSON(dest any) sql.Scanner {
+	return jsonScanner{dest: dest}
+}
+
+type jsonScanner struct {
+	dest any
+}
+
+// Scan satisfies sql.Scanner.
+func (s jsonScanner) Scan(src any) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		b = []byte("null")
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T as JSON", src)
+	}
+
+	if err := json.Unmarshal(b, s.dest); err != nil {
+		return wrapError("error unmarshalling JSON", err)
+	}
+	return nil
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// jsonValue returns v as JSON text if it's a json.RawMessage, which is passed through, or a map, slice, array,
+// or struct, which is marshalled. It returns false for other values, including byte slices and arrays, and time.Time.
+// Pointers to these are dereferenced. Nil maps, slices, and json.RawMessage are bound as NULL.
+func jsonValue(v any) (driver.Value, bool, error) {
+	if v == nil {
+		return nil, false, nil
+	}
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+
+	if rt == rawMessageType {
+		if rv.IsNil() {
+			return nil, true, nil
+		}
+		return string(v.(json.RawMessage)), true, nil
+	}
+
+	switch rt.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, false, nil
+		}
+		return jsonValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return nil, false, nil
+		}
+		if rt.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, true, nil
+		}
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, true, nil
+		}
+	case reflect.Struct:
+		if rt == reflect.TypeOf(time.Time{}) {
+			return nil, false, nil
+		}
+	default:
+		return nil, false, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, true, wrapError("error marshalling %T as JSON", err, v)
+	}
+	return string(b), true, nil
+}
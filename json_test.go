@@ -0,0 +1,163 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestJSONObject(t *testing.T) {
+	t.Run("binds nested map as JSON and scans it back", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+
+		m := map[string]any{
+			"name": "party",
+			"place": map[string]any{
+				"city":  "Copenhagen",
+				"floor": 3,
+			},
+		}
+		_, err = db.Exec(`insert into t values (?)`, sqlite.JSONObject(m))
+		assert.NoErr(t, err)
+
+		var city string
+		var floor int
+		err = db.QueryRow(`select json_extract(v, '$.place.city'), json_extract(v, '$.place.floor') from t`).Scan(&city, &floor)
+		assert.NoErr(t, err)
+		assert.Equal(t, "Copenhagen", city)
+		assert.Equal(t, 3, floor)
+
+		var o sqlite.JSONObject
+		err = db.QueryRow(`select v from t`).Scan(&o)
+		assert.NoErr(t, err)
+		assert.Equal(t, "party", o["name"].(string))
+		assert.Equal(t, 3.0, o["place"].(map[string]any)["floor"].(float64))
+	})
+
+	t.Run("binds and scans nil as NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var isNull bool
+		err := db.QueryRow(`select ? is null`, sqlite.JSONObject(nil)).Scan(&isNull)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, isNull)
+
+		o := sqlite.JSONObject{"a": 1}
+		err = db.QueryRow(`select null`).Scan(&o)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, o == nil)
+	})
+}
+
+func TestDB_Exec_JSON(t *testing.T) {
+	t.Run("binds maps, slices, and structs as JSON text", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		type person struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}
+
+		var typ, name string
+		var age, n int
+		err := db.QueryRow(`select typeof(?), json_extract(?, '$.name'), json_extract(?, '$.age'), json_array_length(?)`,
+			map[string]int{"a": 1}, person{Name: "Ada", Age: 36}, &person{Name: "Ada", Age: 36}, []string{"a", "b"}).
+			Scan(&typ, &name, &age, &n)
+		assert.NoErr(t, err)
+		assert.Equal(t, "text", typ)
+		assert.Equal(t, "Ada", name)
+		assert.Equal(t, 36, age)
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("passes json.RawMessage through as text", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var typ string
+		var v int
+		err := db.QueryRow(`select typeof(?), json_extract(?, '$.v')`, json.RawMessage(`{"v":1}`), json.RawMessage(`{"v":2}`)).
+			Scan(&typ, &v)
+		assert.NoErr(t, err)
+		assert.Equal(t, "text", typ)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("binds nil maps, slices, and json.RawMessage as NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var a, b, c string
+		err := db.QueryRow(`select typeof(?), typeof(?), typeof(?)`, map[string]any(nil), []int(nil), json.RawMessage(nil)).
+			Scan(&a, &b, &c)
+		assert.NoErr(t, err)
+		assert.Equal(t, "null", a)
+		assert.Equal(t, "null", b)
+		assert.Equal(t, "null", c)
+	})
+
+	t.Run("still binds byte slices as blobs and times as text", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var a, b string
+		err := db.QueryRow(`select typeof(?), typeof(?)`, []byte("a"), time.Now()).Scan(&a, &b)
+		assert.NoErr(t, err)
+		assert.Equal(t, "blob", a)
+		assert.Equal(t, "text", b)
+	})
+
+	t.Run("errors if a value can't be marshalled", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`select ?`, map[string]any{"f": func() {}})
+		assert.Err(t, err)
+	})
+}
+
+func TestJSON(t *testing.T) {
+	t.Run("scans JSON text into a Go value", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (?)`, []string{"a", "b"})
+		assert.NoErr(t, err)
+
+		var v []string
+		err = db.QueryRow(`select v from t`).Scan(sqlite.JSON(&v))
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, len(v))
+		assert.Equal(t, "a", v[0])
+		assert.Equal(t, "b", v[1])
+	})
+
+	t.Run("scans JSON blobs and NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var m map[string]int
+		err := db.QueryRow(`select cast('{"a":1}' as blob)`).Scan(sqlite.JSON(&m))
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, m["a"])
+
+		err = db.QueryRow(`select null`).Scan(sqlite.JSON(&m))
+		assert.NoErr(t, err)
+		assert.Equal(t, true, m == nil)
+	})
+
+	t.Run("errors on invalid JSON and non-text values", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v any
+		err := db.QueryRow(`select 'not json'`).Scan(sqlite.JSON(&v))
+		assert.Err(t, err)
+
+		err = db.QueryRow(`select 1`).Scan(sqlite.JSON(&v))
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,481 @@
+package sqlite
+
+import (
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenIdent = tokenKind(iota)
+	tokenString
+	tokenNumber
+	tokenParam
+	tokenPunct
+)
+
+// token is a lexical token of an SQL query. Whitespace and comments are not tokens.
+type token struct {
+	kind tokenKind
+	// text is the token text. Quoted identifiers are unquoted, strings keep their quotes.
+	text string
+	// pos is the byte offset of the token in the query.
+	pos int
+}
+
+// is reports whether the token is the given keyword or punctuation, case-insensitively.
+func (t token) is(s string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenPunct) && strings.EqualFold(t.text, s)
+}
+
+// tokenize splits an SQL query into tokens. It is not a full SQL parser,
+// but knows enough about SQLite's syntax to not be fooled by strings, quoted identifiers, and comments.
+// See https://www.sqlite.org/lang_expr.html
+func tokenize(query string) []token {
+	var tokens []token
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+
+		case c == '\'':
+			i = skipQuoted(query, i, '\'')
+			tokens = append(tokens, token{kind: tokenString, text: query[start:i], pos: start})
+
+		case c == '"' || c == '`':
+			i = skipQuoted(query, i, c)
+			text := strings.TrimSuffix(query[start+1:i], string(c))
+			text = strings.ReplaceAll(text, string([]byte{c, c}), string(c))
+			tokens = append(tokens, token{kind: tokenIdent, text: text, pos: start})
+
+		case c == '[':
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.TrimSuffix(query[start+1:i], "]"), pos: start})
+
+		case c == '?':
+			i++
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenParam, text: query[start:i], pos: start})
+
+		case (c == ':' || c == '@' || c == '$') && i+1 < len(query) && isIdentChar(query[i+1]):
+			i++
+			for i < len(query) && isIdentChar(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenParam, text: query[start:i], pos: start})
+
+		case isDigit(c) || c == '.' && i+1 < len(query) && isDigit(query[i+1]):
+			for i < len(query) && (isIdentChar(query[i]) || query[i] == '.' ||
+				(query[i] == '+' || query[i] == '-') && (query[i-1] == 'e' || query[i-1] == 'E')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: query[start:i], pos: start})
+
+		case isIdentChar(c):
+			for i < len(query) && isIdentChar(query[i]) {
+				i++
+			}
+			if (c == 'x' || c == 'X') && i == start+1 && i < len(query) && query[i] == '\'' {
+				i = skipQuoted(query, i, '\'')
+				tokens = append(tokens, token{kind: tokenString, text: query[start:i], pos: start})
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: query[start:i], pos: start})
+
+		default:
+			i++
+			if i < len(query) {
+				switch query[start : i+1] {
+				case "==", "!=", "<>", "<=", ">=", "||", "<<", ">>", "->":
+					i++
+					if query[start:i] == "->" && i < len(query) && query[i] == '>' {
+						i++
+					}
+				}
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: query[start:i], pos: start})
+		}
+	}
+
+	return tokens
+}
+
+// skipQuoted returns the index after the closing quote of the quoted string starting at i,
+// handling doubled quotes as escapes.
+func skipQuoted(query string, i int, quote byte) int {
+	i++
+	for i < len(query) {
+		if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c >= 0x80
+}
+
+// paramColumn is the table column a parameter is inserted into or compared with.
+// If column is empty, index is the position of the column in the table.
+type paramColumn struct {
+	table  string
+	column string
+	index  int
+}
+
+// paramColumns guesses, by parameter index, which table columns the parameters of a query are inserted into
+// or compared with. It only understands simple INSERT, UPDATE, DELETE and SELECT statements
+// with the parameter directly next to a column name, and leaves out parameters it can't figure out.
+func paramColumns(query string) map[int]paramColumn {
+	tokens := tokenize(query)
+	indexes := paramIndexes(tokens)
+	columns := map[int]paramColumn{}
+	if len(tokens) == 0 {
+		return columns
+	}
+
+	var table string
+	switch {
+	case tokens[0].is("insert") || tokens[0].is("replace"):
+		j := 0
+		for j < len(tokens) && !tokens[j].is("into") {
+			j++
+		}
+		table, j = qualifiedName(tokens, j+1)
+
+		var names []string
+		if j < len(tokens) && tokens[j].is("(") {
+			for j++; j < len(tokens) && !tokens[j].is(")"); j++ {
+				if tokens[j].kind == tokenIdent {
+					names = append(names, tokens[j].text)
+				}
+			}
+			j++
+		}
+
+		if j < len(tokens) && tokens[j].is("values") {
+			depth, position := 0, 0
+		values:
+			for j++; j < len(tokens); j++ {
+				t := tokens[j]
+				switch {
+				case t.is("("):
+					depth++
+					if depth == 1 {
+						position = 0
+					}
+				case t.is(")"):
+					depth--
+				case t.is(",") && depth == 1:
+					position++
+				case t.kind == tokenParam && depth == 1 && isItemBoundary(tokens[j-1]) && j+1 < len(tokens) && isItemBoundary(tokens[j+1]):
+					if names != nil && position >= len(names) {
+						continue
+					}
+					pc := paramColumn{table: table, index: position}
+					if names != nil {
+						pc.column = names[position]
+					}
+					columns[indexes[j]] = pc
+				case depth == 0 && t.kind == tokenIdent:
+					// Things like upsert clauses and RETURNING after the values
+					break values
+				}
+			}
+		}
+		return columns
+
+	case tokens[0].is("update"):
+		j := 1
+		if j < len(tokens) && tokens[j].is("or") {
+			j += 2
+		}
+		table, _ = qualifiedName(tokens, j)
+
+	default:
+		for j, t := range tokens {
+			if t.is("from") {
+				table, _ = qualifiedName(tokens, j+1)
+				break
+			}
+		}
+	}
+
+	if table == "" {
+		return columns
+	}
+
+	for j, t := range tokens {
+		if t.kind != tokenParam {
+			continue
+		}
+		switch {
+		case j >= 2 && isComparison(tokens[j-1]) && tokens[j-2].kind == tokenIdent:
+			columns[indexes[j]] = paramColumn{table: table, column: tokens[j-2].text}
+		case j+2 < len(tokens) && isComparison(tokens[j+1]) && tokens[j+2].kind == tokenIdent:
+			columns[indexes[j]] = paramColumn{table: table, column: tokens[j+2].text}
+		}
+	}
+
+	return columns
+}
+
+// qualifiedName returns the possibly schema-qualified name starting at tokens[i], without the schema,
+// and the index after it.
+func qualifiedName(tokens []token, i int) (string, int) {
+	if i >= len(tokens) || tokens[i].kind != tokenIdent {
+		return "", i
+	}
+	if i+2 < len(tokens) && tokens[i+1].is(".") && tokens[i+2].kind == tokenIdent {
+		return tokens[i+2].text, i + 3
+	}
+	return tokens[i].text, i + 1
+}
+
+func isItemBoundary(t token) bool {
+	return t.is("(") || t.is(",") || t.is(")")
+}
+
+func isComparison(t token) bool {
+	if t.kind != tokenPunct {
+		return false
+	}
+	switch t.text {
+	case "=", "==", "!=", "<>", "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+// paramIndexes returns the SQLite parameter index by token position, for parameter tokens.
+// A plain ? gets the next index after the largest so far, ?NNN gets NNN,
+// and named parameters get the next index the first time they occur.
+// See https://www.sqlite.org/c3ref/bind_blob.html
+func paramIndexes(tokens []token) map[int]int {
+	indexes := map[int]int{}
+	named := map[string]int{}
+	largest := 0
+
+	for j, t := range tokens {
+		if t.kind != tokenParam {
+			continue
+		}
+
+		switch {
+		case t.text == "?":
+			largest++
+			indexes[j] = largest
+
+		case t.text[0] == '?':
+			n := 0
+			for _, c := range t.text[1:] {
+				n = n*10 + int(c-'0')
+			}
+			indexes[j] = n
+			if n > largest {
+				largest = n
+			}
+
+		default:
+			n, ok := named[t.text]
+			if !ok {
+				largest++
+				n = largest
+				named[t.text] = n
+			}
+			indexes[j] = n
+		}
+	}
+
+	return indexes
+}
+
+// leadingKeyword returns the first keyword of query, uppercased, skipping whitespace and comments.
+// It returns an empty string if the query doesn't start with a keyword.
+func leadingKeyword(query string) string {
+	tokens := tokenize(query)
+	if len(tokens) == 0 || tokens[0].kind != tokenIdent || query[tokens[0].pos] == '"' ||
+		query[tokens[0].pos] == '`' || query[tokens[0].pos] == '[' {
+		return ""
+	}
+	return strings.ToUpper(tokens[0].text)
+}
+
+// statementKeywords returns the leading keyword of each statement in query, like leadingKeyword.
+// Semicolons inside the body of CREATE TRIGGER don't end the statement.
+func statementKeywords(query string) []string {
+	tokens := tokenize(query)
+
+	var keywords []string
+	for len(tokens) > 0 {
+		end := statementEnd(tokens)
+		if end > 0 {
+			keywords = append(keywords, leadingKeyword(query[tokens[0].pos:]))
+		}
+		if end < len(tokens) {
+			end++
+		}
+		tokens = tokens[end:]
+	}
+	return keywords
+}
+
+// statementEnd returns the index of the semicolon ending the first statement in tokens, or len(tokens) if there is none.
+// In CREATE [TEMP|TEMPORARY] TRIGGER, semicolons between BEGIN and its END are inside the trigger body,
+// where CASE expressions also end with END, so BEGIN and CASE are counted to find the matching END.
+// See https://www.sqlite.org/lang_createtrigger.html
+func statementEnd(tokens []token) int {
+	trigger := isCreateTrigger(tokens)
+	var depth int
+	for i, t := range tokens {
+		switch {
+		case t.is(";") && depth == 0:
+			return i
+		case !trigger:
+		case t.is("BEGIN") || t.is("CASE"):
+			depth++
+		case t.is("END") && depth > 0:
+			depth--
+		}
+	}
+	return len(tokens)
+}
+
+// isCreateTrigger returns whether tokens start with CREATE [TEMP|TEMPORARY] TRIGGER.
+func isCreateTrigger(tokens []token) bool {
+	if len(tokens) < 2 || !tokens[0].is("CREATE") {
+		return false
+	}
+	i := 1
+	if tokens[i].is("TEMP") || tokens[i].is("TEMPORARY") {
+		i++
+	}
+	return i < len(tokens) && tokens[i].is("TRIGGER")
+}
+
+// mayAddNulls reports whether query has an outer join or a compound SELECT, which can return NULL
+// for table columns declared NOT NULL, or doesn't mention table, so the column comes from a view.
+func mayAddNulls(query, table string) bool {
+	tokens := tokenize(query)
+	mentioned := false
+	for i, t := range tokens {
+		switch {
+		case t.is("LEFT") || t.is("RIGHT") || t.is("FULL") || t.is("UNION") || t.is("INTERSECT") || t.is("EXCEPT"):
+			return true
+		case t.kind == tokenIdent && strings.EqualFold(t.text, table):
+			// A table name is never right after a dot, which would make it a column name
+			if i == 0 || !tokens[i-1].is(".") {
+				mentioned = true
+			}
+		}
+	}
+	return !mentioned
+}
+
+// containsFold reports whether values contains v, case-insensitively.
+func containsFold(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnorderedSelect reports whether query is a SELECT without a top-level ORDER BY,
+// so the order of the result rows is undefined.
+// See https://www.sqlite.org/lang_select.html#the_order_by_clause
+func isUnorderedSelect(query string) bool {
+	tokens := tokenize(query)
+	if len(tokens) == 0 || !tokens[0].is("select") && !tokens[0].is("with") {
+		return false
+	}
+
+	depth := 0
+	for j, t := range tokens {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case depth == 0 && t.is("order") && j+1 < len(tokens) && tokens[j+1].is("by"):
+			return false
+		}
+	}
+	return true
+}
+
+// translatePlaceholders rewrites the numbered placeholders of style in query, such as $1 or @p1,
+// to SQLite's numbered ?NNN parameters, such as ?1.
+// See https://www.sqlite.org/lang_expr.html#parameters
+func translatePlaceholders(query string, style PlaceholderStyle) string {
+	var prefix string
+	switch style {
+	case PlaceholderStyleDollar:
+		prefix = "$"
+	case PlaceholderStyleAtP:
+		prefix = "@p"
+	default:
+		return query
+	}
+
+	var b strings.Builder
+	var last int
+	for _, t := range tokenize(query) {
+		if t.kind != tokenParam || len(t.text) <= len(prefix) || !strings.EqualFold(t.text[:len(prefix)], prefix) {
+			continue
+		}
+		number := t.text[len(prefix):]
+		if strings.TrimLeft(number, "0123456789") != "" {
+			continue
+		}
+		b.WriteString(query[last:t.pos])
+		b.WriteString("?")
+		b.WriteString(number)
+		last = t.pos + len(t.text)
+	}
+	if last == 0 {
+		return query
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+// quoteIdentifier quotes an identifier such as a table or column name for use in a query.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
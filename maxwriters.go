@@ -0,0 +1,44 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"context"
+)
+
+// acquireWriter waits for one of the Options.MaxWriters slots before running a statement that may write,
+// unless the connection already holds a slot or the write lock.
+// The slot is released by checkWriteLock when the connection no longer has a write transaction.
+// See https://www.sqlite.org/c3ref/stmt_readonly.html
+func (c *connection) acquireWriter(ctx context.Context, cStatement *C.sqlite3_stmt) error {
+	if c.writers == nil || c.writer {
+		return nil
+	}
+	if cStatement != nil && C.sqlite3_stmt_readonly(cStatement) != 0 {
+		return nil
+	}
+	if C.sqlite3_txn_state(c.cC, nil) == C.SQLITE_TXN_WRITE {
+		return nil
+	}
+
+	select {
+	case c.writers <- struct{}{}:
+		c.writer = true
+		return nil
+	case <-ctx.Done():
+		return wrapError("error waiting for a writer slot", ctx.Err())
+	}
+}
+
+// releaseWriter releases the connection's Options.MaxWriters slot, if it holds one.
+func (c *connection) releaseWriter() {
+	if c.writer {
+		<-c.writers
+		c.writer = false
+	}
+}
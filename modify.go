@@ -0,0 +1,41 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdlib.h>
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"database/sql"
+	"unsafe"
+)
+
+// WillModify reports whether query would modify the database if run on conn, without running it.
+// SQLite decides this from the compiled query, so it handles for example common table expressions
+// before INSERT, and DDL. Transaction statements such as BEGIN and COMMIT don't modify the database by themselves.
+// Only the first statement in query is checked.
+// See https://www.sqlite.org/c3ref/stmt_readonly.html
+func WillModify(conn *sql.Conn, query string) (bool, error) {
+	var modifies bool
+	err := withConnection(conn, func(c *connection) error {
+		cQuery := C.CString(query)
+		defer C.free(unsafe.Pointer(cQuery))
+
+		var cStatement *C.sqlite3_stmt
+		if cCode := C.sqlite3_prepare_v2(c.cC, cQuery, C.int(len(query)+1), &cStatement, nil); cCode != C.SQLITE_OK {
+			return c.wrapErrorCode(`error preparing query "%v"`, cCode, query)
+		}
+		// The statement is nil for a query with only whitespace or comments
+		if cStatement == nil {
+			return nil
+		}
+		defer C.sqlite3_finalize(cStatement)
+
+		modifies = C.sqlite3_stmt_readonly(cStatement) == 0
+		return nil
+	})
+	return modifies, err
+}
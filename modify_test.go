@@ -0,0 +1,61 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestWillModify(t *testing.T) {
+	db := open(t, sqlite.Options{})
+
+	_, err := db.Exec(`create table t (v int)`)
+	assert.NoErr(t, err)
+
+	conn, err := db.Conn(context.Background())
+	assert.NoErr(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{`select * from t`, false},
+		{`with x as (select 1) select * from x`, false},
+		{`-- only a comment`, false},
+		{`insert into t values (1)`, true},
+		{`with x(v) as (select 1) insert into t select v from x`, true},
+		{`update t set v = 2`, true},
+		{`delete from t`, true},
+		{`create table u (v int)`, true},
+		{`drop table t`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			modifies, err := sqlite.WillModify(conn, test.query)
+			assert.NoErr(t, err)
+			assert.Equal(t, test.expected, modifies)
+		})
+	}
+
+	t.Run("does not run the query", func(t *testing.T) {
+		_, err := sqlite.WillModify(conn, `insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		var count int
+		err = conn.QueryRowContext(context.Background(), `select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("errors on invalid query", func(t *testing.T) {
+		_, err := sqlite.WillModify(conn, `select from`)
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// NDJSONMode is how ImportNDJSON handles malformed lines.
+type NDJSONMode int
+
+const (
+	// NDJSONStrict fails the import and rolls back on the first malformed line.
+	NDJSONStrict = NDJSONMode(iota)
+	// NDJSONSkip skips malformed lines.
+	NDJSONSkip
+)
+
+// ImportNDJSON reads newline-delimited JSON from r and inserts a row into table for each line,
+// all in one transaction. It returns the number of rows inserted.
+//
+// mapping converts each line to column values by column name. If mapping is nil, each line must be a JSON object,
+// which is used as is, with numbers decoded as json.Number. Numbers are bound as integers if they fit in an int64,
+// and as floats otherwise. Nested objects and arrays are bound as JSON text.
+// A line is malformed if it isn't valid JSON or mapping returns an error, and handled according to mode.
+// Empty lines are ignored.
+// See https://github.com/ndjson/ndjson-spec
+func ImportNDJSON(ctx context.Context, db *sql.DB, table string, r io.Reader,
+	mapping func(json.RawMessage) (map[string]any, error), mode NDJSONMode) (int64, error) {
+	if mapping == nil {
+		mapping = func(m json.RawMessage) (map[string]any, error) {
+			d := json.NewDecoder(bytes.NewReader(m))
+			d.UseNumber()
+			var row map[string]any
+			if err := d.Decode(&row); err != nil {
+				return nil, err
+			}
+			if row == nil {
+				return nil, errors.New("line is not a JSON object")
+			}
+			return row, nil
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, wrapError("error beginning transaction", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Rows may have different columns, so keep a prepared statement per column set
+	statements := map[string]*sql.Stmt{}
+	defer func() {
+		for _, s := range statements {
+			_ = s.Close()
+		}
+	}()
+
+	var count int64
+	br := bufio.NewReader(r)
+	for lineNumber := 1; ; lineNumber++ {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return 0, wrapError("error reading line %v", readErr, lineNumber)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			row, err := mapNDJSONLine(line, mapping)
+			switch {
+			case err != nil && mode == NDJSONSkip:
+			case err != nil:
+				return 0, wrapError("error in line %v", err, lineNumber)
+			default:
+				if err := insertNDJSONRow(ctx, tx, table, row, statements); err != nil {
+					return 0, wrapError("error inserting line %v", err, lineNumber)
+				}
+				count++
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, wrapError("error committing transaction", err)
+	}
+	return count, nil
+}
+
+func mapNDJSONLine(line []byte, mapping func(json.RawMessage) (map[string]any, error)) (map[string]any, error) {
+	if !json.Valid(line) {
+		return nil, errors.New("invalid JSON")
+	}
+	row, err := mapping(line)
+	if err != nil {
+		return nil, wrapError("error mapping line", err)
+	}
+	if len(row) == 0 {
+		return nil, errors.New("no columns")
+	}
+	return row, nil
+}
+
+func insertNDJSONRow(ctx context.Context, tx *sql.Tx, table string, row map[string]any, statements map[string]*sql.Stmt) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	key := strings.Join(quoted, ",")
+
+	s, ok := statements[key]
+	if !ok {
+		query := fmt.Sprintf("insert into %v (%v) values (%v)", quoteIdentifier(table), key,
+			strings.TrimSuffix(strings.Repeat("?,", len(columns)), ","))
+		var err error
+		s, err = tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		statements[key] = s
+	}
+
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		v, err := ndjsonValue(row[column])
+		if err != nil {
+			return err
+		}
+		args[i] = v
+	}
+
+	_, err := s.ExecContext(ctx, args...)
+	return err
+}
+
+// ndjsonValue converts a json.Number to an int64 if it fits, and a float64 otherwise, and leaves other values as is.
+// Nested objects and arrays are bound as JSON text by the driver.
+func ndjsonValue(v any) (any, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v, nil
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, wrapError("error converting number %v", err, n)
+	}
+	return f, nil
+}
@@ -0,0 +1,131 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestImportNDJSON(t *testing.T) {
+	t.Run("inserts a row per line", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table people (name text not null, age integer, tags text, meta text)`)
+
+		r := strings.NewReader(`{"name": "Alice", "age": 30, "tags": ["a", "b"]}
+{"name": "Bob", "age": 25, "meta": {"admin": true}}
+
+{"name": "Carol"}
+`)
+		n, err := sqlite.ImportNDJSON(context.Background(), db, "people", r, nil, sqlite.NDJSONStrict)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(3), n)
+
+		assert.Equal(t, "Alice 30 [\"a\",\"b\"] ;Bob 25  {\"admin\":true};Carol   ;", readPeople(t, db))
+	})
+
+	t.Run("binds numbers as integers if they fit in an int64, and as floats otherwise", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{}, `create table numbers (i, f, big, nested)`)
+
+		r := strings.NewReader(`{"i": 9007199254740993, "f": 1.5, "big": 1e100, "nested": [9007199254740993]}`)
+		_, err := sqlite.ImportNDJSON(context.Background(), db, "numbers", r, nil, sqlite.NDJSONStrict)
+		assert.NoErr(t, err)
+
+		var i int64
+		var f, big float64
+		var iType, fType, bigType, nested string
+		err = db.QueryRow(`select i, typeof(i), f, typeof(f), big, typeof(big), nested from numbers`).
+			Scan(&i, &iType, &f, &fType, &big, &bigType, &nested)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(9007199254740993), i)
+		assert.Equal(t, "integer", iType)
+		assert.Equal(t, 1.5, f)
+		assert.Equal(t, "real", fType)
+		assert.Equal(t, 1e100, big)
+		assert.Equal(t, "real", bigType)
+		assert.Equal(t, "[9007199254740993]", nested)
+	})
+
+	t.Run("uses the mapping to convert lines to columns", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table people (name text not null, age integer, tags text, meta text)`)
+
+		r := strings.NewReader(`["Alice", 30]` + "\n" + `["Bob", 25]`)
+		n, err := sqlite.ImportNDJSON(context.Background(), db, "people", r, func(m json.RawMessage) (map[string]any, error) {
+			var v []any
+			if err := json.Unmarshal(m, &v); err != nil {
+				return nil, err
+			}
+			return map[string]any{"name": v[0], "age": v[1]}, nil
+		}, sqlite.NDJSONStrict)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(2), n)
+
+		assert.Equal(t, "Alice 30  ;Bob 25  ;", readPeople(t, db))
+	})
+
+	t.Run("fails and rolls back on malformed line in strict mode", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table people (name text not null, age integer, tags text, meta text)`)
+
+		r := strings.NewReader(`{"name": "Alice"}` + "\n" + `{"name": ` + "\n" + `{"name": "Bob"}`)
+		n, err := sqlite.ImportNDJSON(context.Background(), db, "people", r, nil, sqlite.NDJSONStrict)
+		assert.Err(t, err)
+		assert.Equal(t, int64(0), n)
+		assert.Equal(t, true, strings.Contains(err.Error(), "line 2"))
+
+		assert.Equal(t, "", readPeople(t, db))
+	})
+
+	t.Run("skips malformed lines in skip mode", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table people (name text not null, age integer, tags text, meta text)`)
+
+		r := strings.NewReader(`{"name": "Alice"}` + "\n" + `{"name": ` + "\n" + `42` + "\n" + `{"name": "Bob"}`)
+		n, err := sqlite.ImportNDJSON(context.Background(), db, "people", r, nil, sqlite.NDJSONSkip)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(2), n)
+
+		assert.Equal(t, "Alice   ;Bob   ;", readPeople(t, db))
+	})
+
+	t.Run("skips lines the mapping returns an error for in skip mode", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table people (name text not null, age integer, tags text, meta text)`)
+
+		r := strings.NewReader(`{"name": "Alice"}` + "\n" + `{"name": "Bob"}`)
+		n, err := sqlite.ImportNDJSON(context.Background(), db, "people", r, func(m json.RawMessage) (map[string]any, error) {
+			var v map[string]any
+			if err := json.Unmarshal(m, &v); err != nil {
+				return nil, err
+			}
+			if v["name"] == "Bob" {
+				return nil, errors.New("no Bobs")
+			}
+			return v, nil
+		}, sqlite.NDJSONSkip)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1), n)
+
+		assert.Equal(t, "Alice   ;", readPeople(t, db))
+	})
+}
+
+// readPeople returns all people as "name age tags meta;" in insertion order.
+func readPeople(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	var people string
+	err := db.QueryRow(`
+		select coalesce(group_concat(name || ' ' || coalesce(age, '') || ' ' || coalesce(tags, '') || ' ' || coalesce(meta, '') || ';', ''), '')
+		from (select * from people order by rowid)`).Scan(&people)
+	assert.NoErr(t, err)
+	return people
+}
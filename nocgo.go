@@ -0,0 +1,182 @@
+//go:build !cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+)
+
+// RegisterDriver registers a driver that returns ErrCgoRequired on open,
+// so code using this package compiles without cgo, and fails at runtime with a helpful error.
+func RegisterDriver(opts Options) {
+	opts = withDefaults(opts)
+
+	sql.Register(opts.Name, &d{})
+	registerEffectiveOptions(opts)
+}
+
+// OpenDB always returns ErrCgoRequired.
+func OpenDB(path string, opts Options) (*sql.DB, error) {
+	return nil, ErrCgoRequired
+}
+
+// NewConnector returns a connector that returns ErrCgoRequired on connect.
+func NewConnector(name string, opts Options) driver.Connector {
+	return &connector{}
+}
+
+// LatencyHistogram always returns false without cgo.
+func LatencyHistogram(db *sql.DB) (*Histogram, bool) {
+	return nil, false
+}
+
+// RegisterCollation always returns ErrCgoRequired.
+func RegisterCollation(driverName, name string, compare func(a, b string) int) error {
+	return ErrCgoRequired
+}
+
+// d satisfies driver.Driver.
+type d struct{}
+
+// Open always returns ErrCgoRequired.
+func (d *d) Open(name string) (driver.Conn, error) {
+	return nil, ErrCgoRequired
+}
+
+// RegisterAutoExtension always returns ErrCgoRequired.
+func RegisterAutoExtension(init func(e *Extension) error) error {
+	return ErrCgoRequired
+}
+
+// Extension is passed to functions registered with RegisterAutoExtension, which can't be called without cgo.
+type Extension struct{}
+
+// CreateFunction always returns ErrCgoRequired.
+func (e *Extension) CreateFunction(name string, nArg int, deterministic bool, fn Function) error {
+	return ErrCgoRequired
+}
+
+// Exec always returns ErrCgoRequired.
+func (e *Extension) Exec(query string) error {
+	return ErrCgoRequired
+}
+
+// Blob is an open blob for incremental I/O, which can't be opened without cgo.
+type Blob struct{}
+
+// OpenBlob always returns ErrCgoRequired.
+func OpenBlob(conn *sql.Conn, table, column string, rowid int64, write bool) (*Blob, error) {
+	return nil, ErrCgoRequired
+}
+
+// Size always returns 0.
+func (b *Blob) Size() int64 {
+	return 0
+}
+
+// ReadAt always returns ErrCgoRequired.
+func (b *Blob) ReadAt(p []byte, off int64) (int, error) {
+	return 0, ErrCgoRequired
+}
+
+// WriteAt always returns ErrCgoRequired.
+func (b *Blob) WriteAt(p []byte, off int64) (int, error) {
+	return 0, ErrCgoRequired
+}
+
+// Close always returns ErrCgoRequired.
+func (b *Blob) Close() error {
+	return ErrCgoRequired
+}
+
+// CacheFlush always returns ErrCgoRequired.
+func CacheFlush(conn *sql.Conn) error {
+	return ErrCgoRequired
+}
+
+// CompiledFeatures always returns no features without cgo.
+func CompiledFeatures() Features {
+	return Features{}
+}
+
+// CompileOptionUsed always returns false without cgo.
+func CompileOptionUsed(name string) bool {
+	return false
+}
+
+// WillModify always returns ErrCgoRequired.
+func WillModify(conn *sql.Conn, query string) (bool, error) {
+	return false, ErrCgoRequired
+}
+
+// QueryJSON always returns ErrCgoRequired.
+func QueryJSON(ctx context.Context, db *sql.DB, w io.Writer, query string, args ...any) error {
+	return ErrCgoRequired
+}
+
+// SeedRandom does nothing without cgo.
+func SeedRandom(seed int32) {}
+
+// ReadOnlyStore serves concurrent read-only queries from a database file, which can't be opened without cgo.
+type ReadOnlyStore struct{}
+
+// OpenReadOnlyStore always returns ErrCgoRequired.
+func OpenReadOnlyStore(path string, opts Options) (*ReadOnlyStore, error) {
+	return nil, ErrCgoRequired
+}
+
+// Query always returns ErrCgoRequired.
+func (s *ReadOnlyStore) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, ErrCgoRequired
+}
+
+// QueryRow always returns nil, because there is no ReadOnlyStore to call it on without cgo.
+func (s *ReadOnlyStore) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+// Close always returns ErrCgoRequired.
+func (s *ReadOnlyStore) Close() error {
+	return ErrCgoRequired
+}
+
+// Shutdown always returns ErrCgoRequired.
+func Shutdown(ctx context.Context, db *sql.DB) error {
+	return ErrCgoRequired
+}
+
+// Status always returns ErrCgoRequired.
+func Status(op StatusOp, reset bool) (current, highwater int64, err error) {
+	return 0, 0, ErrCgoRequired
+}
+
+// MemoryHighwater always returns ErrCgoRequired.
+func MemoryHighwater(conn *sql.Conn, reset bool) (int64, error) {
+	return 0, ErrCgoRequired
+}
+
+// StatementHistory always returns ErrCgoRequired.
+func StatementHistory(conn *sql.Conn) ([]StatementRecord, error) {
+	return nil, ErrCgoRequired
+}
+
+// Transaction always returns ErrCgoRequired.
+func Transaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	return ErrCgoRequired
+}
+
+// connector satisfies driver.Connector.
+type connector struct{}
+
+// Connect always returns ErrCgoRequired.
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return nil, ErrCgoRequired
+}
+
+// Driver returns a driver that returns ErrCgoRequired on open.
+func (c *connector) Driver() driver.Driver {
+	return &d{}
+}
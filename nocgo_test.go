@@ -0,0 +1,40 @@
+//go:build !cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestRegisterDriver(t *testing.T) {
+	t.Run("returns helpful error on open without cgo", func(t *testing.T) {
+		sqlite.RegisterDriver(sqlite.Options{})
+
+		db, err := sql.Open("sqlite", ":memory:")
+		assert.NoErr(t, err)
+
+		err = db.Ping()
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrCgoRequired))
+	})
+}
+
+func TestStubs(t *testing.T) {
+	t.Run("returns helpful error from functions that need cgo", func(t *testing.T) {
+		_, err := sqlite.OpenDB(":memory:", sqlite.Options{})
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrCgoRequired))
+
+		_, err = sqlite.OpenReadOnlyStore("app.db", sqlite.Options{})
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrCgoRequired))
+
+		_, _, err = sqlite.Status(sqlite.StatusMemoryUsed, false)
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrCgoRequired))
+
+		err = sqlite.RegisterAutoExtension(func(e *sqlite.Extension) error { return nil })
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrCgoRequired))
+	})
+}
@@ -0,0 +1,124 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestNull(t *testing.T) {
+	created := time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC)
+
+	tests := []struct {
+		name     string
+		declType string
+		test     func(t *testing.T, db *sql.DB)
+	}{
+		{"int64", "integer", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[int64]{V: 42, Valid: true}, roundTripNull(t, db, sql.Null[int64]{V: 42, Valid: true}))
+		}},
+		{"int", "integer", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[int]{V: -1, Valid: true}, roundTripNull(t, db, sql.Null[int]{V: -1, Valid: true}))
+		}},
+		{"float64", "real", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[float64]{V: 1.5, Valid: true}, roundTripNull(t, db, sql.Null[float64]{V: 1.5, Valid: true}))
+		}},
+		{"bool", "boolean", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[bool]{V: true, Valid: true}, roundTripNull(t, db, sql.Null[bool]{V: true, Valid: true}))
+		}},
+		{"string", "text", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[string]{V: "foo", Valid: true}, roundTripNull(t, db, sql.Null[string]{V: "foo", Valid: true}))
+		}},
+		{"bytes", "blob", func(t *testing.T, db *sql.DB) {
+			v := roundTripNull(t, db, sql.Null[[]byte]{V: []byte("foo"), Valid: true})
+			assert.Equal(t, true, v.Valid)
+			assert.EqualBytes(t, []byte("foo"), v.V)
+		}},
+		{"Text", "text", func(t *testing.T, db *sql.DB) {
+			v := roundTripNull(t, db, sql.Null[sqlite.Text]{V: sqlite.Text("foo"), Valid: true})
+			assert.Equal(t, true, v.Valid)
+			assert.EqualBytes(t, []byte("foo"), v.V)
+
+			var typ string
+			err := db.QueryRow(`select typeof(v) from t`).Scan(&typ)
+			assert.NoErr(t, err)
+			assert.Equal(t, "text", typ)
+		}},
+		{"time.Time", "datetime", func(t *testing.T, db *sql.DB) {
+			v := roundTripNull(t, db, sql.Null[time.Time]{V: created, Valid: true})
+			assert.Equal(t, true, v.Valid)
+			assert.Equal(t, true, created.Equal(v.V))
+		}},
+		{"time.Time in a timestamp column", "timestamp", func(t *testing.T, db *sql.DB) {
+			v := roundTripNull(t, db, sql.Null[time.Time]{V: created, Valid: true})
+			assert.Equal(t, true, created.Equal(v.V))
+		}},
+		{"NULL int64", "integer", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[int64]{}, roundTripNull(t, db, sql.Null[int64]{V: 42}))
+		}},
+		{"NULL time.Time", "datetime", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, sql.Null[time.Time]{}, roundTripNull(t, db, sql.Null[time.Time]{}))
+		}},
+		{"NULL Text", "text", func(t *testing.T, db *sql.DB) {
+			v := roundTripNull(t, db, sql.Null[sqlite.Text]{V: sqlite.Text("foo")})
+			assert.Equal(t, false, v.Valid)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := open(t, sqlite.Options{})
+
+			_, err := db.Exec(`create table t (v ` + test.declType + `)`)
+			assert.NoErr(t, err)
+
+			test.test(t, db)
+		})
+	}
+
+	t.Run("time.Time scans as text in a text column", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (?)`, sql.Null[time.Time]{V: created, Valid: true})
+		assert.NoErr(t, err)
+
+		var v string
+		err = db.QueryRow(`select v from t`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, "2023-01-02T03:04:05.123456789Z", v)
+	})
+
+	t.Run("text that isn't a time scans as text in a time column", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v datetime)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values ('yesterday')`)
+		assert.NoErr(t, err)
+
+		var v sql.Null[string]
+		err = db.QueryRow(`select v from t`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, "yesterday", v.V)
+	})
+}
+
+// roundTripNull inserts v into the only column of table t, and scans it back.
+func roundTripNull[T any](t *testing.T, db *sql.DB, v sql.Null[T]) sql.Null[T] {
+	t.Helper()
+
+	_, err := db.Exec(`insert into t values (?)`, v)
+	assert.NoErr(t, err)
+
+	var result sql.Null[T]
+	err = db.QueryRow(`select v from t`).Scan(&result)
+	assert.NoErr(t, err)
+	return result
+}
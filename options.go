@@ -0,0 +1,323 @@
+package sqlite
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type JournalMode string
+
+const (
+	JournalModeDelete   = JournalMode("delete")
+	JournalModeTruncate = JournalMode("truncate")
+	JournalModePersist  = JournalMode("persist")
+	JournalModeMemory   = JournalMode("memory")
+	JournalModeWAL      = JournalMode("wal")
+	JournalModeOff      = JournalMode("off")
+)
+
+func (j JournalMode) String() string {
+	return string(j)
+}
+
+// LockingMode is the database locking mode.
+// See https://www.sqlite.org/pragma.html#pragma_locking_mode
+type LockingMode string
+
+const (
+	LockingModeNormal    = LockingMode("normal")
+	LockingModeExclusive = LockingMode("exclusive")
+)
+
+func (l LockingMode) String() string {
+	return string(l)
+}
+
+// TransactionMode is how transactions begin, which determines when they take the database locks.
+// See https://www.sqlite.org/lang_transaction.html#deferred_immediate_and_exclusive_transactions
+type TransactionMode string
+
+const (
+	// TransactionModeDeferred takes no locks until the first read, and the write lock on the first write.
+	TransactionModeDeferred = TransactionMode("deferred")
+	// TransactionModeImmediate takes the write lock on begin, waiting for the busy timeout if necessary.
+	TransactionModeImmediate = TransactionMode("immediate")
+	// TransactionModeExclusive is like TransactionModeImmediate, and additionally keeps other connections from
+	// reading in journal modes other than WAL.
+	TransactionModeExclusive = TransactionMode("exclusive")
+)
+
+func (t TransactionMode) String() string {
+	return string(t)
+}
+
+// PlaceholderStyle is a query placeholder style of another database, translated to SQLite's ?NNN parameters.
+type PlaceholderStyle string
+
+const (
+	// PlaceholderStyleDollar is the numbered $1, $2 style of PostgreSQL.
+	PlaceholderStyleDollar = PlaceholderStyle("dollar")
+	// PlaceholderStyleAtP is the numbered @p1, @p2 style of SQL Server.
+	PlaceholderStyleAtP = PlaceholderStyle("atp")
+)
+
+func (p PlaceholderStyle) String() string {
+	return string(p)
+}
+
+// TimeEncoding is the format time.Time values are bound as, and parsed from in time columns.
+type TimeEncoding string
+
+const (
+	// TimeEncodingRFC3339 is ISO-8601 text in the RFC3339 format with nanoseconds in UTC,
+	// such as "2006-01-02T15:04:05.999999999Z".
+	TimeEncodingRFC3339 = TimeEncoding("rfc3339")
+	// TimeEncodingDateTime is the format of datetime() and CURRENT_TIMESTAMP in UTC, such as "2006-01-02 15:04:05".
+	// Fractional seconds are dropped.
+	TimeEncodingDateTime = TimeEncoding("datetime")
+	// TimeEncodingUnix is the INTEGER number of seconds since the Unix epoch, like unixepoch() and strftime('%s').
+	// Fractional seconds are dropped.
+	TimeEncodingUnix = TimeEncoding("unix")
+	// TimeEncodingJulian is the REAL Julian day number, like julianday(). The precision is about a millisecond,
+	// so times are rounded to the millisecond when parsed.
+	TimeEncodingJulian = TimeEncoding("julian")
+)
+
+func (t TimeEncoding) String() string {
+	return string(t)
+}
+
+// layout of the encoding for time.Time.Format and time.Parse.
+// Text in time columns is parsed with it first for all encodings.
+func (t TimeEncoding) layout() string {
+	if t == TimeEncodingDateTime {
+		return time.DateTime
+	}
+	return time.RFC3339Nano
+}
+
+// timeLayouts are tried in order when parsing text in time columns, after the layout of the time encoding,
+// so values written by SQLite itself, such as from CURRENT_TIMESTAMP and date(), are parsed too.
+// Fractional seconds are accepted after the seconds, even if the layout doesn't include them.
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", time.DateOnly}
+
+// parse v with the layout of the time encoding, or else any of timeLayouts. Values without a time zone are in UTC.
+func (t TimeEncoding) parse(v string) (time.Time, bool) {
+	if parsed, err := time.Parse(t.layout(), v); err == nil {
+		return parsed, true
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, v); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// unixEpochJulianDay is the Julian day number of the Unix epoch.
+const unixEpochJulianDay = 2440587.5
+
+// toJulianDay returns the Julian day number of t.
+func toJulianDay(t time.Time) float64 {
+	return float64(t.Unix())/86400 + float64(t.Nanosecond())/86400e9 + unixEpochJulianDay
+}
+
+// fromJulianDay returns the time of the Julian day number jd in UTC, rounded to the millisecond.
+func fromJulianDay(jd float64) time.Time {
+	return time.UnixMilli(int64(math.Round((jd - unixEpochJulianDay) * 86400e3))).UTC()
+}
+
+type logger interface {
+	Println(v ...any)
+}
+
+type discardLogger struct{}
+
+func (d *discardLogger) Println(...any) {}
+
+type Options struct {
+	// AllowedStatementPrefixes, if not empty, restricts queries to those whose leading keyword is one of these,
+	// case-insensitively, such as "SELECT". Queries with a statement that isn't allowed are rejected in Prepare,
+	// before any of their statements run.
+	// Note that queries starting with a common table expression have the leading keyword WITH, which can be followed
+	// by a write such as DELETE. If only SELECT, VALUES, and WITH are allowed, statements must also be read-only
+	// according to SQLite, so such writes are rejected too. With other keywords allowed, WITH allows any write.
+	// Queries the driver runs itself, such as pragmas on open and the health check, are not restricted.
+	AllowedStatementPrefixes []string
+	// BigFloatDigits is the number of significant decimal digits used when binding a *big.Float as text.
+	// If zero, the fewest digits that represent the value exactly at its precision are used.
+	BigFloatDigits int
+	BusyTimeout    *time.Duration
+	// CellSizeCheck enables extra checks of b-tree cells when they're read from disk, if set, to detect
+	// database corruption earlier, at a small performance cost.
+	// See https://www.sqlite.org/pragma.html#pragma_cell_size_check
+	CellSizeCheck *bool
+	ForeignKeys   *bool
+	// HealthCheckQuery is run by Ping to check that the database is usable. Defaults to "select 1".
+	HealthCheckQuery string
+	JournalMode      JournalMode
+	// LatencyHistogram records the duration of every query, if set. For queries returning rows,
+	// the duration is until the rows are closed. See also the LatencyHistogram function.
+	LatencyHistogram *Histogram
+	// LockingMode is set on every connection, if not empty. With LockingModeExclusive, a connection keeps its locks
+	// between transactions once it has read or written, which saves system calls for single-connection
+	// write-heavy workloads, and lets WAL mode work without a shared-memory file.
+	// Other connections, including from the same sql.DB, can't access the database while the locks are held,
+	// so use it with sql.DB.SetMaxOpenConns(1).
+	LockingMode LockingMode
+	Logger      logger
+	// MapError is called with the result code, extended result code, and message whenever the driver returns
+	// an error from SQLite, to translate it into an application error. If MapError is nil or returns nil,
+	// an *Error is returned. Otherwise, the returned error wraps both the application error and the *Error,
+	// so errors.Is and errors.As find either.
+	MapError func(code, extendedCode int, msg string) error
+	// MaxSQLLength rejects queries longer than this many bytes before preparing them, if greater than zero.
+	MaxSQLLength int
+	// MaxWriters limits how many connections may write at the same time, if greater than zero.
+	// Statements that may write wait for a free slot before running, and a connection keeps its slot until its
+	// write transaction ends. Immediate transactions from Transaction take a slot on begin.
+	// This avoids SQLITE_BUSY errors and busy-waiting between writers, while keeping a large pool for reads.
+	// Waiting for a slot can be cancelled with the context, except for writes through Query, such as INSERT ... RETURNING.
+	MaxWriters int
+	Name       string
+	// NoDeclTypeConversion returns column values as their storage class, regardless of the declared column type.
+	// By default, values in columns declared as DATE, DATETIME, or TIMESTAMP are returned as time.Time if they can be
+	// parsed with the TimeEncoding, and integers in columns declared as BOOLEAN or BOOL as bool.
+	NoDeclTypeConversion bool
+	// NoLock disables file locking, which can speed up writes on file systems where locking is slow or broken.
+	// Only use it if a single connection in a single process ever accesses the database at a time, for example with
+	// sql.DB.SetMaxOpenConns(1), because concurrent access without locking corrupts the database.
+	// It uses the unix-none VFS, or exclusive locking mode on Windows. Note that in WAL mode,
+	// locks on the shared-memory file are still used.
+	// See https://www.sqlite.org/vfs.html#standard_unix_vfses
+	NoLock *bool
+	// OnJournalFallback is called on open when the requested JournalMode could not be set,
+	// with the mode SQLite actually uses. Returning an error fails the open.
+	// If nil, a warning is logged instead.
+	OnJournalFallback func(requested, actual JournalMode) error
+	// PlaceholderStyle translates placeholders of this style to SQLite's numbered ?NNN parameters before preparing
+	// queries, if not empty, to ease porting queries from other databases. Arguments are bound by number,
+	// so $2 gets the second argument wherever it is in the query. Placeholders in strings, quoted identifiers,
+	// and comments are left alone. Error messages show the translated query.
+	PlaceholderStyle PlaceholderStyle
+	// ReadOnly opens connections read-only. The JournalMode is not set, as it can't be changed by a read-only connection.
+	ReadOnly bool
+	// ReadOnlyImmutableFallback opens a ReadOnly database as immutable if it has a WAL file that can't be replayed,
+	// instead of returning ErrUnreplayableWAL. Note that this ignores any changes in the WAL file.
+	ReadOnlyImmutableFallback bool
+	// StatementHistory is the number of most recent statements to keep per connection, with their durations,
+	// for post-mortem debugging. Read them with the StatementHistory function.
+	StatementHistory int
+	// StrictBoolColumns makes binding a bool to a column not declared as BOOLEAN or an integer type an error.
+	// The column is found on a best-effort basis from simple INSERT, UPDATE, DELETE, and SELECT queries,
+	// and binding is allowed if the column can't be determined.
+	StrictBoolColumns bool
+	// TimeEncoding is the format time.Time values are bound as, and parsed from in columns declared as
+	// DATE, DATETIME, or TIMESTAMP. Defaults to TimeEncodingRFC3339.
+	// Use TimeEncodingDateTime for schemas comparing against datetime() or CURRENT_TIMESTAMP,
+	// and TimeEncodingUnix or TimeEncodingJulian to store INTEGER or REAL values.
+	// Text in time columns is parsed for all encodings, also in the RFC3339, datetime(), and date() formats,
+	// but integers only with TimeEncodingUnix, and reals only with TimeEncodingJulian.
+	TimeEncoding TimeEncoding
+	// TimeTruncate truncates bound time.Time values to this precision before formatting, if non-zero.
+	// For example, use time.Millisecond to store millisecond precision.
+	TimeTruncate time.Duration
+	// TransactionMode is how transactions begin, unless they're read-only, which are always deferred.
+	// Defaults to TransactionModeDeferred. Use TransactionModeImmediate for write-heavy workloads with concurrent
+	// writers, where a deferred transaction that reads before writing can fail with SQLITE_BUSY when upgrading
+	// to a write transaction, instead of waiting for the busy timeout.
+	TransactionMode TransactionMode
+	// VerifyPragmasOnReset are names of pragmas whose values at connection open are verified when the
+	// connection is reused from the pool, and restored if they have been changed.
+	VerifyPragmasOnReset []string
+	// WarnUnorderedQueries logs a warning when a SELECT without a top-level ORDER BY returns more than one row,
+	// because the row order is then undefined and may change, for example with a new query plan.
+	// It's a development aid for catching flaky tests asserting on row order, not meant for production.
+	WarnUnorderedQueries bool
+	// WriteLockTimeout logs a warning if a write transaction is held for longer than this, if greater than zero,
+	// including the recent statements if StatementHistory is set. Long write transactions block all other writers.
+	WriteLockTimeout time.Duration
+	// ZeroCopyBlobs returns BLOB values from the driver as views of SQLite's memory, instead of copies,
+	// which are only valid until the next row is read or the rows are closed.
+	// database/sql copies values when scanning into *[]byte or *any, so this only saves copying with
+	// sql.RawBytes, Unsafe, or scanners of your own that use the value right away.
+	ZeroCopyBlobs bool
+}
+
+// withDefaults returns opts with defaults applied to unset fields.
+func withDefaults(opts Options) Options {
+	if opts.Name == "" {
+		opts.Name = "sqlite"
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = &discardLogger{}
+	}
+
+	if opts.JournalMode == "" {
+		opts.JournalMode = JournalModeWAL
+	}
+
+	if opts.TimeEncoding == "" {
+		opts.TimeEncoding = TimeEncodingRFC3339
+	}
+
+	if opts.TransactionMode == "" {
+		opts.TransactionMode = TransactionModeDeferred
+	}
+
+	if opts.HealthCheckQuery == "" {
+		opts.HealthCheckQuery = "select 1"
+	}
+
+	if opts.BusyTimeout == nil {
+		opts.BusyTimeout = ptr(5 * time.Second)
+	}
+
+	if opts.ForeignKeys == nil {
+		opts.ForeignKeys = ptr(true)
+	}
+
+	return opts
+}
+
+var (
+	effectiveOptions     = map[string]Options{}
+	effectiveOptionsLock sync.RWMutex
+)
+
+// registerEffectiveOptions stores the options of a registered driver, after defaults are applied.
+func registerEffectiveOptions(opts Options) {
+	effectiveOptionsLock.Lock()
+	defer effectiveOptionsLock.Unlock()
+	effectiveOptions[opts.Name] = opts
+}
+
+// EffectiveOptions returns the options in effect for the driver registered with RegisterDriver as name,
+// with defaults filled in, and false if no driver is registered with that name.
+func EffectiveOptions(name string) (Options, bool) {
+	effectiveOptionsLock.RLock()
+	defer effectiveOptionsLock.RUnlock()
+	opts, ok := effectiveOptions[name]
+	if !ok {
+		return Options{}, false
+	}
+
+	// Copy the pointers and slices, so the caller can't change the driver's options
+	opts.BusyTimeout = ptr(*opts.BusyTimeout)
+	opts.ForeignKeys = ptr(*opts.ForeignKeys)
+	if opts.CellSizeCheck != nil {
+		opts.CellSizeCheck = ptr(*opts.CellSizeCheck)
+	}
+	if opts.NoLock != nil {
+		opts.NoLock = ptr(*opts.NoLock)
+	}
+	opts.AllowedStatementPrefixes = append([]string(nil), opts.AllowedStatementPrefixes...)
+	opts.VerifyPragmasOnReset = append([]string(nil), opts.VerifyPragmasOnReset...)
+	return opts, true
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,13 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is satisfied by *sql.DB, *sql.Conn, and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
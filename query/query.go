@@ -0,0 +1,217 @@
+// Package query is a minimal builder for simple SELECT, INSERT, UPDATE, and DELETE queries.
+// It's not an ORM: it only builds a query string and its args, for use with database/sql.
+// Table and column names are quoted as identifiers, and values are always passed as args to placeholders.
+// WHERE expressions are SQL written by the caller, so only use placeholders for values in them.
+package query
+
+import (
+	"strings"
+)
+
+type where struct {
+	expr string
+	args []any
+}
+
+// writeWhere writes the WHERE clause for wheres, joined with AND, and appends their args.
+func writeWhere(b *strings.Builder, wheres []where, args []any) []any {
+	for i, w := range wheres {
+		if i == 0 {
+			b.WriteString(" where ")
+		} else {
+			b.WriteString(" and ")
+		}
+		if len(wheres) > 1 {
+			b.WriteString("(" + w.expr + ")")
+		} else {
+			b.WriteString(w.expr)
+		}
+		args = append(args, w.args...)
+	}
+	return args
+}
+
+// SelectBuilder builds a SELECT query. Create it with Select.
+type SelectBuilder struct {
+	table   string
+	columns []string
+	wheres  []where
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// Select from table. If no columns are given, all columns are selected.
+func Select(table string, columns ...string) *SelectBuilder {
+	return &SelectBuilder{table: table, columns: columns}
+}
+
+// Where adds a condition with placeholders for args, such as "x = ?". Multiple conditions are joined with AND.
+func (s *SelectBuilder) Where(expr string, args ...any) *SelectBuilder {
+	s.wheres = append(s.wheres, where{expr: expr, args: args})
+	return s
+}
+
+// OrderBy adds column to the ORDER BY clause, in ascending order.
+func (s *SelectBuilder) OrderBy(column string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, quoteIdentifier(column))
+	return s
+}
+
+// OrderByDesc adds column to the ORDER BY clause, in descending order.
+func (s *SelectBuilder) OrderByDesc(column string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, quoteIdentifier(column)+" desc")
+	return s
+}
+
+// Limit the number of rows returned, if n is greater than zero.
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+// Offset skips the first n rows. It's only used together with Limit.
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+// Build the query and its args.
+func (s *SelectBuilder) Build() (string, []any) {
+	var b strings.Builder
+	b.WriteString("select ")
+	if len(s.columns) == 0 {
+		b.WriteString("*")
+	} else {
+		b.WriteString(quoteIdentifiers(s.columns))
+	}
+	b.WriteString(" from " + quoteIdentifier(s.table))
+
+	args := writeWhere(&b, s.wheres, nil)
+
+	if len(s.orderBy) > 0 {
+		b.WriteString(" order by " + strings.Join(s.orderBy, ", "))
+	}
+
+	if s.limit > 0 {
+		b.WriteString(" limit ?")
+		args = append(args, s.limit)
+		if s.offset > 0 {
+			b.WriteString(" offset ?")
+			args = append(args, s.offset)
+		}
+	}
+
+	return b.String(), args
+}
+
+// InsertBuilder builds an INSERT query. Create it with Insert.
+type InsertBuilder struct {
+	table   string
+	columns []string
+	values  []any
+}
+
+// Insert into table.
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Value sets column to v.
+func (i *InsertBuilder) Value(column string, v any) *InsertBuilder {
+	i.columns = append(i.columns, column)
+	i.values = append(i.values, v)
+	return i
+}
+
+// Build the query and its args. Without values, the row gets default values.
+func (i *InsertBuilder) Build() (string, []any) {
+	if len(i.columns) == 0 {
+		return "insert into " + quoteIdentifier(i.table) + " default values", nil
+	}
+	return "insert into " + quoteIdentifier(i.table) + " (" + quoteIdentifiers(i.columns) + ") values (" +
+		placeholders(len(i.columns)) + ")", i.values
+}
+
+// UpdateBuilder builds an UPDATE query. Create it with Update.
+type UpdateBuilder struct {
+	table   string
+	columns []string
+	values  []any
+	wheres  []where
+}
+
+// Update table.
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set column to v.
+func (u *UpdateBuilder) Set(column string, v any) *UpdateBuilder {
+	u.columns = append(u.columns, column)
+	u.values = append(u.values, v)
+	return u
+}
+
+// Where adds a condition with placeholders for args, such as "x = ?". Multiple conditions are joined with AND.
+// Without conditions, all rows are updated.
+func (u *UpdateBuilder) Where(expr string, args ...any) *UpdateBuilder {
+	u.wheres = append(u.wheres, where{expr: expr, args: args})
+	return u
+}
+
+// Build the query and its args.
+func (u *UpdateBuilder) Build() (string, []any) {
+	var b strings.Builder
+	b.WriteString("update " + quoteIdentifier(u.table) + " set ")
+	for i, column := range u.columns {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoteIdentifier(column) + " = ?")
+	}
+	args := writeWhere(&b, u.wheres, append([]any(nil), u.values...))
+	return b.String(), args
+}
+
+// DeleteBuilder builds a DELETE query. Create it with Delete.
+type DeleteBuilder struct {
+	table  string
+	wheres []where
+}
+
+// Delete from table.
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds a condition with placeholders for args, such as "x = ?". Multiple conditions are joined with AND.
+// Without conditions, all rows are deleted.
+func (d *DeleteBuilder) Where(expr string, args ...any) *DeleteBuilder {
+	d.wheres = append(d.wheres, where{expr: expr, args: args})
+	return d
+}
+
+// Build the query and its args.
+func (d *DeleteBuilder) Build() (string, []any) {
+	var b strings.Builder
+	b.WriteString("delete from " + quoteIdentifier(d.table))
+	args := writeWhere(&b, d.wheres, nil)
+	return b.String(), args
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
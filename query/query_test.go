@@ -0,0 +1,76 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite/internal/assert"
+	"github.com/maragudk/sqlite/query"
+)
+
+func TestSelect(t *testing.T) {
+	t.Run("selects all columns", func(t *testing.T) {
+		q, args := query.Select("people").Build()
+		assert.Equal(t, `select * from "people"`, q)
+		assert.Equal(t, 0, len(args))
+	})
+
+	t.Run("selects columns with multiple conditions, order, limit, and offset", func(t *testing.T) {
+		q, args := query.Select("people", "name", "age").
+			Where("age > ?", 18).
+			Where("name = ? or name = ?", "Alice", "Bob").
+			OrderBy("name").
+			OrderByDesc("age").
+			Limit(10).
+			Offset(20).
+			Build()
+		assert.Equal(t, `select "name", "age" from "people" where (age > ?) and (name = ? or name = ?) order by "name", "age" desc limit ? offset ?`, q)
+		assert.Equal(t, 5, len(args))
+		assert.Equal(t, 18, args[0].(int))
+		assert.Equal(t, "Alice", args[1].(string))
+		assert.Equal(t, "Bob", args[2].(string))
+		assert.Equal(t, 10, args[3].(int))
+		assert.Equal(t, 20, args[4].(int))
+	})
+
+	t.Run("quotes identifiers", func(t *testing.T) {
+		q, _ := query.Select(`people"; drop table people; --`, "name").OrderBy(`x"`).Build()
+		assert.Equal(t, `select "name" from "people""; drop table people; --" order by "x"""`, q)
+	})
+}
+
+func TestInsert(t *testing.T) {
+	t.Run("inserts values", func(t *testing.T) {
+		q, args := query.Insert("people").Value("name", "Alice").Value("age", 30).Build()
+		assert.Equal(t, `insert into "people" ("name", "age") values (?, ?)`, q)
+		assert.Equal(t, 2, len(args))
+		assert.Equal(t, "Alice", args[0].(string))
+		assert.Equal(t, 30, args[1].(int))
+	})
+
+	t.Run("inserts default values without values", func(t *testing.T) {
+		q, args := query.Insert("people").Build()
+		assert.Equal(t, `insert into "people" default values`, q)
+		assert.Equal(t, 0, len(args))
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("updates with a condition", func(t *testing.T) {
+		q, args := query.Update("people").Set("name", "Alicia").Set("age", 31).Where("id = ?", 1).Build()
+		assert.Equal(t, `update "people" set "name" = ?, "age" = ? where id = ?`, q)
+		assert.Equal(t, 3, len(args))
+		assert.Equal(t, "Alicia", args[0].(string))
+		assert.Equal(t, 31, args[1].(int))
+		assert.Equal(t, 1, args[2].(int))
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("deletes with multiple conditions", func(t *testing.T) {
+		q, args := query.Delete("people").Where("age < ?", 18).Where("name != ?", "Alice").Build()
+		assert.Equal(t, `delete from "people" where (age < ?) and (name != ?)`, q)
+		assert.Equal(t, 2, len(args))
+		assert.Equal(t, 18, args[0].(int))
+		assert.Equal(t, "Alice", args[1].(string))
+	})
+}
@@ -0,0 +1,190 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// QueryJSON runs query with args on db and streams the result to w as a JSON array of objects keyed by column name.
+// Integers and reals are encoded as numbers, text as strings, blobs as base64-encoded strings, and NULL as null.
+// The type of each value is its SQLite storage class, not the declared column type.
+// Rows are written as they are read, so the whole result is never held in memory.
+// If an error occurs after writing has started, w holds incomplete JSON.
+func QueryJSON(ctx context.Context, db *sql.DB, w io.Writer, query string, args ...any) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return wrapError("error getting connection", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return withConnection(conn, func(c *connection) error {
+		namedValues := make([]driver.NamedValue, len(args))
+		for i, arg := range args {
+			nv := driver.NamedValue{Ordinal: i + 1, Value: arg}
+			if na, ok := arg.(sql.NamedArg); ok {
+				nv.Name, nv.Value = na.Name, na.Value
+			}
+			v := nv.Value
+			err := c.CheckNamedValue(&nv)
+			if errors.Is(err, driver.ErrSkip) {
+				nv.Value, err = driver.DefaultParameterConverter.ConvertValue(v)
+			}
+			if err != nil {
+				return wrapError("error converting arg %v", err, i+1)
+			}
+			namedValues[i] = nv
+		}
+
+		stmt, err := c.Prepare(query)
+		if err != nil {
+			return err
+		}
+		s := stmt.(*statement)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		values, err := s.namedValuesToValues(namedValues)
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stop := c.interruptOnDone(ctx)
+		defer stop()
+
+		driverRows, err := s.Query(values)
+		if err != nil {
+			return err
+		}
+		r := driverRows.(*rows)
+		defer func() {
+			_ = r.Close()
+		}()
+
+		if err := writeJSONRows(w, r); err != nil {
+			if ctx.Err() != nil {
+				return wrapError(`query "%v" interrupted`, ctx.Err(), query)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// writeJSONRows writes all rows in r to w as a JSON array of objects.
+func writeJSONRows(w io.Writer, r *rows) error {
+	// Encode the keys once, with the separators they're written with
+	keys := make([][]byte, len(r.Columns()))
+	for i, name := range r.Columns() {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			key = append([]byte{','}, key...)
+		}
+		keys[i] = append(key, ':')
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+
+	var buf []byte
+	dest := make([]driver.Value, len(keys))
+	for count := 0; ; count++ {
+		if err := r.Next(dest); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		buf = buf[:0]
+		if count > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '{')
+		for i, v := range dest {
+			buf = append(buf, keys[i]...)
+
+			var err error
+			if buf, err = appendJSONValue(buf, v); err != nil {
+				return wrapError(`error encoding column "%v" as JSON`, err, r.Columns()[i])
+			}
+		}
+		buf = append(buf, '}')
+
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.WriteByte(']'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// appendJSONValue appends the JSON encoding of v, a value from the driver, to buf.
+func appendJSONValue(buf []byte, v driver.Value) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+
+	case int64:
+		return strconv.AppendInt(buf, v, 10), nil
+
+	case bool:
+		// The driver returns integers in BOOLEAN columns as bool
+		return strconv.AppendBool(buf, v), nil
+
+	case float64:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, b...), nil
+
+	case time.Time:
+		// The driver returns time values in time columns as time.Time, from the text it bound them as
+		b, err := json.Marshal(v.Format(time.RFC3339Nano))
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, b...), nil
+
+	case string:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, b...), nil
+
+	case []byte:
+		buf = append(buf, '"')
+		buf = base64.StdEncoding.AppendEncode(buf, v)
+		return append(buf, '"'), nil
+
+	default:
+		return buf, fmt.Errorf("unexpected value type %T", v)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestQueryJSON(t *testing.T) {
+	t.Run("writes rows as a JSON array of objects with types by storage class", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (i int, r real, s text, b blob, n text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1, 1.5, 'hello "world"', x'00ff10', null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (-2, 0.25, 'æøå', x'', null)`)
+		assert.NoErr(t, err)
+
+		var buf bytes.Buffer
+		err = sqlite.QueryJSON(context.Background(), db, &buf, `select i, r, s, b, n from t where i < ? order by i`, 10)
+		assert.NoErr(t, err)
+
+		var got []map[string]any
+		assert.NoErr(t, json.Unmarshal(buf.Bytes(), &got))
+
+		expected := []map[string]any{
+			{"i": float64(-2), "r": 0.25, "s": "æøå", "b": "", "n": nil},
+			{"i": float64(1), "r": 1.5, "s": `hello "world"`, "b": "AP8Q", "n": nil},
+		}
+		assert.Equal(t, len(expected), len(got))
+		for i := range expected {
+			assert.Equal(t, len(expected[i]), len(got[i]))
+			for k, v := range expected[i] {
+				assert.Equal(t, v, got[i][k])
+			}
+		}
+	})
+
+	t.Run("writes an empty array for no rows", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var buf bytes.Buffer
+		err := sqlite.QueryJSON(context.Background(), db, &buf, `select 1 as a where false`)
+		assert.NoErr(t, err)
+		assert.Equal(t, "[]", buf.String())
+	})
+
+	t.Run("errors on invalid query", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var buf bytes.Buffer
+		err := sqlite.QueryJSON(context.Background(), db, &buf, `select from`)
+		assert.Err(t, err)
+	})
+}
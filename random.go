@@ -0,0 +1,25 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+
+// sqlite3_test_control is variadic, which cgo can't call directly.
+static int my_prng_seed(int seed) {
+	return sqlite3_test_control(SQLITE_TESTCTRL_PRNG_SEED, seed, (sqlite3 *)0);
+}
+*/
+import "C"
+
+// SeedRandom seeds and resets the pseudo-random number generator of SQLite, so that random(), randomblob() and
+// friends return the same sequence every time after seeding with the same seed. It is meant for tests only.
+// Note that SQLite also draws from the generator internally, such as when creating the WAL file,
+// so do any setup before seeding.
+//
+// Using it outside of tests is unsafe: the generator is shared by all connections of all databases in the process,
+// and SQLite also uses it for things like temporary file names, so reseeding makes those predictable.
+// See https://www.sqlite.org/c3ref/test_control.html
+func SeedRandom(seed int32) {
+	C.my_prng_seed(C.int(seed))
+}
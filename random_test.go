@@ -0,0 +1,43 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestSeedRandom(t *testing.T) {
+	t.Run("makes random return the same sequence after seeding with the same seed", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		// SQLite draws from the generator itself when setting up the database file, so do that before seeding
+		_ = randoms(t, db)
+
+		sqlite.SeedRandom(42)
+		first := randoms(t, db)
+
+		sqlite.SeedRandom(42)
+		second := randoms(t, db)
+
+		for i := range first {
+			assert.Equal(t, first[i], second[i])
+		}
+
+		sqlite.SeedRandom(43)
+		third := randoms(t, db)
+		assert.Equal(t, false, first[0] == third[0] && first[1] == third[1] && first[2] == third[2])
+	})
+}
+
+func randoms(t *testing.T, db *sql.DB) [3]int64 {
+	t.Helper()
+
+	var vs [3]int64
+	err := db.QueryRow(`select random(), random(), random()`).Scan(&vs[0], &vs[1], &vs[2])
+	assert.NoErr(t, err)
+	return vs
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrUnreplayableWAL is returned when opening a database read-only which has a WAL file,
+// but no shared-memory file. This typically happens when the database and WAL files have been copied,
+// for example as a backup, without checkpointing first.
+// A read-only connection would need to create the shared-memory file to read the WAL, which is not
+// possible if the directory is read-only, so SQLite's behavior depends on the environment.
+// See https://www.sqlite.org/wal.html#readonly
+var ErrUnreplayableWAL = errors.New("database has a WAL file without a shared-memory file, which can't be replayed read-only")
+
+// hasUnreplayableWAL reports whether the database file at name has a non-empty WAL file but no shared-memory file.
+// In-memory databases and URI filenames are not checked.
+func hasUnreplayableWAL(name string) bool {
+	if name == "" || name == ":memory:" || strings.HasPrefix(name, "file:") {
+		return false
+	}
+
+	wal, err := os.Stat(name + "-wal")
+	if err != nil || wal.Size() == 0 {
+		return false
+	}
+
+	_, err = os.Stat(name + "-shm")
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// immutableURI returns a URI filename for the database file at name with the immutable parameter set,
+// which makes SQLite assume the file can't change and skip all locking and WAL handling.
+// See https://www.sqlite.org/uri.html#uriimmutable
+func immutableURI(name string) string {
+	u := url.URL{Path: name, RawQuery: "immutable=1"}
+	return "file:" + u.String()
+}
@@ -0,0 +1,103 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestOptions_ReadOnly(t *testing.T) {
+	// Create a database with data both in the main file and the WAL, and copy it mid-transaction
+	// without the shared-memory file, like a naive file-level backup would.
+	src := t.TempDir()
+	db := openPath(t, sqlite.Options{}, path.Join(src, "app.db"))
+
+	_, err := db.Exec(`create table t (v int)`)
+	assert.NoErr(t, err)
+	_, err = db.Exec(`insert into t values (1)`)
+	assert.NoErr(t, err)
+	_, err = db.Exec(`pragma wal_checkpoint(truncate)`)
+	assert.NoErr(t, err)
+	_, err = db.Exec(`insert into t values (2)`)
+	assert.NoErr(t, err)
+
+	conn, err := db.Conn(context.Background())
+	assert.NoErr(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, err = conn.ExecContext(context.Background(), `begin`)
+	assert.NoErr(t, err)
+	_, err = conn.ExecContext(context.Background(), `insert into t values (3)`)
+	assert.NoErr(t, err)
+
+	copyFile := func(t *testing.T, dst string) {
+		t.Helper()
+		for _, suffix := range []string{"", "-wal"} {
+			b, err := os.ReadFile(path.Join(src, "app.db"+suffix))
+			assert.NoErr(t, err)
+			err = os.WriteFile(dst+suffix, b, 0600)
+			assert.NoErr(t, err)
+		}
+	}
+
+	t.Run("errors on read-only open of copied database with WAL", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		copyFile(t, p)
+
+		db := openPath(t, sqlite.Options{ReadOnly: true}, p)
+		err := db.Ping()
+		assert.Err(t, err)
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrUnreplayableWAL))
+	})
+
+	t.Run("opens as immutable with fallback, ignoring the WAL", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		copyFile(t, p)
+
+		db := openPath(t, sqlite.Options{ReadOnly: true, ReadOnlyImmutableFallback: true}, p)
+		var count int
+		err := db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
+
+		_, err = db.Exec(`insert into t values (4)`)
+		assert.Err(t, err)
+	})
+
+	t.Run("replays committed WAL changes on read-write open", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		copyFile(t, p)
+
+		db := openPath(t, sqlite.Options{}, p)
+		var count int
+		err := db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, count)
+	})
+}
+
+func openPath(t *testing.T, opts sqlite.Options, p string) *sql.DB {
+	t.Helper()
+
+	opts.Name = strconv.Itoa(int(time.Now().UnixNano()))
+	sqlite.RegisterDriver(opts)
+
+	db, err := sql.Open(opts.Name, p)
+	assert.NoErr(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
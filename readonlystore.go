@@ -0,0 +1,66 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ReadOnlyStore serves concurrent read-only queries from a database file that never changes,
+// such as a data file shipped with an application or a snapshot for analytics.
+//
+// The file is opened read-only and immutable, so SQLite skips all locking and change detection,
+// and memory-mapped, so all connections share the operating system's page cache for the file instead of
+// each reading pages into its own cache. Only SELECT queries (including those starting with WITH) are allowed.
+// The file must not change while the store is open, and must not have changes in a WAL file that haven't been checkpointed,
+// because the WAL is ignored.
+//
+// A ReadOnlyStore is safe for concurrent use by multiple goroutines.
+// See https://www.sqlite.org/uri.html#uriimmutable and https://www.sqlite.org/mmap.html
+type ReadOnlyStore struct {
+	db *sql.DB
+}
+
+// readOnlyStoreMmapSize is the maximum number of bytes of the file to memory-map. SQLite caps it at compile time,
+// which is 2 GiB by default.
+const readOnlyStoreMmapSize = 1 << 31
+
+// OpenReadOnlyStore opens the database file at path as a ReadOnlyStore.
+// Options.ReadOnly and Options.AllowedStatementPrefixes are always set, and Options.Name is not used.
+func OpenReadOnlyStore(path string, opts Options) (*ReadOnlyStore, error) {
+	opts.ReadOnly = true
+	opts.AllowedStatementPrefixes = []string{"SELECT", "WITH"}
+	opts = withDefaults(opts)
+
+	d := newDriver(opts)
+	d.immutable = true
+	c := &connector{
+		d:       d,
+		name:    path,
+		pragmas: []string{fmt.Sprintf("mmap_size = %v", readOnlyStoreMmapSize)},
+	}
+
+	db := sql.OpenDB(c)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, wrapError("error opening read-only store %v", err, path)
+	}
+	return &ReadOnlyStore{db: db}, nil
+}
+
+// Query runs a SELECT query with args.
+func (s *ReadOnlyStore) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, query, args...)
+}
+
+// QueryRow runs a SELECT query with args that is expected to return at most one row.
+func (s *ReadOnlyStore) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.db.QueryRowContext(ctx, query, args...)
+}
+
+// Close the store and all its connections.
+func (s *ReadOnlyStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,86 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestReadOnlyStore(t *testing.T) {
+	p := path.Join(t.TempDir(), "app.db")
+	db := openPath(t, sqlite.Options{}, p)
+
+	_, err := db.Exec(`create table t (id integer primary key, v int not null)`)
+	assert.NoErr(t, err)
+	_, err = db.Exec(`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t (v) select i from n`)
+	assert.NoErr(t, err)
+	assert.NoErr(t, db.Close())
+
+	t.Run("serves many parallel reads", func(t *testing.T) {
+		s, err := sqlite.OpenReadOnlyStore(p, sqlite.Options{})
+		assert.NoErr(t, err)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					var sum int
+					if err := s.QueryRow(context.Background(), `select sum(v) from t where id <= ?`, i+1).Scan(&sum); err != nil {
+						errs <- err
+						return
+					}
+					if sum != (i+1)*(i+2)/2 {
+						t.Errorf("unexpected sum %v for %v", sum, i+1)
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoErr(t, err)
+		}
+
+		rows, err := s.Query(context.Background(), `select v from t order by id limit 3`)
+		assert.NoErr(t, err)
+		var vs []int
+		for rows.Next() {
+			var v int
+			assert.NoErr(t, rows.Scan(&v))
+			vs = append(vs, v)
+		}
+		assert.NoErr(t, rows.Err())
+		assert.NoErr(t, rows.Close())
+		assert.Equal(t, 3, len(vs))
+	})
+
+	t.Run("rejects writes", func(t *testing.T) {
+		s, err := sqlite.OpenReadOnlyStore(p, sqlite.Options{})
+		assert.NoErr(t, err)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		rows, err := s.Query(context.Background(), `insert into t (v) values (1) returning id`)
+		assert.Err(t, err)
+		assert.Equal(t, true, rows == nil)
+	})
+
+	t.Run("errors on missing file", func(t *testing.T) {
+		_, err := sqlite.OpenReadOnlyStore(path.Join(t.TempDir(), "nope.db"), sqlite.Options{})
+		assert.Err(t, err)
+	})
+}
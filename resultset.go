@@ -0,0 +1,116 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <stdlib.h>
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+)
+
+// HasNextResultSet is called at the end of the current result set and
+// reports whether there is another result set after the current one.
+// There is, if the query has more statements after the current one.
+// Statements after the first can't have parameters.
+func (r *rows) HasNextResultSet() bool {
+	if r.statement == nil {
+		return false
+	}
+	if r.next == nil && r.nextErr == nil {
+		r.next, r.tail, r.nextErr = r.statement.connection.prepareNext(r.tail)
+	}
+	return r.next != nil || r.nextErr != nil
+}
+
+// NextResultSet advances the driver to the next result set even
+// if there are remaining rows in the current result set.
+// If no rows of the current result set have been read, its statement is run first,
+// so skipped statements such as INSERTs still apply.
+//
+// NextResultSet should return io.EOF when there are no more result sets.
+func (r *rows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	if r.nextErr != nil {
+		return r.nextErr
+	}
+
+	if !r.stepped {
+		if err := r.Next(make([]driver.Value, len(r.Columns()))); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+
+	c := r.statement.connection
+	r.closeOwned()
+	r.statement, r.owned, r.next, r.count, r.stepped, r.onRow = r.next, true, nil, 0, false, false
+
+	if err := c.acquireWriter(context.Background(), r.statement.cStatement); err != nil {
+		return err
+	}
+	return nil
+}
+
+// closeOwned finalizes the current statement if rows prepared it.
+func (r *rows) closeOwned() {
+	if r.owned {
+		_ = r.statement.Close()
+		r.owned = false
+	}
+}
+
+// prepareNext prepares the first statement in tail, returning nil if there is none, and the rest of tail after it.
+func (c *connection) prepareNext(tail string) (*statement, string, error) {
+	for strings.TrimSpace(tail) != "" {
+		query := tail
+
+		cQuery := C.CString(query)
+		var cStatement *C.sqlite3_stmt
+		var cTail *C.char
+		cCode := C.sqlite3_prepare_v2(c.cC, cQuery, C.int(len(query)+1), &cStatement, &cTail)
+		tail = tailOf(query, cQuery, cTail)
+		C.free(unsafe.Pointer(cQuery))
+
+		if cCode != C.SQLITE_OK {
+			return nil, "", c.wrapErrorCode(`error preparing statement for query "%v"`, cCode, query)
+		}
+		// The statement is nil if query starts with only whitespace or comments until the next statement
+		if cStatement == nil {
+			continue
+		}
+
+		// Only the statement itself, not the rest of the tail
+		query = strings.TrimSpace(query[:len(query)-len(tail)])
+
+		s := &statement{connection: c, query: query, cStatement: cStatement, tail: tail}
+		if err := c.checkAllowedStatement(query); err != nil {
+			_ = s.Close()
+			return nil, "", err
+		}
+		if err := c.checkReadOnlyStatement(s); err != nil {
+			_ = s.Close()
+			return nil, "", err
+		}
+		if s.NumInput() > 0 {
+			_ = s.Close()
+			return nil, "", fmt.Errorf(`parameters are only supported in the first statement of a query, not in "%v"`, query)
+		}
+		if c.opts.WarnUnorderedQueries {
+			s.unordered = isUnorderedSelect(query)
+		}
+		s.loadColumnNames()
+		return s, tail, nil
+	}
+	return nil, "", nil
+}
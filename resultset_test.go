@@ -0,0 +1,219 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestRows_NextResultSet(t *testing.T) {
+	t.Run("iterates result sets with their own columns", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query(`select 1 as a, 2 as b union all select 3, 4; select 'x' as c`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		columns, err := rows.Columns()
+		assert.NoErr(t, err)
+		assert.Equal(t, "a,b", strings.Join(columns, ","))
+
+		var sum int
+		for rows.Next() {
+			var a, b int
+			assert.NoErr(t, rows.Scan(&a, &b))
+			sum += a + b
+		}
+		assert.Equal(t, 10, sum)
+
+		assert.Equal(t, true, rows.NextResultSet())
+
+		columns, err = rows.Columns()
+		assert.NoErr(t, err)
+		assert.Equal(t, "c", strings.Join(columns, ","))
+
+		assert.Equal(t, true, rows.Next())
+		var c string
+		assert.NoErr(t, rows.Scan(&c))
+		assert.Equal(t, "x", c)
+		assert.Equal(t, false, rows.Next())
+
+		assert.Equal(t, false, rows.NextResultSet())
+		assert.NoErr(t, rows.Err())
+	})
+
+	t.Run("skips to the next result set with rows remaining, and runs skipped statements", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select 1 union all select 2; insert into t values (1); insert into t values (2) returning v; select count(*) from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		assert.Equal(t, true, rows.NextResultSet())
+		assert.Equal(t, true, rows.NextResultSet())
+		assert.Equal(t, true, rows.NextResultSet())
+		assert.Equal(t, true, rows.Next())
+		var count int
+		assert.NoErr(t, rows.Scan(&count))
+		assert.Equal(t, 2, count)
+		assert.Equal(t, false, rows.NextResultSet())
+	})
+
+	t.Run("has no next result set after only whitespace and comments", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query("select 1; \n -- done\n")
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		for rows.Next() {
+		}
+		assert.Equal(t, false, rows.NextResultSet())
+		assert.NoErr(t, rows.Err())
+	})
+
+	t.Run("can run a prepared statement with several result sets again", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		stmt, err := db.Prepare(`select ?; select 2`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = stmt.Close()
+		}()
+
+		for i := 0; i < 2; i++ {
+			rows, err := stmt.Query(1)
+			assert.NoErr(t, err)
+			assert.Equal(t, 3, sumResultSets(t, rows))
+		}
+	})
+
+	t.Run("errors on parameters in later statements", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query(`select 1; select ?`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		for rows.Next() {
+		}
+		assert.Equal(t, false, rows.NextResultSet())
+		assert.Err(t, rows.Err())
+	})
+
+	t.Run("rejects queries with later statements not allowed by AllowedStatementPrefixes before running any", func(t *testing.T) {
+		db := open(t, sqlite.Options{AllowedStatementPrefixes: []string{"select", "create"}})
+
+		_, err := db.Exec(`create table t (v int); delete from t`)
+		assert.Err(t, err)
+
+		_, err = db.Query(`select 1; delete from t`)
+		assert.Err(t, err)
+
+		var count int
+		err = db.QueryRow(`select count(*) from sqlite_schema`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+
+		_, err = db.Exec(`create trigger tr after insert on t begin select 1; select 2; end`)
+		assert.Err(t, err)
+		assert.Equal(t, true, !strings.Contains(err.Error(), `"END"`))
+	})
+}
+
+// sumResultSets returns the sum of the integers in the first column of all rows in all result sets, and closes rows.
+func sumResultSets(t *testing.T, rows *sql.Rows) int {
+	t.Helper()
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var sum int
+	for {
+		for rows.Next() {
+			var v int
+			assert.NoErr(t, rows.Scan(&v))
+			sum += v
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	assert.NoErr(t, rows.Err())
+	return sum
+}
+
+func TestDB_Exec_multipleStatements(t *testing.T) {
+	t.Run("runs all statements in the query", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		result, err := db.Exec(`insert into t values (?); insert into t values (2), (3);`, 1)
+		assert.NoErr(t, err)
+
+		rowsAffected, err := result.RowsAffected()
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(2), rowsAffected)
+
+		var sum int
+		err = db.QueryRow(`select sum(v) from t`).Scan(&sum)
+		assert.NoErr(t, err)
+		assert.Equal(t, 6, sum)
+	})
+
+	t.Run("runs all statements in a prepared statement", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		stmt, err := db.Prepare(`insert into t values (?); insert into t values (10)`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = stmt.Close()
+		}()
+
+		_, err = stmt.Exec(1)
+		assert.NoErr(t, err)
+		_, err = stmt.Exec(2)
+		assert.NoErr(t, err)
+
+		var sum int
+		err = db.QueryRow(`select sum(v) from t`).Scan(&sum)
+		assert.NoErr(t, err)
+		assert.Equal(t, 23, sum)
+	})
+
+	t.Run("errors on a failing later statement", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int); insert into nope values (1)`)
+		assert.Err(t, err)
+		assert.Equal(t, true, strings.Contains(err.Error(), "no such table: nope"))
+	})
+
+	t.Run("errors on parameters in later statements", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int); insert into t values (?)`)
+		assert.Err(t, err)
+	})
+}
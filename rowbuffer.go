@@ -0,0 +1,47 @@
+package sqlite
+
+import "database/sql"
+
+// ScanInto scans the current row of rows into dest, which must hold one pointer per column, like for rows.Scan.
+// Create dest once before the loop and pass it for every row, to avoid allocating destinations per row:
+//
+//	var id int64
+//	var name string
+//	dest := []any{&id, &name}
+//	for rows.Next() {
+//		if err := sqlite.ScanInto(rows, dest); err != nil { … }
+//		// Use id and name before the next iteration overwrites them
+//	}
+func ScanInto(rows *sql.Rows, dest []any) error {
+	if err := rows.Scan(dest...); err != nil {
+		return wrapError("error scanning row", err)
+	}
+	return nil
+}
+
+// RowBuffer is a reusable buffer of column values, for scanning many rows without
+// allocating a new destination slice per row. It is not safe for concurrent use.
+//
+// Values holds the values of the most recently scanned row, and is overwritten on each Scan,
+// so copy values that must outlive the iteration.
+type RowBuffer struct {
+	Values []any
+	ptrs   []any
+}
+
+// NewRowBuffer for rows with the given number of columns.
+func NewRowBuffer(columns int) *RowBuffer {
+	b := &RowBuffer{
+		Values: make([]any, columns),
+		ptrs:   make([]any, columns),
+	}
+	for i := range b.Values {
+		b.ptrs[i] = &b.Values[i]
+	}
+	return b
+}
+
+// Scan the current row of rows into Values.
+func (b *RowBuffer) Scan(rows *sql.Rows) error {
+	return ScanInto(rows, b.ptrs)
+}
@@ -0,0 +1,123 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestRowBuffer(t *testing.T) {
+	t.Run("reuses the buffer across rows", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, name text not null)`,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 3) insert into t (name) select 'name' from n`)
+
+		rows, err := db.Query(`select id, name from t order by id`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		b := sqlite.NewRowBuffer(2)
+		var ids []int64
+		var names []string
+		for rows.Next() {
+			err := b.Scan(rows)
+			assert.NoErr(t, err)
+			ids = append(ids, b.Values[0].(int64))
+			names = append(names, b.Values[1].(string))
+		}
+		assert.NoErr(t, rows.Err())
+
+		assert.Equal(t, 3, len(ids))
+		for i := range ids {
+			assert.Equal(t, int64(i+1), ids[i])
+			assert.Equal(t, "name", names[i])
+		}
+	})
+}
+
+func TestScanInto(t *testing.T) {
+	t.Run("scans into reused pointers", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, name text not null)`,
+			`with recursive n(i) as (select 1 union all select i + 1 from n where i < 3) insert into t (name) select 'name' from n`)
+
+		rows, err := db.Query(`select id, name from t order by id`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var id int64
+		var name string
+		dest := []any{&id, &name}
+		var sum int64
+		for rows.Next() {
+			err := sqlite.ScanInto(rows, dest)
+			assert.NoErr(t, err)
+			assert.Equal(t, "name", name)
+			sum += id
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, int64(6), sum)
+	})
+
+	t.Run("errors on wrong number of destinations", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, name text not null)`,
+			`insert into t (name) values ('name')`)
+
+		rows, err := db.Query(`select id, name from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		assert.Equal(t, true, rows.Next())
+		var id int64
+		err = sqlite.ScanInto(rows, []any{&id})
+		assert.Err(t, err)
+	})
+}
+
+func BenchmarkRowBuffer(b *testing.B) {
+	db := openWith(b, sqlite.Options{},
+		`create table t (id integer primary key, name text not null)`,
+		`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t (name) select 'name' from n`)
+
+	b.Run("allocating per row", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rows, err := db.Query(`select id, name from t`)
+			assert.NoErr(b, err)
+			for rows.Next() {
+				values := make([]any, 2)
+				ptrs := make([]any, 2)
+				for j := range values {
+					ptrs[j] = &values[j]
+				}
+				err := rows.Scan(ptrs...)
+				assert.NoErr(b, err)
+			}
+			assert.NoErr(b, rows.Close())
+		}
+	})
+
+	b.Run("reusing a row buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := sqlite.NewRowBuffer(2)
+		for i := 0; i < b.N; i++ {
+			rows, err := db.Query(`select id, name from t`)
+			assert.NoErr(b, err)
+			for rows.Next() {
+				err := buf.Scan(rows)
+				assert.NoErr(b, err)
+			}
+			assert.NoErr(b, rows.Close())
+		}
+	})
+}
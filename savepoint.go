@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+var savepointCounter atomic.Int64
+
+// Savepoint is a nested transaction inside a *sql.Tx, created with BeginNested.
+// See https://www.sqlite.org/lang_savepoint.html
+type Savepoint struct {
+	ctx   context.Context
+	tx    *sql.Tx
+	name  string
+	depth int
+	done  bool
+}
+
+// BeginNested starts a savepoint directly in tx with an automatically generated unique name.
+// Use Savepoint.BeginNested to start a savepoint nested inside another.
+func BeginNested(ctx context.Context, tx *sql.Tx) (*Savepoint, error) {
+	return beginSavepoint(ctx, tx, 1)
+}
+
+// BeginNested starts a savepoint nested inside s, which must be committed or rolled back before s.
+func (s *Savepoint) BeginNested(ctx context.Context) (*Savepoint, error) {
+	if s.done {
+		return nil, errors.New("savepoint already committed or rolled back")
+	}
+	return beginSavepoint(ctx, s.tx, s.depth+1)
+}
+
+func beginSavepoint(ctx context.Context, tx *sql.Tx, depth int) (*Savepoint, error) {
+	name := fmt.Sprintf("sp_%v", savepointCounter.Add(1))
+	if _, err := tx.ExecContext(ctx, "savepoint "+name); err != nil {
+		return nil, wrapError("error starting savepoint", err)
+	}
+	return &Savepoint{ctx: ctx, tx: tx, name: name, depth: depth}, nil
+}
+
+// Name of the savepoint.
+func (s *Savepoint) Name() string {
+	return s.name
+}
+
+// Depth of the savepoint, starting at 1 for a savepoint directly in the transaction,
+// and one more than the depth of the savepoint it was started from with Savepoint.BeginNested.
+func (s *Savepoint) Depth() int {
+	return s.depth
+}
+
+// Commit the savepoint with RELEASE, keeping its changes as part of the enclosing transaction or savepoint.
+func (s *Savepoint) Commit() error {
+	if err := s.finish(); err != nil {
+		return err
+	}
+	if _, err := s.tx.ExecContext(s.ctx, "release "+s.name); err != nil {
+		return wrapError("error releasing savepoint %v", err, s.name)
+	}
+	return nil
+}
+
+// Rollback the changes made since the savepoint started, with ROLLBACK TO, and RELEASE it.
+func (s *Savepoint) Rollback() error {
+	if err := s.finish(); err != nil {
+		return err
+	}
+	if _, err := s.tx.ExecContext(s.ctx, "rollback to "+s.name); err != nil {
+		return wrapError("error rolling back to savepoint %v", err, s.name)
+	}
+	if _, err := s.tx.ExecContext(s.ctx, "release "+s.name); err != nil {
+		return wrapError("error releasing savepoint %v", err, s.name)
+	}
+	return nil
+}
+
+// finish marks the savepoint as done.
+func (s *Savepoint) finish() error {
+	if s.done {
+		return errors.New("savepoint already committed or rolled back")
+	}
+	s.done = true
+	return nil
+}
@@ -0,0 +1,94 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestBeginNested(t *testing.T) {
+	t.Run("rolls back the inner savepoint and commits the outer", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int not null)`)
+		assert.NoErr(t, err)
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+
+		outer, err := sqlite.BeginNested(context.Background(), tx)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, outer.Depth())
+
+		_, err = tx.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		inner, err := outer.BeginNested(context.Background())
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, inner.Depth())
+		assert.Equal(t, true, inner.Name() != outer.Name())
+
+		_, err = tx.Exec(`insert into t values (2)`)
+		assert.NoErr(t, err)
+
+		err = inner.Rollback()
+		assert.NoErr(t, err)
+
+		err = outer.Commit()
+		assert.NoErr(t, err)
+
+		err = tx.Commit()
+		assert.NoErr(t, err)
+
+		var sum int
+		err = db.QueryRow(`select sum(v) from t`).Scan(&sum)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, sum)
+	})
+
+	t.Run("errors on finishing a savepoint twice", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		s, err := sqlite.BeginNested(context.Background(), tx)
+		assert.NoErr(t, err)
+
+		assert.NoErr(t, s.Commit())
+		assert.Err(t, s.Rollback())
+	})
+
+	t.Run("keeps depths when savepoints are finished out of order", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		outer, err := sqlite.BeginNested(context.Background(), tx)
+		assert.NoErr(t, err)
+		inner, err := outer.BeginNested(context.Background())
+		assert.NoErr(t, err)
+
+		// Releasing the outer savepoint also releases the inner one in SQLite
+		assert.NoErr(t, outer.Commit())
+
+		next, err := sqlite.BeginNested(context.Background(), tx)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, next.Depth())
+		assert.Equal(t, 2, inner.Depth())
+
+		_, err = outer.BeginNested(context.Background())
+		assert.Err(t, err)
+	})
+}
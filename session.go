@@ -0,0 +1,502 @@
+//go:build cgo && session
+
+package sqlite
+
+/*
+#cgo CFLAGS: -DSQLITE_ENABLE_SESSION -DSQLITE_ENABLE_PREUPDATE_HOOK
+#include <stdint.h>
+#include <stdlib.h>
+#include <sqlite3.h>
+
+extern int goConflict(void *p, int eConflict, sqlite3_changeset_iter *pIter);
+extern int goChangesetInput(void *p, void *pData, int *pnData);
+
+static int my_changeset_apply(sqlite3 *db, int n, void *p, uintptr_t handle, int *pnRebase, void **ppRebase) {
+	return sqlite3changeset_apply_v2(db, n, p, NULL,
+		(int (*)(void *, int, sqlite3_changeset_iter *))goConflict, (void *)handle, ppRebase, pnRebase, 0);
+}
+
+static int my_changeset_apply_strm(sqlite3 *db, uintptr_t inputHandle, uintptr_t handle) {
+	return sqlite3changeset_apply_strm(db, goChangesetInput, (void *)inputHandle, NULL,
+		(int (*)(void *, int, sqlite3_changeset_iter *))goConflict, (void *)handle);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// WithTxChangeset runs fn in a transaction like Transaction, and records the changes made in it with the session
+// extension. If fn returns nil and the transaction commits, the changes are returned as a changeset,
+// which is nil if nothing changed. Otherwise, the transaction is rolled back and no changeset is returned.
+//
+// Only changes to tables in the main database with a PRIMARY KEY are recorded.
+// It's behind the session build tag, which compiles SQLite with the session extension:
+//
+//	go build -tags session
+//
+// See https://www.sqlite.org/sessionintro.html
+func WithTxChangeset(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) ([]byte, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, wrapError("error getting connection", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// The session records changes on the connection it's created on, so everything runs on conn
+	var cSession *C.sqlite3_session
+	err = withConnection(conn, func(c *connection) error {
+		cMain := C.CString("main")
+		defer C.free(unsafe.Pointer(cMain))
+
+		if cCode := C.sqlite3session_create(c.cC, cMain, &cSession); cCode != C.SQLITE_OK {
+			return c.wrapErrorCode("error creating session", cCode)
+		}
+		if cCode := C.sqlite3session_attach(cSession, nil); cCode != C.SQLITE_OK {
+			C.sqlite3session_delete(cSession)
+			return c.wrapErrorCode("error attaching session", cCode)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = withConnection(conn, func(c *connection) error {
+			C.sqlite3session_delete(cSession)
+			return nil
+		})
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, wrapError("error beginning transaction", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return nil, wrapError("error rolling back transaction after error %v", rollbackErr, err)
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, wrapError("error committing transaction", err)
+	}
+
+	var changeset []byte
+	err = withConnection(conn, func(c *connection) error {
+		var n C.int
+		var p unsafe.Pointer
+		if cCode := C.sqlite3session_changeset(cSession, &n, &p); cCode != C.SQLITE_OK {
+			return c.wrapErrorCode("error getting changeset", cCode)
+		}
+		defer C.sqlite3_free(p)
+
+		if n > 0 {
+			changeset = C.GoBytes(p, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changeset, nil
+}
+
+// ConflictType is the kind of conflict when applying a changeset.
+// See https://www.sqlite.org/session/c_changeset_conflict.html
+type ConflictType int
+
+const (
+	// ConflictData is a DELETE or UPDATE of a row that exists, but with different values than expected.
+	ConflictData = ConflictType(C.SQLITE_CHANGESET_DATA)
+	// ConflictNotFound is a DELETE or UPDATE of a row that doesn't exist.
+	ConflictNotFound = ConflictType(C.SQLITE_CHANGESET_NOTFOUND)
+	// ConflictConflict is an INSERT of a row with a primary key that already exists.
+	ConflictConflict = ConflictType(C.SQLITE_CHANGESET_CONFLICT)
+	// ConflictConstraint is a change that violates a constraint other than the primary key.
+	ConflictConstraint = ConflictType(C.SQLITE_CHANGESET_CONSTRAINT)
+	// ConflictForeignKey is a foreign key violation after all changes are applied.
+	ConflictForeignKey = ConflictType(C.SQLITE_CHANGESET_FOREIGN_KEY)
+)
+
+// ConflictAction is how to resolve a conflict when applying a changeset.
+// See https://www.sqlite.org/session/c_changeset_abort.html
+type ConflictAction int
+
+const (
+	// ConflictOmit skips the conflicting change.
+	ConflictOmit = ConflictAction(C.SQLITE_CHANGESET_OMIT)
+	// ConflictReplace applies the conflicting change anyway, replacing the existing row.
+	// It's only allowed for ConflictData and ConflictConflict.
+	ConflictReplace = ConflictAction(C.SQLITE_CHANGESET_REPLACE)
+	// ConflictAbort rolls back all changes applied so far, and makes ApplyChangeset return an error.
+	ConflictAbort = ConflictAction(C.SQLITE_CHANGESET_ABORT)
+)
+
+// ConflictResolver decides how to resolve a conflict when applying a changeset.
+type ConflictResolver func(conflict ConflictInfo) ConflictAction
+
+// ChangeOp is a change to a row in a changeset.
+type ChangeOp struct {
+	// Table is the name of the table the change is for.
+	Table string
+	// Op is the change, one of "INSERT", "UPDATE", or "DELETE".
+	Op string
+	// Indirect is whether the change was made by a trigger or foreign key action, instead of directly.
+	Indirect bool
+	// Old are the values of the row before the change, for UPDATE and DELETE.
+	// For UPDATE, only the primary key and changed columns have values, the rest are nil.
+	Old []driver.Value
+	// New are the values of the row after the change, for INSERT and UPDATE.
+	// For UPDATE, only the changed columns have values, the rest are nil.
+	New []driver.Value
+}
+
+// ConflictInfo describes a conflict when applying a changeset.
+type ConflictInfo struct {
+	ChangeOp
+	Type ConflictType
+	// Conflicting are the values of the existing row in the database, for ConflictData and ConflictConflict.
+	Conflicting []driver.Value
+}
+
+// ApplyChangeset applies changeset to db, calling resolve for every conflict to decide what to do.
+// If resolve is nil, conflicts abort. The changeset is applied in a savepoint, so on error, nothing is applied.
+// resolve is called on the connection applying the changeset, so it must not use db.
+// See https://www.sqlite.org/session/sqlite3changeset_apply.html
+func ApplyChangeset(ctx context.Context, db *sql.DB, changeset []byte, resolve ConflictResolver) error {
+	_, err := applyChangeset(ctx, db, changeset, resolve, false)
+	return err
+}
+
+// ApplyChangesetWithRebase is like ApplyChangeset, but also returns a rebase buffer,
+// which records how conflicts were resolved. Use it with RebaseChangeset to rebase local changes
+// onto the applied changeset.
+// See https://www.sqlite.org/session/sqlite3changeset_apply.html
+func ApplyChangesetWithRebase(ctx context.Context, db *sql.DB, changeset []byte, resolve ConflictResolver) ([]byte, error) {
+	return applyChangeset(ctx, db, changeset, resolve, true)
+}
+
+func applyChangeset(ctx context.Context, db *sql.DB, changeset []byte, resolve ConflictResolver, withRebase bool) ([]byte, error) {
+	if resolve == nil {
+		resolve = abortOnConflict
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, wrapError("error getting connection", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var rebase []byte
+	err = withConnection(conn, func(c *connection) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		cChangeset := C.CBytes(changeset)
+		defer C.free(cChangeset)
+
+		handle := cgo.NewHandle(resolve)
+		defer handle.Delete()
+
+		var n C.int
+		var p unsafe.Pointer
+		pn, pp := &n, &p
+		if !withRebase {
+			pn, pp = nil, nil
+		}
+
+		stop := c.interruptOnDone(ctx)
+		cCode := C.my_changeset_apply(c.cC, C.int(len(changeset)), cChangeset, C.uintptr_t(handle), pn, pp)
+		stop()
+		defer C.sqlite3_free(p)
+
+		if cCode != C.SQLITE_OK {
+			if ctx.Err() != nil {
+				return wrapError("changeset apply interrupted and rolled back", ctx.Err())
+			}
+			return c.wrapErrorCode("error applying changeset", cCode)
+		}
+
+		if n > 0 {
+			rebase = C.GoBytes(p, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rebase, nil
+}
+
+// ApplyChangesetStream is like ApplyChangeset, but reads the changeset from r while applying it,
+// so large changesets don't have to be held in memory.
+// See https://www.sqlite.org/session/sqlite3changegroup_add_strm.html
+func ApplyChangesetStream(ctx context.Context, db *sql.DB, r io.Reader, resolve ConflictResolver) error {
+	if resolve == nil {
+		resolve = abortOnConflict
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return wrapError("error getting connection", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return withConnection(conn, func(c *connection) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		input := &changesetInput{r: r}
+		inputHandle := cgo.NewHandle(input)
+		defer inputHandle.Delete()
+
+		handle := cgo.NewHandle(resolve)
+		defer handle.Delete()
+
+		stop := c.interruptOnDone(ctx)
+		cCode := C.my_changeset_apply_strm(c.cC, C.uintptr_t(inputHandle), C.uintptr_t(handle))
+		stop()
+
+		if cCode != C.SQLITE_OK {
+			if ctx.Err() != nil {
+				return wrapError("changeset apply interrupted and rolled back", ctx.Err())
+			}
+			if input.err != nil {
+				return wrapError("error reading changeset", input.err)
+			}
+			return c.wrapErrorCode("error applying changeset", cCode)
+		}
+		return nil
+	})
+}
+
+func abortOnConflict(ConflictInfo) ConflictAction {
+	return ConflictAbort
+}
+
+// changesetInput is the reader of ApplyChangesetStream, with the error from reading it, if any.
+type changesetInput struct {
+	r   io.Reader
+	err error
+}
+
+// readChangesetInput reads up to *pnData bytes into pData from the changesetInput in the handle p,
+// setting *pnData to the number of bytes read, which is zero at the end of the input.
+// See https://www.sqlite.org/session/sqlite3changegroup_add_strm.html
+func readChangesetInput(p unsafe.Pointer, pData unsafe.Pointer, pnData *C.int) C.int {
+	input := cgo.Handle(uintptr(p)).Value().(*changesetInput)
+
+	buf := unsafe.Slice((*byte)(pData), int(*pnData))
+	n, err := io.ReadFull(input.r, buf)
+	*pnData = C.int(n)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		input.err = err
+		return C.SQLITE_IOERR
+	}
+	return C.SQLITE_OK
+}
+
+// callConflict calls the resolve function of ApplyChangeset in the handle p with the conflict at pIter.
+// See https://www.sqlite.org/session/sqlite3changeset_conflict.html
+func callConflict(p unsafe.Pointer, eConflict C.int, pIter *C.sqlite3_changeset_iter) C.int {
+	resolve := cgo.Handle(uintptr(p)).Value().(ConflictResolver)
+
+	op, nCol, cCode := changeOpFromIter(pIter)
+	if cCode != C.SQLITE_OK {
+		return C.SQLITE_CHANGESET_ABORT
+	}
+
+	info := ConflictInfo{ChangeOp: op, Type: ConflictType(eConflict)}
+	if info.Type == ConflictData || info.Type == ConflictConflict {
+		info.Conflicting = changesetValues(pIter, nCol, changesetConflict)
+	}
+
+	return C.int(resolve(info))
+}
+
+// changeOpFromIter returns the change at pIter, and its number of columns.
+// See https://www.sqlite.org/session/sqlite3changeset_op.html
+func changeOpFromIter(pIter *C.sqlite3_changeset_iter) (ChangeOp, C.int, C.int) {
+	var cTable *C.char
+	var nCol, op, indirect C.int
+	if cCode := C.sqlite3changeset_op(pIter, &cTable, &nCol, &op, &indirect); cCode != C.SQLITE_OK {
+		return ChangeOp{}, 0, cCode
+	}
+
+	change := ChangeOp{Table: C.GoString(cTable), Indirect: indirect != 0}
+	switch op {
+	case C.SQLITE_INSERT:
+		change.Op = "INSERT"
+		change.New = changesetValues(pIter, nCol, changesetNew)
+	case C.SQLITE_UPDATE:
+		change.Op = "UPDATE"
+		change.Old = changesetValues(pIter, nCol, changesetOld)
+		change.New = changesetValues(pIter, nCol, changesetNew)
+	case C.SQLITE_DELETE:
+		change.Op = "DELETE"
+		change.Old = changesetValues(pIter, nCol, changesetOld)
+	default:
+		change.Op = fmt.Sprint(op)
+	}
+	return change, nCol, C.SQLITE_OK
+}
+
+type changesetValueKind int
+
+const (
+	changesetOld changesetValueKind = iota
+	changesetNew
+	changesetConflict
+)
+
+// changesetValues returns the nCol values of the kind at pIter. Values SQLite doesn't have are nil.
+func changesetValues(pIter *C.sqlite3_changeset_iter, nCol C.int, kind changesetValueKind) []driver.Value {
+	values := make([]driver.Value, nCol)
+	for i := range values {
+		var v *C.sqlite3_value
+		var cCode C.int
+		switch kind {
+		case changesetOld:
+			cCode = C.sqlite3changeset_old(pIter, C.int(i), &v)
+		case changesetNew:
+			cCode = C.sqlite3changeset_new(pIter, C.int(i), &v)
+		case changesetConflict:
+			cCode = C.sqlite3changeset_conflict(pIter, C.int(i), &v)
+		}
+		if cCode == C.SQLITE_OK && v != nil {
+			values[i] = valueFromC(v)
+		}
+	}
+	return values
+}
+
+// InvertChangeset returns the inverse of changeset, which undoes its changes when applied:
+// inserts become deletes, deletes become inserts, and updates swap their old and new values.
+// See https://www.sqlite.org/session/sqlite3changeset_invert.html
+func InvertChangeset(changeset []byte) ([]byte, error) {
+	cChangeset := C.CBytes(changeset)
+	defer C.free(cChangeset)
+
+	var n C.int
+	var p unsafe.Pointer
+	if cCode := C.sqlite3changeset_invert(C.int(len(changeset)), cChangeset, &n, &p); cCode != C.SQLITE_OK {
+		return nil, wrapErrorCode("error inverting changeset", cCode)
+	}
+	defer C.sqlite3_free(p)
+
+	if n == 0 {
+		return nil, nil
+	}
+	return C.GoBytes(p, n), nil
+}
+
+// ConcatChangesets returns a single changeset with the changes of a followed by the changes of b.
+// Changes to the same row are combined, so for example an insert in a and an update of the row in b
+// become an insert with the updated values.
+// See https://www.sqlite.org/session/sqlite3changeset_concat.html
+func ConcatChangesets(a, b []byte) ([]byte, error) {
+	cA := C.CBytes(a)
+	defer C.free(cA)
+	cB := C.CBytes(b)
+	defer C.free(cB)
+
+	var n C.int
+	var p unsafe.Pointer
+	if cCode := C.sqlite3changeset_concat(C.int(len(a)), cA, C.int(len(b)), cB, &n, &p); cCode != C.SQLITE_OK {
+		return nil, wrapErrorCode("error concatenating changesets", cCode)
+	}
+	defer C.sqlite3_free(p)
+
+	if n == 0 {
+		return nil, nil
+	}
+	return C.GoBytes(p, n), nil
+}
+
+// RebaseChangeset rebases the local changeset onto a remote changeset that has been applied locally with
+// ApplyChangesetWithRebase, which returned rebase. The result is changeset adjusted for how conflicts with the remote
+// changes were resolved, so it can be applied where the remote changeset has been applied, such as on a server.
+// For example, local updates of columns where a conflict was resolved with ConflictReplace are removed,
+// so the remote values are kept.
+// See https://www.sqlite.org/session/rebaser.html
+func RebaseChangeset(rebase, changeset []byte) ([]byte, error) {
+	var cRebaser *C.sqlite3_rebaser
+	if cCode := C.sqlite3rebaser_create(&cRebaser); cCode != C.SQLITE_OK {
+		return nil, wrapErrorCode("error creating rebaser", cCode)
+	}
+	defer C.sqlite3rebaser_delete(cRebaser)
+
+	cRebase := C.CBytes(rebase)
+	defer C.free(cRebase)
+	if cCode := C.sqlite3rebaser_configure(cRebaser, C.int(len(rebase)), cRebase); cCode != C.SQLITE_OK {
+		return nil, wrapErrorCode("error configuring rebaser", cCode)
+	}
+
+	cChangeset := C.CBytes(changeset)
+	defer C.free(cChangeset)
+
+	var n C.int
+	var p unsafe.Pointer
+	if cCode := C.sqlite3rebaser_rebase(cRebaser, C.int(len(changeset)), cChangeset, &n, &p); cCode != C.SQLITE_OK {
+		return nil, wrapErrorCode("error rebasing changeset", cCode)
+	}
+	defer C.sqlite3_free(p)
+
+	if n == 0 {
+		return nil, nil
+	}
+	return C.GoBytes(p, n), nil
+}
+
+// IterateChangeset calls fn for each change in changeset, in order, without applying it.
+// If fn returns an error, iteration stops and the error is returned.
+// See https://www.sqlite.org/session/sqlite3changeset_start.html
+func IterateChangeset(changeset []byte, fn func(op ChangeOp) error) error {
+	cChangeset := C.CBytes(changeset)
+	defer C.free(cChangeset)
+
+	var pIter *C.sqlite3_changeset_iter
+	if cCode := C.sqlite3changeset_start(&pIter, C.int(len(changeset)), cChangeset); cCode != C.SQLITE_OK {
+		return wrapErrorCode("error starting changeset iteration", cCode)
+	}
+
+	var fnErr error
+	for C.sqlite3changeset_next(pIter) == C.SQLITE_ROW {
+		op, _, cCode := changeOpFromIter(pIter)
+		if cCode != C.SQLITE_OK {
+			break
+		}
+		if fnErr = fn(op); fnErr != nil {
+			break
+		}
+	}
+
+	// Finalizing returns the first error from iterating, if any
+	cCode := C.sqlite3changeset_finalize(pIter)
+	if fnErr != nil {
+		return fnErr
+	}
+	if cCode != C.SQLITE_OK {
+		return wrapErrorCode("error iterating changeset", cCode)
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+//go:build cgo && session
+
+package sqlite_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestWithTxChangeset(t *testing.T) {
+	t.Run("returns a changeset with the inserts and updates of the transaction", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1, 'Alice')`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`insert into t values (2, 'Carol')`); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`update t set name = 'Dave' where id = 2`); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`update t set name = 'Alicia' where id = 1`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		// A changeset starts with a table header, which has the table name
+		assert.Equal(t, byte('T'), changeset[0])
+		assert.Equal(t, true, bytes.Contains(changeset, []byte("t\x00")))
+
+		// The insert and update of the same row are combined into an insert of the final values
+		assert.Equal(t, false, bytes.Contains(changeset, []byte("Carol")))
+		assert.Equal(t, true, bytes.Contains(changeset, []byte("Dave")))
+
+		// The update of an existing row has the old and new values
+		assert.Equal(t, true, bytes.Contains(changeset, []byte("Alice")))
+		assert.Equal(t, true, bytes.Contains(changeset, []byte("Alicia")))
+
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("returns nil if nothing changed", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			return nil
+		})
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, len(changeset))
+	})
+
+	t.Run("rolls back and returns the error from fn", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+
+		fnErr := errors.New("oh no")
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`insert into t values (1, 'Alice')`); err != nil {
+				return err
+			}
+			return fnErr
+		})
+		assert.Equal(t, true, errors.Is(err, fnErr))
+		assert.Equal(t, 0, len(changeset))
+
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func TestApplyChangeset(t *testing.T) {
+	// capture returns a changeset of inserting Alice with id 1 and Bob with id 2
+	capture := func(t *testing.T) []byte {
+		t.Helper()
+
+		db := open(t, sqlite.Options{})
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`insert into t values (1, 'Alice'), (2, 'Bob')`)
+			return err
+		})
+		assert.NoErr(t, err)
+		return changeset
+	}
+
+	// openTarget returns a database where Carol has id 1, conflicting with Alice
+	openTarget := func(t *testing.T) *sql.DB {
+		t.Helper()
+
+		db := open(t, sqlite.Options{})
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1, 'Carol')`)
+		assert.NoErr(t, err)
+		return db
+	}
+
+	readNames := func(t *testing.T, db *sql.DB) string {
+		t.Helper()
+
+		var names string
+		err := db.QueryRow(`select group_concat(name, ',') from (select name from t order by id)`).Scan(&names)
+		assert.NoErr(t, err)
+		return names
+	}
+
+	t.Run("resolves a conflict with replace", func(t *testing.T) {
+		changeset := capture(t)
+		db := openTarget(t)
+
+		var conflicts []sqlite.ConflictInfo
+		err := sqlite.ApplyChangeset(context.Background(), db, changeset, func(conflict sqlite.ConflictInfo) sqlite.ConflictAction {
+			conflicts = append(conflicts, conflict)
+			return sqlite.ConflictReplace
+		})
+		assert.NoErr(t, err)
+
+		assert.Equal(t, 1, len(conflicts))
+		assert.Equal(t, sqlite.ConflictConflict, conflicts[0].Type)
+		assert.Equal(t, "t", conflicts[0].Table)
+		assert.Equal(t, "INSERT", conflicts[0].Op)
+		assert.Equal(t, 2, len(conflicts[0].New))
+		assert.Equal(t, driver.Value(int64(1)), conflicts[0].New[0])
+		assert.Equal(t, driver.Value("Alice"), conflicts[0].New[1])
+		assert.Equal(t, 2, len(conflicts[0].Conflicting))
+		assert.Equal(t, driver.Value("Carol"), conflicts[0].Conflicting[1])
+
+		assert.Equal(t, "Alice,Bob", readNames(t, db))
+	})
+
+	t.Run("resolves a conflict with omit", func(t *testing.T) {
+		changeset := capture(t)
+		db := openTarget(t)
+
+		err := sqlite.ApplyChangeset(context.Background(), db, changeset, func(conflict sqlite.ConflictInfo) sqlite.ConflictAction {
+			return sqlite.ConflictOmit
+		})
+		assert.NoErr(t, err)
+
+		assert.Equal(t, "Carol,Bob", readNames(t, db))
+	})
+
+	t.Run("aborts on conflict without a resolver and applies nothing", func(t *testing.T) {
+		changeset := capture(t)
+		db := openTarget(t)
+
+		err := sqlite.ApplyChangeset(context.Background(), db, changeset, nil)
+		assert.Err(t, err)
+
+		assert.Equal(t, "Carol", readNames(t, db))
+	})
+}
+
+func TestInvertChangeset(t *testing.T) {
+	t.Run("undoes the changes when applied", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1, 'Alice'), (2, 'Bob')`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`insert into t values (3, 'Carol')`); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`update t set name = 'Alicia' where id = 1`); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`delete from t where id = 2`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		var names string
+		err = db.QueryRow(`select group_concat(name, ',') from (select name from t order by id)`).Scan(&names)
+		assert.NoErr(t, err)
+		assert.Equal(t, "Alicia,Carol", names)
+
+		inverse, err := sqlite.InvertChangeset(changeset)
+		assert.NoErr(t, err)
+
+		err = sqlite.ApplyChangeset(context.Background(), db, inverse, nil)
+		assert.NoErr(t, err)
+
+		err = db.QueryRow(`select group_concat(id || ':' || name, ',') from (select id, name from t order by id)`).Scan(&names)
+		assert.NoErr(t, err)
+		assert.Equal(t, "1:Alice,2:Bob", names)
+	})
+
+	t.Run("errors on a corrupt changeset", func(t *testing.T) {
+		_, err := sqlite.InvertChangeset([]byte("not a changeset"))
+		assert.Err(t, err)
+	})
+}
+
+func TestConcatChangesets(t *testing.T) {
+	t.Run("combines the changes of two transactions", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+
+		a, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`insert into t values (1, 'Alice'), (2, 'Bob')`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		b, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`update t set name = 'Alicia' where id = 1`); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`delete from t where id = 2`); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`insert into t values (3, 'Carol')`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		combined, err := sqlite.ConcatChangesets(a, b)
+		assert.NoErr(t, err)
+
+		target := open(t, sqlite.Options{})
+		_, err = target.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+
+		err = sqlite.ApplyChangeset(context.Background(), target, combined, nil)
+		assert.NoErr(t, err)
+
+		var names string
+		err = target.QueryRow(`select group_concat(id || ':' || name, ',') from (select id, name from t order by id)`).Scan(&names)
+		assert.NoErr(t, err)
+		assert.Equal(t, "1:Alicia,3:Carol", names)
+	})
+
+	t.Run("errors on a corrupt changeset", func(t *testing.T) {
+		_, err := sqlite.ConcatChangesets([]byte("not a changeset"), nil)
+		assert.Err(t, err)
+	})
+}
+
+func TestRebaseChangeset(t *testing.T) {
+	t.Run("rebases local changes onto remote changes so they apply without conflicts", func(t *testing.T) {
+		openWithAlice := func(t *testing.T) *sql.DB {
+			t.Helper()
+
+			db := open(t, sqlite.Options{})
+			_, err := db.Exec(`create table t (id integer primary key, name text, age int)`)
+			assert.NoErr(t, err)
+			_, err = db.Exec(`insert into t values (1, 'Alice', 30)`)
+			assert.NoErr(t, err)
+			return db
+		}
+
+		server := openWithAlice(t)
+		client := openWithAlice(t)
+
+		// The server has applied a remote change of the name
+		remote, err := sqlite.WithTxChangeset(context.Background(), server, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`update t set name = 'Alicia' where id = 1`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		// The client has changed both the name and the age locally
+		local, err := sqlite.WithTxChangeset(context.Background(), client, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`update t set name = 'Ally', age = 31 where id = 1`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		// Without rebasing, the local changeset conflicts on the server
+		err = sqlite.ApplyChangeset(context.Background(), server, local, nil)
+		assert.Err(t, err)
+
+		// The client applies the remote change, letting the remote name win
+		rebase, err := sqlite.ApplyChangesetWithRebase(context.Background(), client, remote, func(conflict sqlite.ConflictInfo) sqlite.ConflictAction {
+			return sqlite.ConflictReplace
+		})
+		assert.NoErr(t, err)
+		assert.Equal(t, true, len(rebase) > 0)
+
+		rebased, err := sqlite.RebaseChangeset(rebase, local)
+		assert.NoErr(t, err)
+
+		// The rebased changeset only has the age change, which applies on the server without conflicts
+		err = sqlite.ApplyChangeset(context.Background(), server, rebased, nil)
+		assert.NoErr(t, err)
+
+		for _, db := range []*sql.DB{server, client} {
+			var name string
+			var age int
+			err = db.QueryRow(`select name, age from t where id = 1`).Scan(&name, &age)
+			assert.NoErr(t, err)
+			assert.Equal(t, "Alicia", name)
+			assert.Equal(t, 31, age)
+		}
+	})
+}
+
+func TestApplyChangesetStream(t *testing.T) {
+	t.Run("applies a large changeset from a reader", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, v blob)`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`insert into t select i, randomblob(1000) from (with recursive n(i) as (select 1 union all select i + 1 from n where i < 5000) select i from n)`)
+			return err
+		})
+		assert.NoErr(t, err)
+		assert.Equal(t, true, len(changeset) > 4*1024*1024)
+
+		target := open(t, sqlite.Options{})
+		_, err = target.Exec(`create table t (id integer primary key, v blob)`)
+		assert.NoErr(t, err)
+
+		err = sqlite.ApplyChangesetStream(context.Background(), target, bytes.NewBuffer(changeset), nil)
+		assert.NoErr(t, err)
+
+		var count int
+		err = target.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 5000, count)
+
+		var same bool
+		err = target.QueryRow(`select v = ? from t where id = 4242`, readBlob(t, db, 4242)).Scan(&same)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, same)
+	})
+
+	t.Run("returns the error from the reader and applies nothing", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, v blob)`)
+		assert.NoErr(t, err)
+
+		readErr := errors.New("oh no")
+		r := io.MultiReader(bytes.NewReader([]byte("T")), iotest.ErrReader(readErr))
+		err = sqlite.ApplyChangesetStream(context.Background(), db, r, nil)
+		assert.Equal(t, true, errors.Is(err, readErr))
+	})
+}
+
+func readBlob(t *testing.T, db *sql.DB, id int) []byte {
+	t.Helper()
+
+	var v []byte
+	err := db.QueryRow(`select v from t where id = ?`, id).Scan(&v)
+	assert.NoErr(t, err)
+	return v
+}
+
+func TestIterateChangeset(t *testing.T) {
+	t.Run("calls fn with each operation and its values", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text, age int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1, 'Alice', 30), (2, 'Bob', 40)`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`insert into t values (3, 'Carol', 50)`); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`update t set age = 31 where id = 1`); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`delete from t where id = 2`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		ops := map[string]sqlite.ChangeOp{}
+		err = sqlite.IterateChangeset(changeset, func(op sqlite.ChangeOp) error {
+			assert.Equal(t, "t", op.Table)
+			assert.Equal(t, false, op.Indirect)
+			ops[op.Op] = op
+			return nil
+		})
+		assert.NoErr(t, err)
+		assert.Equal(t, 3, len(ops))
+
+		insert := ops["INSERT"]
+		assert.Equal(t, 0, len(insert.Old))
+		assert.Equal(t, 3, len(insert.New))
+		assert.Equal(t, driver.Value(int64(3)), insert.New[0])
+		assert.Equal(t, driver.Value("Carol"), insert.New[1])
+		assert.Equal(t, driver.Value(int64(50)), insert.New[2])
+
+		// Updates have the primary key and changed columns only
+		update := ops["UPDATE"]
+		assert.Equal(t, 3, len(update.Old))
+		assert.Equal(t, driver.Value(int64(1)), update.Old[0])
+		assert.Equal(t, nil, update.Old[1])
+		assert.Equal(t, driver.Value(int64(30)), update.Old[2])
+		assert.Equal(t, nil, update.New[0])
+		assert.Equal(t, nil, update.New[1])
+		assert.Equal(t, driver.Value(int64(31)), update.New[2])
+
+		del := ops["DELETE"]
+		assert.Equal(t, 0, len(del.New))
+		assert.Equal(t, driver.Value(int64(2)), del.Old[0])
+		assert.Equal(t, driver.Value("Bob"), del.Old[1])
+		assert.Equal(t, driver.Value(int64(40)), del.Old[2])
+	})
+
+	t.Run("stops and returns the error from fn", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text)`)
+		assert.NoErr(t, err)
+
+		changeset, err := sqlite.WithTxChangeset(context.Background(), db, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`insert into t values (1, 'Alice'), (2, 'Bob')`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		fnErr := errors.New("oh no")
+		var calls int
+		err = sqlite.IterateChangeset(changeset, func(op sqlite.ChangeOp) error {
+			calls++
+			return fnErr
+		})
+		assert.Equal(t, true, errors.Is(err, fnErr))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("errors on a corrupt changeset", func(t *testing.T) {
+		err := sqlite.IterateChangeset([]byte("not a changeset"), func(op sqlite.ChangeOp) error {
+			return nil
+		})
+		assert.Err(t, err)
+	})
+}
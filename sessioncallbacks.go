@@ -0,0 +1,24 @@
+//go:build cgo && session
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// This file contains the Go functions called from C for the session extension, like callbacks.go.
+
+//export goConflict
+func goConflict(p unsafe.Pointer, eConflict C.int, pIter *C.sqlite3_changeset_iter) C.int {
+	return callConflict(p, eConflict, pIter)
+}
+
+//export goChangesetInput
+func goChangesetInput(p unsafe.Pointer, pData unsafe.Pointer, pnData *C.int) C.int {
+	return readChangesetInput(p, pData, pnData)
+}
@@ -0,0 +1,114 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// inFlight counts the statements and transactions in progress on the connections to a database, for Shutdown.
+type inFlight struct {
+	lock     sync.Mutex
+	count    int
+	shutdown bool
+	// idle is closed when count drops to zero after shutdown has started.
+	idle chan struct{}
+	// idleClosed is set when idle is closed, because statements in transactions can still start and finish after that.
+	idleClosed bool
+}
+
+// start an operation. After shutdown has started, new operations are rejected with ErrShutdown,
+// unless inTx is true, so that transactions in progress can finish.
+func (f *inFlight) start(inTx bool) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.shutdown && !inTx {
+		return ErrShutdown
+	}
+	f.count++
+	return nil
+}
+
+// done with an operation started with start.
+func (f *inFlight) done() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.count--
+	f.closeIdleIfDone()
+}
+
+// closeIdleIfDone closes idle once, if shutdown has started and nothing is in progress. The lock must be held.
+func (f *inFlight) closeIdleIfDone() {
+	if f.shutdown && f.count == 0 && !f.idleClosed {
+		close(f.idle)
+		f.idleClosed = true
+	}
+}
+
+// stop accepting new operations, and wait until the ones in progress are done or ctx is done.
+func (f *inFlight) stop(ctx context.Context) error {
+	f.lock.Lock()
+	if !f.shutdown {
+		f.shutdown = true
+		f.idle = make(chan struct{})
+		f.closeIdleIfDone()
+	}
+	idle := f.idle
+	f.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops db from accepting new statements and transactions, which then return ErrShutdown,
+// and waits for the ones in progress to finish or ctx to be done. Statements in transactions in progress are still run.
+// It then runs PRAGMA optimize and a truncating WAL checkpoint, unless Options.ReadOnly is set,
+// and closes db. Other databases opened with the same driver are not affected.
+//
+// If ctx is done before the statements and transactions in progress finish, db is closed without the final
+// optimize and checkpoint, and the context error is returned.
+// See https://www.sqlite.org/pragma.html#pragma_optimize
+func Shutdown(ctx context.Context, db *sql.DB) error {
+	d, ok := db.Driver().(*d)
+	if !ok {
+		return errors.New("database is not using this driver")
+	}
+
+	if err := d.inFlight.stop(ctx); err != nil {
+		_ = db.Close()
+		return wrapError("error waiting for statements and transactions in progress", err)
+	}
+
+	if !d.opts.ReadOnly {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			_ = db.Close()
+			return wrapError("error getting connection", err)
+		}
+		err = withConnection(conn, func(c *connection) error {
+			if err := c.exec("pragma optimize"); err != nil {
+				return err
+			}
+			return c.exec("pragma wal_checkpoint(truncate)")
+		})
+		_ = conn.Close()
+		if err != nil {
+			_ = db.Close()
+			return wrapError("error optimizing and checkpointing", err)
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		return wrapError("error closing database", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Run("waits for a query in progress, rejects new ones, and closes the database", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (1), (2)`)
+		assert.NoErr(t, err)
+
+		// The query is in progress until its rows are closed
+		rows, err := db.Query(`select v from t`)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, rows.Next())
+
+		done := make(chan error)
+		go func() {
+			done <- sqlite.Shutdown(context.Background(), db)
+		}()
+
+		select {
+		case err := <-done:
+			t.Fatal("shutdown returned before the query was done:", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		_, err = db.Exec(`insert into t values (3)`)
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrShutdown))
+
+		var v int
+		err = rows.Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, v)
+		assert.Equal(t, true, rows.Next())
+		assert.Equal(t, false, rows.Next())
+		assert.NoErr(t, rows.Close())
+
+		select {
+		case err := <-done:
+			assert.NoErr(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("shutdown didn't return after the query was done")
+		}
+
+		err = db.Ping()
+		assert.Err(t, err)
+	})
+
+	t.Run("lets a transaction in progress finish", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+
+		done := make(chan error)
+		go func() {
+			done <- sqlite.Shutdown(context.Background(), db)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		_, err = db.Begin()
+		assert.Equal(t, true, errors.Is(err, sqlite.ErrShutdown))
+
+		_, err = tx.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+		assert.NoErr(t, tx.Commit())
+
+		assert.NoErr(t, <-done)
+	})
+
+	t.Run("closes the database and returns the context error if the context is done first", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query(`select 1`)
+		assert.NoErr(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err = sqlite.Shutdown(ctx, db)
+		assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+		assert.NoErr(t, rows.Close())
+	})
+
+	t.Run("does not affect another database opened with the same driver", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{Name: name})
+
+		db1, err := sql.Open(name, path.Join(t.TempDir(), "app.db"))
+		assert.NoErr(t, err)
+		db2, err := sql.Open(name, path.Join(t.TempDir(), "app.db"))
+		assert.NoErr(t, err)
+		defer func() {
+			_ = db2.Close()
+		}()
+
+		err = sqlite.Shutdown(context.Background(), db1)
+		assert.NoErr(t, err)
+
+		_, err = db2.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+		tx, err := db2.Begin()
+		assert.NoErr(t, err)
+		assert.NoErr(t, tx.Rollback())
+	})
+
+	t.Run("runs statements in a transaction started with begin after shutdown is done waiting", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+
+		_, err = conn.ExecContext(context.Background(), `begin`)
+		assert.NoErr(t, err)
+
+		done := make(chan error)
+		go func() {
+			done <- sqlite.Shutdown(context.Background(), db)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		// Nothing is in progress, so shutdown has stopped waiting, but the statement is in a transaction so it's run
+		_, err = conn.ExecContext(context.Background(), `insert into t values (1)`)
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `commit`)
+		assert.NoErr(t, err)
+		assert.NoErr(t, conn.Close())
+
+		select {
+		case err := <-done:
+			assert.NoErr(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("shutdown didn't return")
+		}
+	})
+}
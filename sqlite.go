@@ -3,6 +3,7 @@
 package sqlite
 
 /*
+#cgo CFLAGS: -DSQLITE_ENABLE_COLUMN_METADATA
 #include <stdlib.h>
 #include <sqlite3.h>
 
@@ -24,78 +25,60 @@ static int my_bind_blob(sqlite3_stmt *stmt, int n, void *p, int np) {
 import "C"
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 	"unsafe"
 )
 
-type JournalMode string
-
-const (
-	JournalModeDelete   = JournalMode("delete")
-	JournalModeTruncate = JournalMode("truncate")
-	JournalModePersist  = JournalMode("persist")
-	JournalModeMemory   = JournalMode("memory")
-	JournalModeWAL      = JournalMode("wal")
-	JournalModeOff      = JournalMode("off")
-)
-
-func (j JournalMode) String() string {
-	return string(j)
-}
-
-type logger interface {
-	Println(v ...any)
-}
-
-type discardLogger struct{}
-
-func (d *discardLogger) Println(...any) {}
-
-type Options struct {
-	BusyTimeout *time.Duration
-	ForeignKeys *bool
-	JournalMode JournalMode
-	Logger      logger
-	Name        string
-}
-
 func RegisterDriver(opts Options) {
-	if opts.Name == "" {
-		opts.Name = "sqlite"
-	}
-
-	if opts.Logger == nil {
-		opts.Logger = &discardLogger{}
-	}
+	opts = withDefaults(opts)
 
-	if opts.JournalMode == "" {
-		opts.JournalMode = JournalModeWAL
-	}
-
-	if opts.BusyTimeout == nil {
-		opts.BusyTimeout = ptr(5 * time.Second)
-	}
+	sql.Register(opts.Name, newDriver(opts))
+	registerEffectiveOptions(opts)
+}
 
-	if opts.ForeignKeys == nil {
-		opts.ForeignKeys = ptr(true)
+// newDriver returns a driver with opts, which must have defaults applied.
+func newDriver(opts Options) *d {
+	d := &d{opts: opts, log: opts.Logger, pragmas: openPragmas(opts), inFlight: &inFlight{}}
+	if opts.MaxWriters > 0 {
+		d.writers = make(chan struct{}, opts.MaxWriters)
 	}
-
-	sql.Register(opts.Name, &d{opts: opts, log: opts.Logger})
+	return d
 }
 
-func ptr[T any](v T) *T {
-	return &v
+// LatencyHistogram returns the Options.LatencyHistogram of the driver used by db,
+// and false if db doesn't use this driver or no histogram is set.
+func LatencyHistogram(db *sql.DB) (*Histogram, bool) {
+	d, ok := db.Driver().(*d)
+	if !ok || d.opts.LatencyHistogram == nil {
+		return nil, false
+	}
+	return d.opts.LatencyHistogram, true
 }
 
-// d satisfies driver.Driver.
+// d satisfies driver.Driver and driver.DriverContext.
 type d struct {
 	opts Options
 	log  logger
+	// pragmas are set on every new connection, in order.
+	pragmas []pragma
+	// immutable opens ReadOnly databases as immutable, for ReadOnlyStore.
+	immutable bool
+	// writers is the semaphore for Options.MaxWriters, shared by all connections.
+	writers chan struct{}
+	// inFlight counts the statements and transactions in progress on the connections to one database, for Shutdown.
+	// OpenConnector and NewConnector give each database its own.
+	inFlight *inFlight
 }
 
 // Open returns a new connection to the database.
@@ -107,76 +90,345 @@ type d struct {
 //
 // The returned connection is only used by one goroutine at a
 // time.
+//
+// The name is a file name or "file:" URI, with optional query parameters overriding the driver options,
+// such as "file:app.db?_journal_mode=wal&_busy_timeout=10000&_foreign_keys=on". See OpenConnector.
 func (d *d) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// open a new connection to the database at name, which has no driver options.
+func (d *d) open(name string) (driver.Conn, error) {
 	var cC *C.sqlite3
 
+	// The default threading mode is serialized, but we set it explicitly: https://www.sqlite.org/threadsafe.html
+	flags := C.SQLITE_OPEN_READWRITE | C.SQLITE_OPEN_CREATE | C.SQLITE_OPEN_FULLMUTEX
+	if strings.HasPrefix(name, "file:") {
+		flags |= C.SQLITE_OPEN_URI
+	}
+	if d.opts.ReadOnly {
+		flags = C.SQLITE_OPEN_READONLY | C.SQLITE_OPEN_FULLMUTEX
+
+		switch {
+		case d.immutable:
+			name = immutableURI(name)
+			flags |= C.SQLITE_OPEN_URI
+
+		case hasUnreplayableWAL(name):
+			if !d.opts.ReadOnlyImmutableFallback {
+				return nil, wrapError("error opening connection to %v", ErrUnreplayableWAL, name)
+			}
+			d.log.Println("Warning: opening", name, "as immutable, ignoring the contents of its WAL file")
+			name = immutableURI(name)
+			flags |= C.SQLITE_OPEN_URI
+		}
+	}
+
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
-	// The default threading mode is serialized, but we set it explicitly: https://www.sqlite.org/threadsafe.html
-	const flags = C.SQLITE_OPEN_READWRITE | C.SQLITE_OPEN_CREATE | C.SQLITE_OPEN_FULLMUTEX
-	if cCode := C.sqlite3_open_v2(cName, &cC, flags, nil); cCode != C.SQLITE_OK {
+	// The unix-none VFS is like the default unix VFS, but without file locking
+	var cVFS *C.char
+	if d.opts.NoLock != nil && *d.opts.NoLock && runtime.GOOS != "windows" {
+		cVFS = C.CString("unix-none")
+		defer C.free(unsafe.Pointer(cVFS))
+	}
+
+	if cCode := C.sqlite3_open_v2(cName, &cC, C.int(flags), cVFS); cCode != C.SQLITE_OK {
+		err := newError(cC, cCode)
 		if cC != nil {
 			// TODO handle return value
 			C.sqlite3_close_v2(cC)
 		}
-		return nil, wrapErrorCode("error opening connection", cCode)
+		return nil, wrapError("error opening connection", err)
+	}
+
+	c := &connection{cC: cC, opts: d.opts, writers: d.writers, inFlight: d.inFlight}
+	if d.opts.StatementHistory > 0 {
+		c.history = newStatementHistory(d.opts.StatementHistory)
 	}
 
-	c := &connection{cC: cC}
+	if err := createCollations(cC, d.opts.Name); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
 
-	pragmas := map[string]any{
-		"journal_mode": d.opts.JournalMode,
-		"busy_timeout": d.opts.BusyTimeout.Milliseconds(),
-		"foreign_keys": *d.opts.ForeignKeys,
+	if err := createCArray(cC); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	for _, p := range d.pragmas {
+		d.log.Println("Setting pragma", p.name, "to", p.value)
+		if err := c.exec("pragma %v = %v", p.name, p.value); err != nil {
+			_ = c.Close()
+			return nil, wrapError("error setting pragma %v", err, p.name)
+		}
+	}
+
+	// Some journal modes can't be enabled everywhere (for example WAL on some network file systems,
+	// or anything but memory and off for in-memory databases), and SQLite silently keeps another mode.
+	if !d.opts.ReadOnly {
+		actualJournalMode, err := c.queryString("pragma journal_mode")
+		if err != nil {
+			_ = c.Close()
+			return nil, wrapError("error reading journal mode", err)
+		}
+		if !strings.EqualFold(actualJournalMode, string(d.opts.JournalMode)) {
+			if d.opts.OnJournalFallback != nil {
+				if err := d.opts.OnJournalFallback(d.opts.JournalMode, JournalMode(actualJournalMode)); err != nil {
+					_ = c.Close()
+					return nil, wrapError("error setting journal mode %v", err, d.opts.JournalMode)
+				}
+			} else {
+				d.log.Println("Warning: journal mode", d.opts.JournalMode, "could not be set, using", actualJournalMode)
+			}
+		}
 	}
 
-	for k, v := range pragmas {
-		d.log.Println("Setting pragma", k, "to", v)
-		if err := c.exec("pragma %v = %v", k, v); err != nil {
-			return nil, wrapError("error setting pragma %v", err, k)
+	// Remember the pragma values at open, to be able to restore them in ResetSession
+	if len(d.opts.VerifyPragmasOnReset) > 0 {
+		c.pragmas = map[string]string{}
+		for _, name := range d.opts.VerifyPragmasOnReset {
+			v, err := c.queryString("pragma " + name)
+			if err != nil {
+				_ = c.Close()
+				return nil, wrapError("error reading pragma %v", err, name)
+			}
+			c.pragmas[name] = v
 		}
 	}
 
 	return c, nil
 }
 
-func wrapError(format string, err error, args ...any) error {
-	args = append(args, err)
-	return fmt.Errorf(format+": %w", args...)
+// pragma is set on every new connection.
+type pragma struct {
+	name  string
+	value any
 }
 
-func wrapErrorCode(format string, cCode C.int, args ...any) error {
-	args = append(args, errString(cCode))
-	return fmt.Errorf(format+": %w", args...)
-}
+// openPragmas returns the pragmas to set on every new connection with opts, in the order they must be set.
+func openPragmas(opts Options) []pragma {
+	values := map[string]any{
+		"journal_mode": opts.JournalMode,
+		"busy_timeout": opts.BusyTimeout.Milliseconds(),
+		"foreign_keys": *opts.ForeignKeys,
+	}
+
+	if opts.LockingMode != "" {
+		values["locking_mode"] = opts.LockingMode
+	}
+
+	if opts.CellSizeCheck != nil {
+		values["cell_size_check"] = *opts.CellSizeCheck
+	}
+
+	// There's no VFS without locking on Windows, so hold the lock instead
+	if opts.NoLock != nil && *opts.NoLock && runtime.GOOS == "windows" {
+		values["locking_mode"] = "exclusive"
+	}
 
-func errString(cCode C.int) error {
-	return errors.New(C.GoString(C.sqlite3_errstr(cCode)))
+	// The journal mode is a property of the database file, which read-only connections can't change
+	if opts.ReadOnly {
+		delete(values, "journal_mode")
+	}
+
+	// The locking mode must be set before the journal mode, for WAL mode to work without a shared-memory file
+	// in exclusive locking mode, so set pragmas in a fixed order
+	var pragmas []pragma
+	for _, name := range []string{"locking_mode", "journal_mode", "busy_timeout", "foreign_keys", "cell_size_check"} {
+		if v, ok := values[name]; ok {
+			pragmas = append(pragmas, pragma{name: name, value: v})
+		}
+	}
+	return pragmas
 }
 
 // connection is a connection to a database. It is not used concurrently
 // by multiple goroutines.
 //
 // connection is assumed to be stateful.
-// connection satisfies driver.Conn.
+// connection satisfies driver.Conn, driver.ExecerContext, driver.QueryerContext, driver.NamedValueChecker, and driver.Validator.
 type connection struct {
-	cC *C.sqlite3
+	cC   *C.sqlite3
+	opts Options
+	// pragmas are the values of Options.VerifyPragmasOnReset at open.
+	pragmas map[string]string
+	// history is the recent statements, if Options.StatementHistory is set.
+	history *statementHistory
+	// writeLockTimer fires if a write transaction is held longer than Options.WriteLockTimeout.
+	writeLockTimer *time.Timer
+	// writers is the semaphore for Options.MaxWriters, and writer whether this connection holds a slot.
+	writers chan struct{}
+	writer  bool
+	// inFlight counts the statements and transactions in progress, for Shutdown.
+	inFlight *inFlight
+	// broken is true after an error that may have left the connection in a bad state, see IsValid.
+	broken bool
+}
+
+// observe a statement that started at start and is done now, for Options.LatencyHistogram and Options.StatementHistory.
+func (c *connection) observe(query string, start time.Time) {
+	if c.opts.LatencyHistogram == nil && c.history == nil {
+		return
+	}
+	duration := time.Since(start)
+	if c.opts.LatencyHistogram != nil {
+		c.opts.LatencyHistogram.Observe(duration)
+	}
+	if c.history != nil {
+		c.history.add(StatementRecord{Query: query, Start: start, Duration: duration})
+	}
+}
+
+// withConnection calls fn with the underlying driver connection of conn, through conn.Raw.
+func withConnection(conn *sql.Conn, fn func(c *connection) error) error {
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*connection)
+		if !ok {
+			return fmt.Errorf("connection is not from this driver, but %T", driverConn)
+		}
+		return fn(c)
+	})
 }
 
 // Prepare returns a prepared statement, bound to this connection.
 // See https://www.sqlite.org/c3ref/prepare.html
 func (c *connection) Prepare(query string) (driver.Stmt, error) {
+	query = translatePlaceholders(query, c.opts.PlaceholderStyle)
+
+	if c.opts.MaxSQLLength > 0 && len(query) > c.opts.MaxSQLLength {
+		return nil, fmt.Errorf("query length %v exceeds the maximum SQL length of %v", len(query), c.opts.MaxSQLLength)
+	}
+
+	if err := c.checkAllowedStatement(query); err != nil {
+		return nil, err
+	}
+
 	cQuery := C.CString(query)
 	defer C.free(unsafe.Pointer(cQuery))
 
 	var cStatement *C.sqlite3_stmt
+	var cTail *C.char
+
+	if cCode := C.sqlite3_prepare_v2(c.cC, cQuery, C.int(len(query)+1), &cStatement, &cTail); cCode != C.SQLITE_OK {
+		return nil, c.wrapErrorCode(`error preparing statement for query "%v"`, cCode, query)
+	}
+
+	s := &statement{connection: c, query: query, cStatement: cStatement, tail: tailOf(query, cQuery, cTail)}
+	if err := c.checkReadOnlyStatement(s); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	if c.opts.WarnUnorderedQueries {
+		s.unordered = isUnorderedSelect(query)
+	}
+	return s, nil
+}
+
+// ExecContext runs query directly on the connection, so database/sql doesn't have to prepare a statement
+// and keep track of it first, which saves work for one-off statements such as DDL and pragmas.
+// Like with a prepared statement, all statements in query are run. See statement.Exec.
+func (c *connection) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s, err := c.prepare(query, len(args))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = s.Close()
+	}()
+
+	return s.ExecContext(ctx, args)
+}
+
+// QueryContext runs query directly on the connection, so database/sql doesn't have to prepare a statement
+// and keep track of it first. See statement.QueryContext.
+func (c *connection) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s, err := c.prepare(query, len(args))
+	if err != nil {
+		return nil, err
+	}
+
+	dr, err := s.QueryContext(ctx, args)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+
+	r := dr.(*rows)
+	r.owned = true
+	return r, nil
+}
+
+// prepare query like Prepare, and check that it has n parameters,
+// for running queries directly on the connection, where database/sql doesn't check the number of args.
+func (c *connection) prepare(query string, n int) (*statement, error) {
+	ds, err := c.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	s := ds.(*statement)
+	if s.NumInput() != n {
+		_ = s.Close()
+		return nil, fmt.Errorf(`expected %v arguments, got %v, for query "%v"`, s.NumInput(), n, query)
+	}
+	return s, nil
+}
+
+// checkAllowedStatement checks the leading keyword of each statement in query against Options.AllowedStatementPrefixes,
+// if set, so that no statement in query is run if any of them is not allowed.
+func (c *connection) checkAllowedStatement(query string) error {
+	if len(c.opts.AllowedStatementPrefixes) > 0 {
+		for _, keyword := range statementKeywords(query) {
+			if !containsFold(c.opts.AllowedStatementPrefixes, keyword) {
+				return fmt.Errorf(`statement type "%v" is not allowed for query "%v"`, keyword, query)
+			}
+		}
+	}
+	return nil
+}
+
+// readKeywords are the leading keywords of statements that only read, unless they're a WITH before a write.
+var readKeywords = []string{"SELECT", "VALUES", "WITH"}
 
-	if cCode := C.sqlite3_prepare_v2(c.cC, cQuery, C.int(len(query)+1), &cStatement, nil); cCode != C.SQLITE_OK {
-		return nil, wrapErrorCode(`error preparing statement for query "%v"`, cCode, query)
+// checkReadOnlyStatement checks that the prepared statement s doesn't write to the database,
+// if Options.AllowedStatementPrefixes only has readKeywords, so for example WITH ... DELETE is rejected.
+// See https://www.sqlite.org/c3ref/stmt_readonly.html
+func (c *connection) checkReadOnlyStatement(s *statement) error {
+	if len(c.opts.AllowedStatementPrefixes) == 0 {
+		return nil
+	}
+	for _, keyword := range c.opts.AllowedStatementPrefixes {
+		if !containsFold(readKeywords, keyword) {
+			return nil
+		}
+	}
+	if C.sqlite3_stmt_readonly(s.cStatement) == 0 {
+		return fmt.Errorf(`only read-only statements are allowed, for query "%v"`, s.query)
 	}
+	return nil
+}
 
-	return &statement{connection: c, query: query, cStatement: cStatement}, nil
+// tailOf returns the rest of query after the statement SQLite prepared from cQuery, a C copy of query,
+// given the tail pointer from sqlite3_prepare_v2.
+func tailOf(query string, cQuery, cTail *C.char) string {
+	if cTail == nil {
+		return ""
+	}
+	offset := int(uintptr(unsafe.Pointer(cTail)) - uintptr(unsafe.Pointer(cQuery)))
+	if offset < 0 || offset >= len(query) {
+		return ""
+	}
+	return query[offset:]
 }
 
 // Close invalidates and potentially stops any current
@@ -191,19 +443,256 @@ func (c *connection) Prepare(query string) (driver.Stmt, error) {
 // Drivers must ensure all network calls made by Close
 // do not block indefinitely (e.g. apply a timeout).
 func (c *connection) Close() error {
+	c.stopWriteLockTimer()
+	c.releaseWriter()
 	if cCode := C.sqlite3_close_v2(c.cC); cCode != C.SQLITE_OK {
-		return wrapErrorCode("error closing connection", cCode)
+		return c.wrapErrorCode("error closing connection", cCode)
 	}
 	c.cC = nil
 	return nil
 }
 
+// CheckNamedValue is called before passing arguments to the driver
+// and is called in place of any ColumnConverter. CheckNamedValue must do type
+// validation and conversion as appropriate for the driver.
+// Returning driver.ErrSkip uses the default conversion of database/sql.
+//
+// All Go integer types are bound as integers, and unsigned integers larger than math.MaxInt64 are an error.
+func (c *connection) CheckNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case Text, ZeroBlob, CArray:
+		// Keep the type, so it isn't converted by the default conversion
+		return nil
+	case *big.Float:
+		if v == nil {
+			nv.Value = nil
+			return nil
+		}
+		nv.Value = formatBigFloat(v, c.opts.BigFloatDigits)
+		return nil
+	}
+
+	if v, ok, err := convertBindValue(nv.Value); ok {
+		if err != nil {
+			return err
+		}
+		nv.Value = v
+		return nil
+	}
+
+	if v, ok, err := intValue(nv.Value); ok {
+		if err != nil {
+			return err
+		}
+		nv.Value = v
+		return nil
+	}
+
+	// sql.Null[T].Value converts V with the default conversion, which for example binds Text as BLOB,
+	// so unwrap it and check V instead
+	if v, ok := unwrapNull(nv.Value); ok {
+		nv.Value = v
+		return c.CheckNamedValue(nv)
+	}
+
+	// Valuers such as sql.Null[T] can return values that need converting, like int or Text,
+	// which the default conversion rejects, so check the value again. Nil pointers are left to the default conversion.
+	if vr, ok := nv.Value.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(vr); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return driver.ErrSkip
+		}
+		v, err := vr.Value()
+		if err != nil {
+			return err
+		}
+		nv.Value = v
+		if reflect.TypeOf(v) == reflect.TypeOf(vr) {
+			return driver.ErrSkip
+		}
+		return c.CheckNamedValue(nv)
+	}
+
+	// Maps, slices, and structs are bound as JSON text, so they can be used with SQLite's JSON functions
+	if v, ok, err := jsonValue(nv.Value); ok {
+		if err != nil {
+			return err
+		}
+		nv.Value = v
+		return nil
+	}
+
+	return driver.ErrSkip
+}
+
+// unwrapNull returns the value of v if it's an sql.Null[T], or nil if it's not valid.
+func unwrapNull(v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	if rt.Kind() != reflect.Struct || rt.PkgPath() != "database/sql" || !strings.HasPrefix(rt.Name(), "Null[") {
+		return nil, false
+	}
+	if !rv.FieldByName("Valid").Bool() {
+		return nil, true
+	}
+	return rv.FieldByName("V").Interface(), true
+}
+
+// Ping verifies a connection to the database is still alive,
+// by running Options.HealthCheckQuery.
+func (c *connection) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	stop := c.interruptOnDone(ctx)
+	err := c.exec("%s", c.opts.HealthCheckQuery)
+	stop()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return wrapError("error running health check", err)
+	}
+	return nil
+}
+
+// ResetSession is called prior to executing a query on the connection
+// if the connection has been used before. If the driver returns ErrBadConn
+// the connection is discarded.
+//
+// So that state doesn't leak between users of the connection, statements left running are reset,
+// a transaction left open, for example with BEGIN through Exec, is rolled back,
+// temporary tables, views, and triggers are dropped, and the pragmas in Options.VerifyPragmasOnReset are restored.
+// See https://www.sqlite.org/c3ref/next_stmt.html
+func (c *connection) ResetSession(ctx context.Context) error {
+	for cStatement := C.sqlite3_next_stmt(c.cC, nil); cStatement != nil; cStatement = C.sqlite3_next_stmt(c.cC, cStatement) {
+		if C.sqlite3_stmt_busy(cStatement) != 0 {
+			C.sqlite3_reset(cStatement)
+		}
+	}
+
+	if c.inTx() {
+		c.opts.Logger.Println("Rolling back transaction left open on reset")
+		if err := c.exec("rollback"); err != nil {
+			c.opts.Logger.Println("Error rolling back transaction on reset:", err)
+			return driver.ErrBadConn
+		}
+	}
+
+	drops, err := c.queryString(`select coalesce(group_concat('drop ' || type || ' if exists temp."' || replace(name, '"', '""') || '"', ';'), '') ` +
+		`from temp.sqlite_master where type in ('table', 'view', 'trigger')`)
+	if err != nil {
+		c.opts.Logger.Println("Error reading temporary objects on reset:", err)
+		return driver.ErrBadConn
+	}
+	if drops != "" {
+		if err := c.exec("%v", drops); err != nil {
+			c.opts.Logger.Println("Error dropping temporary objects on reset:", err)
+			return driver.ErrBadConn
+		}
+	}
+
+	for name, expected := range c.pragmas {
+		actual, err := c.queryString("pragma " + name)
+		if err != nil {
+			c.opts.Logger.Println("Error reading pragma", name, "on reset:", err)
+			return driver.ErrBadConn
+		}
+		if actual == expected {
+			continue
+		}
+		c.opts.Logger.Println("Restoring pragma", name, "from", actual, "to", expected)
+		if err := c.exec("pragma %v = %v", name, expected); err != nil {
+			c.opts.Logger.Println("Error restoring pragma", name, "on reset:", err)
+			return driver.ErrBadConn
+		}
+	}
+	return nil
+}
+
+// IsValid is called prior to placing the connection into the
+// connection pool. The connection will be discarded if false is returned.
+//
+// A connection is not valid after it's closed, or after an out-of-memory, I/O, or corruption error,
+// after which SQLite may not be able to roll back cleanly, so a fresh connection is safer.
+func (c *connection) IsValid() bool {
+	return c.cC != nil && !c.broken
+}
+
 // Begin starts and returns a new transaction.
 //
 // Deprecated: Drivers should implement ConnBeginTx instead (or additionally).
 func (c *connection) Begin() (driver.Tx, error) {
-	//TODO implement me
-	panic("implement Begin")
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts and returns a new transaction.
+// If the context is canceled by the user the sql package will
+// call Tx.Rollback before discarding and closing the connection.
+//
+// This must check opts.Isolation to determine if there is a set
+// isolation level. If the driver does not support a non-default
+// level and one is set or if there is a non-default isolation level
+// that is not supported, an error must be returned.
+//
+// This must also check opts.ReadOnly to determine if the read-only
+// value is true to either set the read-only transaction property if supported
+// or return an error if it is not supported.
+//
+// Transactions begin in Options.TransactionMode, or IMMEDIATE if started by Transaction retrying a DEFERRED one.
+// Read-only transactions are always DEFERRED, and set PRAGMA query_only until they end, so writes in them fail.
+// See https://www.sqlite.org/lang_transaction.html
+func (c *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelSerializable:
+	default:
+		return nil, fmt.Errorf("isolation level %v is not supported", sql.IsolationLevel(opts.Isolation))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := c.inFlight.start(false); err != nil {
+		return nil, err
+	}
+
+	mode := c.opts.TransactionMode
+	if ctxMode, ok := txModeFromContext(ctx); ok && mode == TransactionModeDeferred {
+		mode = ctxMode
+	}
+	// Read-only transactions never take the write lock, so they are always deferred
+	if opts.ReadOnly {
+		mode = TransactionModeDeferred
+	}
+	if mode != TransactionModeDeferred {
+		if err := c.acquireWriter(ctx, nil); err != nil {
+			c.inFlight.done()
+			return nil, err
+		}
+	}
+	if err := c.exec("begin %v", mode); err != nil {
+		c.inFlight.done()
+		return nil, wrapError("error beginning %v transaction", err, mode)
+	}
+
+	if opts.ReadOnly {
+		if err := c.exec("pragma query_only = 1"); err != nil {
+			_ = c.exec("rollback")
+			c.inFlight.done()
+			return nil, wrapError("error making transaction read-only", err)
+		}
+	}
+	return &tx{connection: c, readOnly: opts.ReadOnly}, nil
+}
+
+// inTx returns whether the connection is in an explicit transaction.
+// See https://www.sqlite.org/c3ref/get_autocommit.html
+func (c *connection) inTx() bool {
+	return C.sqlite3_get_autocommit(c.cC) == 0
 }
 
 // exec a query and interpolate args directly. For internal use only.
@@ -213,21 +702,142 @@ func (c *connection) exec(format string, args ...any) error {
 	cQuery := C.CString(query)
 	defer C.free(unsafe.Pointer(cQuery))
 
+	defer c.checkWriteLock()
+
 	if cCode := C.sqlite3_exec(c.cC, cQuery, nil, nil, nil); cCode != C.SQLITE_OK {
-		return wrapErrorCode(`error running query "%v"`, cCode, query)
+		return c.wrapErrorCode(`error running query "%v"`, cCode, query)
 	}
 
 	return nil
 }
 
+// queryString runs a query returning a single text value. For internal use only.
+func (c *connection) queryString(query string) (string, error) {
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	var cStatement *C.sqlite3_stmt
+	if cCode := C.sqlite3_prepare_v2(c.cC, cQuery, -1, &cStatement, nil); cCode != C.SQLITE_OK {
+		return "", c.wrapErrorCode(`error preparing statement for query "%v"`, cCode, query)
+	}
+	defer C.sqlite3_finalize(cStatement)
+
+	if cCode := C.sqlite3_step(cStatement); cCode != C.SQLITE_ROW {
+		return "", c.wrapErrorCode(`error running query "%v"`, cCode, query)
+	}
+
+	return C.GoString((*C.char)(unsafe.Pointer(C.sqlite3_column_text(cStatement, 0)))), nil
+}
+
+// declType returns the declared type of a table column, and false if the column can't be found.
+// See https://www.sqlite.org/c3ref/column_decltype.html
+func (c *connection) declType(pc paramColumn) (string, bool) {
+	query := "select * from " + quoteIdentifier(pc.table)
+	index := pc.index
+	if pc.column != "" {
+		query = "select " + quoteIdentifier(pc.column) + " from " + quoteIdentifier(pc.table)
+		index = 0
+	}
+
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	var cStatement *C.sqlite3_stmt
+	if cCode := C.sqlite3_prepare_v2(c.cC, cQuery, -1, &cStatement, nil); cCode != C.SQLITE_OK {
+		return "", false
+	}
+	defer C.sqlite3_finalize(cStatement)
+
+	if index >= int(C.sqlite3_column_count(cStatement)) {
+		return "", false
+	}
+
+	return C.GoString(C.sqlite3_column_decltype(cStatement, C.int(index))), true
+}
+
+// interruptOnDone interrupts any running statement on the connection when ctx is done,
+// until the returned stop function is called. After stop returns, no interrupt happens.
+func (c *connection) interruptOnDone(ctx context.Context) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			C.sqlite3_interrupt(c.cC)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// namedValuesToValues orders args by parameter index, for bindArgs.
+// Named args, such as from sql.Named("a", v), are bound to the parameter with that name and any of the
+// prefixes SQLite supports, such as :a, @a, or $a. Other args are bound by their position.
+// See https://www.sqlite.org/c3ref/bind_parameter_index.html
+func (s *statement) namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, max(len(args), s.NumInput()))
+	set := make([]bool, len(values))
+	for _, arg := range args {
+		idx := arg.Ordinal
+		if arg.Name != "" {
+			idx = s.parameterIndex(arg.Name)
+			if idx == 0 {
+				return nil, fmt.Errorf(`no parameter named %v in query "%v"`, arg.Name, s.query)
+			}
+		}
+		if idx < 1 || idx > len(values) {
+			return nil, fmt.Errorf(`no parameter at position %v in query "%v"`, idx, s.query)
+		}
+		if set[idx-1] {
+			return nil, fmt.Errorf(`more than one arg for the parameter at position %v in query "%v"`, idx, s.query)
+		}
+		values[idx-1] = arg.Value
+		set[idx-1] = true
+	}
+	return values, nil
+}
+
+// parameterIndex returns the index of the parameter named name with any prefix, or 0 if there is none.
+// See https://www.sqlite.org/c3ref/bind_parameter_index.html
+func (s *statement) parameterIndex(name string) int {
+	for _, prefix := range []string{":", "@", "$"} {
+		cName := C.CString(prefix + name)
+		idx := int(C.sqlite3_bind_parameter_index(s.cStatement, cName))
+		C.free(unsafe.Pointer(cName))
+		if idx > 0 {
+			return idx
+		}
+	}
+	return 0
+}
+
 // statement is a prepared statement. It is bound to a connection and not
 // used by multiple goroutines concurrently.
-// statement satisfies driver.Stmt.
+// statement satisfies driver.Stmt, driver.StmtExecContext, and driver.StmtQueryContext.
 type statement struct {
 	connection  *connection
 	query       string
 	cStatement  *C.sqlite3_stmt
 	columnNames []string
+	// timeColumns and boolColumns are the columns declared with a time type or as BOOLEAN, by index,
+	// unless Options.NoDeclTypeConversion is set.
+	timeColumns []bool
+	boolColumns []bool
+	// tail is the rest of the query after this statement, which is run for further result sets.
+	tail string
+	// paramColumns are the table columns parameters are bound to, by parameter index, computed lazily.
+	paramColumns map[int]paramColumn
+	// unordered is true if Options.WarnUnorderedQueries is set and the query is a SELECT without ORDER BY.
+	unordered bool
 }
 
 // Close closes the statement.
@@ -240,7 +850,7 @@ type statement struct {
 // See https://www.sqlite.org/c3ref/finalize.html
 func (s *statement) Close() error {
 	if cCode := C.sqlite3_finalize(s.cStatement); cCode != C.SQLITE_OK {
-		return wrapErrorCode(`error closing statement for query "%v"`, cCode, s.query)
+		return s.connection.wrapErrorCode(`error closing statement for query "%v"`, cCode, s.query)
 	}
 	return nil
 }
@@ -254,15 +864,73 @@ func (s *statement) Close() error {
 // NumInput may also return -1, if the driver doesn't know
 // its number of placeholders. In that case, the sql package
 // will not sanity check Exec or Query argument counts.
+//
+// It's the largest parameter index, so a parameter used more than once, such as ?1 or :name, takes a single arg,
+// and numbered ?NNN parameters take args by number, with a ? after them numbered one higher than the largest so far.
+// See https://www.sqlite.org/lang_expr.html#parameters
 func (s *statement) NumInput() int {
 	return int(C.sqlite3_bind_parameter_count(s.cStatement))
 }
 
+// ExecContext executes a query that doesn't return rows, such
+// as an INSERT or UPDATE.
+//
+// ExecContext must honor the context timeout and return when it is canceled.
+//
+// If ctx is done while the statement runs, the statement is interrupted and ctx.Err() returned.
+// An interrupted statement never partially applies: outside an explicit transaction, all changes made by the
+// statement are rolled back. Inside an explicit transaction, SQLite rolls back the whole transaction.
+// See https://www.sqlite.org/c3ref/interrupt.html
+func (s *statement) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	values, err := s.namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.connection.inFlight.start(s.connection.inTx()); err != nil {
+		return nil, err
+	}
+	defer s.connection.inFlight.done()
+
+	if err := s.connection.acquireWriter(ctx, s.cStatement); err != nil {
+		return nil, err
+	}
+
+	stop := s.connection.interruptOnDone(ctx)
+	result, err := s.Exec(values)
+	stop()
+
+	if err != nil && ctx.Err() != nil && isInterrupt(err) {
+		return nil, wrapError(`query "%v" interrupted and rolled back`, ctx.Err(), s.query)
+	}
+	return result, err
+}
+
+// isInterrupt reports whether err is an SQLITE_INTERRUPT error.
+func isInterrupt(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == CodeInterrupt
+}
+
 // Exec executes a query that doesn't return rows, such
 // as an INSERT or UPDATE.
 //
+// If the query has more statements after the first, such as in a script, they are run in order after it,
+// and the result is of the last one. Statements after the first can't have parameters.
+// Each statement runs on its own, so outside an explicit transaction, earlier statements stay applied
+// if a later one fails.
+//
 // Deprecated: Drivers should implement StmtExecContext instead (or additionally).
 func (s *statement) Exec(args []driver.Value) (driver.Result, error) {
+	defer s.connection.observe(s.query, time.Now())
+	defer s.connection.checkWriteLock()
+
+	s.reset()
+
 	if len(args) > 0 {
 		if err := s.bindArgs(args); err != nil {
 			return nil, wrapError(`error binding args while executing query "%v"`, err, s.query)
@@ -270,7 +938,12 @@ func (s *statement) Exec(args []driver.Value) (driver.Result, error) {
 	}
 
 	if cCode := C.sqlite3_step(s.cStatement); cCode != C.SQLITE_DONE && cCode != C.SQLITE_ROW {
-		return nil, wrapErrorCode(`error executing query "%v"`, cCode, s.query)
+		err := s.connection.wrapErrorCode(`error executing query "%v"`, cCode, s.query)
+		return nil, s.connection.checkUpgrade(s.cStatement, err)
+	}
+
+	if err := s.execTail(); err != nil {
+		return nil, err
 	}
 
 	lastInsertID := int64(C.sqlite3_last_insert_rowid(s.connection.cC))
@@ -279,26 +952,144 @@ func (s *statement) Exec(args []driver.Value) (driver.Result, error) {
 	return &result{lastInsertID: lastInsertID, rowsAffected: rowsAffected}, nil
 }
 
+// execTail runs the statements after this one in the query, in order.
+func (s *statement) execTail() error {
+	tail := s.tail
+	for {
+		next, rest, err := s.connection.prepareNext(tail)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			return nil
+		}
+		tail = rest
+
+		if err := s.connection.acquireWriter(context.Background(), next.cStatement); err != nil {
+			_ = next.Close()
+			return err
+		}
+
+		cCode := C.sqlite3_step(next.cStatement)
+		s.connection.checkWriteLock()
+		if cCode != C.SQLITE_DONE && cCode != C.SQLITE_ROW {
+			err := s.connection.wrapErrorCode(`error executing query "%v"`, cCode, next.query)
+			err = s.connection.checkUpgrade(next.cStatement, err)
+			_ = next.Close()
+			return err
+		}
+		_ = next.Close()
+	}
+}
+
+// QueryContext executes a query that may return rows, such as a
+// SELECT.
+//
+// QueryContext must honor the context timeout and return when it is canceled.
+//
+// If ctx is done while the rows are read, the query is interrupted, and Next returns the context error.
+// See https://www.sqlite.org/c3ref/interrupt.html
+func (s *statement) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := s.namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dr, err := s.Query(values)
+	if err != nil {
+		return nil, err
+	}
+
+	r := dr.(*rows)
+	r.ctx = ctx
+	r.stop = s.connection.interruptOnDone(ctx)
+	return r, nil
+}
+
 // Query executes a query that may return rows, such as a
 // SELECT.
 //
 // Deprecated: Drivers should implement StmtQueryContext instead (or additionally).
 func (s *statement) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+
+	// The query is in progress until the rows are closed
+	if err := s.connection.inFlight.start(s.connection.inTx()); err != nil {
+		return nil, err
+	}
+
+	// Writes through Query, such as INSERT ... RETURNING, also count for Options.MaxWriters
+	if err := s.connection.acquireWriter(context.Background(), s.cStatement); err != nil {
+		s.connection.inFlight.done()
+		return nil, err
+	}
+
+	s.reset()
+
 	if len(args) > 0 {
 		if err := s.bindArgs(args); err != nil {
+			s.connection.releaseWriter()
+			s.connection.inFlight.done()
 			return nil, wrapError(`error binding args while executing query "%v"`, err, s.query)
 		}
 	}
 
-	if s.columnNames == nil {
-		columnCount := int64(C.sqlite3_column_count(s.cStatement))
-		s.columnNames = make([]string, columnCount)
-		for i := range s.columnNames {
-			s.columnNames[i] = C.GoString(C.sqlite3_column_name(s.cStatement, C.int(i)))
+	s.loadColumnNames()
+
+	return &rows{statement: s, start: start, tail: s.tail}, nil
+}
+
+// loadColumnNames of the statement, if not already loaded.
+// The names are kept per statement, so each result set has the column names of its own statement.
+// See https://www.sqlite.org/c3ref/column_name.html
+func (s *statement) loadColumnNames() {
+	if s.columnNames != nil {
+		return
+	}
+	columnCount := int64(C.sqlite3_column_count(s.cStatement))
+	s.columnNames = make([]string, columnCount)
+	s.timeColumns = make([]bool, columnCount)
+	s.boolColumns = make([]bool, columnCount)
+	for i := range s.columnNames {
+		s.columnNames[i] = C.GoString(C.sqlite3_column_name(s.cStatement, C.int(i)))
+		if s.connection.opts.NoDeclTypeConversion {
+			continue
 		}
+		declType := C.GoString(C.sqlite3_column_decltype(s.cStatement, C.int(i)))
+		s.timeColumns[i] = isTimeType(declType)
+		s.boolColumns[i] = isBoolType(declType)
 	}
+}
+
+// isTimeType reports whether the declared column type is for time values.
+func isTimeType(declType string) bool {
+	switch strings.ToUpper(declType) {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return true
+	default:
+		return false
+	}
+}
+
+// isBoolType reports whether the declared column type is for bool values.
+func isBoolType(declType string) bool {
+	switch strings.ToUpper(declType) {
+	case "BOOL", "BOOLEAN":
+		return true
+	default:
+		return false
+	}
+}
 
-	return &rows{statement: s}, nil
+// reset the statement so it can be run again, for when it's reused.
+// The return code is the error of the previous run, if any, so it's ignored.
+// See https://www.sqlite.org/c3ref/reset.html
+func (s *statement) reset() {
+	C.sqlite3_reset(s.cStatement)
 }
 
 func (s *statement) bindArgs(args []driver.Value) error {
@@ -309,43 +1100,105 @@ func (s *statement) bindArgs(args []driver.Value) error {
 		switch arg := arg.(type) {
 		case nil:
 			if cCode := C.sqlite3_bind_null(s.cStatement, idx); cCode != C.SQLITE_OK {
-				return wrapErrorCode("error binding nil arg at position %v", cCode, i)
+				return s.connection.wrapErrorCode("error binding nil arg at position %v", cCode, i)
 			}
 
 		case bool:
+			if s.connection.opts.StrictBoolColumns {
+				if err := s.checkBoolColumn(int(idx)); err != nil {
+					return wrapError("error binding bool arg at position %v", err, i)
+				}
+			}
 			argAsInt := 0
 			if arg {
 				argAsInt = 1
 			}
 			if cCode := C.sqlite3_bind_int64(s.cStatement, idx, C.sqlite3_int64(argAsInt)); cCode != C.SQLITE_OK {
-				return wrapErrorCode("error binding bool arg at position %v", cCode, i)
+				return s.connection.wrapErrorCode("error binding bool arg at position %v", cCode, i)
 			}
 
 		case int64:
 			if cCode := C.sqlite3_bind_int64(s.cStatement, idx, C.sqlite3_int64(arg)); cCode != C.SQLITE_OK {
-				return wrapErrorCode("error binding int64 arg at position %v", cCode, i)
+				return s.connection.wrapErrorCode("error binding int64 arg at position %v", cCode, i)
+			}
+
+		case int, int8, int16, int32, uint, uint8, uint16, uint32, uint64:
+			v, _, err := intValue(arg)
+			if err != nil {
+				return wrapError("error binding %T arg at position %v", err, arg, i)
+			}
+			if cCode := C.sqlite3_bind_int64(s.cStatement, idx, C.sqlite3_int64(v)); cCode != C.SQLITE_OK {
+				return s.connection.wrapErrorCode("error binding %T arg at position %v", cCode, arg, i)
 			}
 
 		case float64:
 			if cCode := C.sqlite3_bind_double(s.cStatement, idx, C.double(arg)); cCode != C.SQLITE_OK {
-				return wrapErrorCode("error binding float64 arg at position %v", cCode, i)
+				return s.connection.wrapErrorCode("error binding float64 arg at position %v", cCode, i)
 			}
 
 		case []byte:
+			var cCode C.int
+			switch {
+			case arg == nil:
+				cCode = C.sqlite3_bind_null(s.cStatement, idx)
+			case len(arg) == 0:
+				// A NULL pointer binds NULL, so bind a zero-length blob explicitly for the empty slice
+				cCode = C.sqlite3_bind_zeroblob(s.cStatement, idx, 0)
+			default:
+				cCode = C.my_bind_blob(s.cStatement, idx, unsafe.Pointer(&arg[0]), C.int(len(arg)))
+			}
+			if cCode != C.SQLITE_OK {
+				return s.connection.wrapErrorCode("error binding []byte arg at position %v", cCode, i)
+			}
+
+		case Text:
 			var p *byte
 			if len(arg) > 0 {
 				p = &arg[0]
+			} else if arg != nil {
+				// A NULL pointer binds NULL, so point at something for the empty string
+				p = new(byte)
+			}
+			if cCode := C.my_bind_text(s.cStatement, idx, (*C.char)(unsafe.Pointer(p)), C.int(len(arg))); cCode != C.SQLITE_OK {
+				return s.connection.wrapErrorCode("error binding Text arg at position %v", cCode, i)
+			}
+
+		case CArray:
+			cCode, err := bindArray(s.cStatement, idx, arg)
+			if err != nil {
+				return err
 			}
-			if cCode := C.my_bind_blob(s.cStatement, idx, unsafe.Pointer(p), C.int(len(arg))); cCode != C.SQLITE_OK {
-				return wrapErrorCode("error binding []byte arg at position %v", cCode, i)
+			if cCode != C.SQLITE_OK {
+				return s.connection.wrapErrorCode("error binding CArray arg at position %v", cCode, i)
+			}
+
+		case ZeroBlob:
+			if cCode := C.sqlite3_bind_zeroblob(s.cStatement, idx, C.int(arg)); cCode != C.SQLITE_OK {
+				return s.connection.wrapErrorCode("error binding ZeroBlob arg at position %v", cCode, i)
+			}
+
+		case time.Time:
+			if s.connection.opts.TimeTruncate > 0 {
+				arg = arg.Truncate(s.connection.opts.TimeTruncate)
+			}
+			var cCode C.int
+			switch s.connection.opts.TimeEncoding {
+			case TimeEncodingUnix:
+				cCode = C.sqlite3_bind_int64(s.cStatement, idx, C.sqlite3_int64(arg.Unix()))
+			case TimeEncodingJulian:
+				cCode = C.sqlite3_bind_double(s.cStatement, idx, C.double(toJulianDay(arg)))
+			default:
+				formatted := arg.UTC().Format(s.connection.opts.TimeEncoding.layout())
+				cCode = C.my_bind_text(s.cStatement, idx, textPointer(formatted), C.int(len(formatted)))
+			}
+			if cCode != C.SQLITE_OK {
+				return s.connection.wrapErrorCode("error binding time.Time arg at position %v", cCode, i)
 			}
 
 		case string:
-			cArg := C.CString(arg)
-			cCode := C.my_bind_text(s.cStatement, idx, cArg, C.int(len(arg)))
-			C.free(unsafe.Pointer(cArg))
+			cCode := C.my_bind_text(s.cStatement, idx, textPointer(arg), C.int(len(arg)))
 			if cCode != C.SQLITE_OK {
-				return wrapErrorCode("error binding string arg at position %v", cCode, i)
+				return s.connection.wrapErrorCode("error binding string arg at position %v", cCode, i)
 			}
 
 		default:
@@ -356,11 +1209,71 @@ func (s *statement) bindArgs(args []driver.Value) error {
 	return nil
 }
 
+// textPointer returns a pointer to the bytes of s for binding with an explicit length, so embedded NUL bytes
+// are kept and no C copy is made. SQLite copies the bytes with SQLITE_TRANSIENT before the call returns.
+func textPointer(s string) *C.char {
+	p := unsafe.StringData(s)
+	if p == nil {
+		// A NULL pointer binds NULL, so point at something for the empty string
+		p = new(byte)
+	}
+	return (*C.char)(unsafe.Pointer(p))
+}
+
+// checkBoolColumn returns an error if the parameter at idx is bound to a column which is not declared
+// as BOOLEAN or an integer type.
+func (s *statement) checkBoolColumn(idx int) error {
+	if s.paramColumns == nil {
+		s.paramColumns = paramColumns(s.query)
+	}
+
+	pc, ok := s.paramColumns[idx]
+	if !ok {
+		return nil
+	}
+
+	declType, ok := s.connection.declType(pc)
+	if !ok {
+		return nil
+	}
+
+	upperDeclType := strings.ToUpper(declType)
+	if strings.Contains(upperDeclType, "INT") || strings.Contains(upperDeclType, "BOOL") {
+		return nil
+	}
+
+	column := pc.column
+	if column == "" {
+		column = strconv.Itoa(pc.index)
+	}
+	return fmt.Errorf("column %v.%v is declared as %q, not BOOLEAN or INTEGER", pc.table, column, declType)
+}
+
 // rows is an iterator over an executed query's results.
-// rows satisfies driver.Rows.
+// rows satisfies driver.Rows, driver.RowsColumnTypeScanType, driver.RowsColumnTypeDatabaseTypeName,
+// driver.RowsColumnTypeNullable, and driver.RowsNextResultSet.
 type rows struct {
 	statement *statement
-	err       error
+	// owned is true if rows must finalize statement, because it was prepared by connection.QueryContext
+	// or by rows for a later result set.
+	owned bool
+	// tail is the rest of the query after the current statement, and next and nextErr the result of preparing
+	// its first statement, for the next result set.
+	tail    string
+	next    *statement
+	nextErr error
+	err     error
+	// start is when the query started, for Options.LatencyHistogram and Options.StatementHistory.
+	start time.Time
+	// count is the number of rows returned so far.
+	count int
+	// stepped is true if the current statement has been run with Next.
+	stepped bool
+	// onRow is true if the last call to Next returned a row, so its values can be read.
+	onRow bool
+	// ctx is the context of rows from QueryContext, and stop stops interrupting the query when it's done.
+	ctx  context.Context
+	stop func()
 }
 
 // Columns returns the names of the columns. The number of
@@ -371,8 +1284,120 @@ func (r *rows) Columns() []string {
 	return r.statement.columnNames
 }
 
+// ColumnTypeDatabaseTypeName returns the database system type name. If an empty string is returned, then the type name is not supported.
+// It's the declared type of the column in upper case, such as INTEGER or DATETIME, without any length
+// or precision, so VARCHAR(10) is VARCHAR. Columns without a declared type, such as expressions, return an empty string.
+// See https://www.sqlite.org/c3ref/column_decltype.html
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	declType := C.GoString(C.sqlite3_column_decltype(r.statement.cStatement, C.int(index)))
+	declType, _, _ = strings.Cut(declType, "(")
+	return strings.ToUpper(strings.TrimSpace(declType))
+}
+
+var (
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	stringType  = reflect.TypeOf("")
+	bytesType   = reflect.TypeOf([]byte(nil))
+	boolType    = reflect.TypeOf(false)
+)
+
+// ColumnTypeScanType returns the value type that can be used to scan types into.
+// The type is based on the declared column type: time.Time for time types, bool for BOOLEAN,
+// and otherwise int64, float64, string, or []byte for INTEGER, REAL, TEXT, and BLOB affinity.
+// Columns without a declared type, such as expressions, and NUMERIC columns can hold values of any type,
+// so the type of the value in the current row is used, or any if there is no current row or the value is NULL.
+// See https://www.sqlite.org/datatype3.html#type_affinity
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	declType := C.GoString(C.sqlite3_column_decltype(r.statement.cStatement, C.int(index)))
+	switch {
+	case r.statement.timeColumns[index]:
+		return timeType
+	case r.statement.boolColumns[index]:
+		return boolType
+	}
+
+	if strings.TrimSpace(declType) != "" {
+		switch ColumnAffinity(declType) {
+		case AffinityInteger:
+			return int64Type
+		case AffinityReal:
+			return float64Type
+		case AffinityText:
+			return stringType
+		case AffinityBlob:
+			return bytesType
+		}
+	}
+
+	if !r.onRow {
+		return anyType
+	}
+	switch C.sqlite3_column_type(r.statement.cStatement, C.int(index)) {
+	case C.SQLITE_INTEGER:
+		return int64Type
+	case C.SQLITE_FLOAT:
+		return float64Type
+	case C.SQLITE_TEXT:
+		return stringType
+	case C.SQLITE_BLOB:
+		return bytesType
+	default:
+		return anyType
+	}
+}
+
+// ColumnTypeNullable returns true if it is known the column may be null,
+// or false if the column is known to be not nullable.
+// If the column nullability is unknown, ok should be false.
+//
+// Nullability is known for result columns that are table columns, from the NOT NULL constraint,
+// and INTEGER PRIMARY KEY columns are never null. For other columns, such as expressions, nullability is unknown.
+// Because NOT NULL table columns can still be NULL in queries with outer joins or compound SELECTs,
+// and in views, they're only reported as not nullable if the query has neither and mentions the table directly.
+// See https://www.sqlite.org/c3ref/table_column_metadata.html
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	cIndex := C.int(index)
+	cDatabase := C.sqlite3_column_database_name(r.statement.cStatement, cIndex)
+	cTable := C.sqlite3_column_table_name(r.statement.cStatement, cIndex)
+	cColumn := C.sqlite3_column_origin_name(r.statement.cStatement, cIndex)
+	if cTable == nil || cColumn == nil {
+		return false, false
+	}
+
+	var cDataType *C.char
+	var cNotNull, cPrimaryKey C.int
+	if cCode := C.sqlite3_table_column_metadata(r.statement.connection.cC, cDatabase, cTable, cColumn,
+		&cDataType, nil, &cNotNull, &cPrimaryKey, nil); cCode != C.SQLITE_OK {
+		return false, false
+	}
+
+	notNull := cNotNull != 0 || cPrimaryKey != 0 && strings.EqualFold(C.GoString(cDataType), "INTEGER")
+	if !notNull {
+		return true, true
+	}
+	if mayAddNulls(r.statement.query, C.GoString(cTable)) {
+		return false, false
+	}
+	return false, true
+}
+
 // Close closes the rows iterator.
 func (r *rows) Close() error {
+	if r.stop != nil {
+		r.stop()
+		r.stop = nil
+	}
+	if r.statement != nil {
+		r.statement.connection.observe(r.statement.query, r.start)
+		r.statement.connection.checkWriteLock()
+		r.statement.connection.inFlight.done()
+		r.closeOwned()
+	}
+	if r.next != nil {
+		_ = r.next.Close()
+		r.next = nil
+	}
 	r.statement = nil
 	return r.err
 }
@@ -390,7 +1415,10 @@ const maxSlice = 1<<31 - 1
 // a buffer held in dest.
 // See https://www.sqlite.org/c3ref/step.html
 func (r *rows) Next(dest []driver.Value) error {
+	r.stepped = true
 	cCode := C.sqlite3_step(r.statement.cStatement)
+	r.onRow = cCode == C.SQLITE_ROW
+	r.statement.connection.checkWriteLock()
 
 	if cCode == C.SQLITE_DONE {
 		return io.EOF
@@ -398,26 +1426,67 @@ func (r *rows) Next(dest []driver.Value) error {
 
 	// If next row is not ready
 	if cCode != C.SQLITE_ROW {
-		return wrapErrorCode(`error getting next row for query "%v"`, cCode, r.statement.query)
+		if r.ctx != nil && r.ctx.Err() != nil {
+			return wrapError(`query "%v" interrupted`, r.ctx.Err(), r.statement.query)
+		}
+		err := r.statement.connection.wrapErrorCode(`error getting next row for query "%v"`, cCode, r.statement.query)
+		return r.statement.connection.checkUpgrade(r.statement.cStatement, err)
+	}
+
+	r.count++
+	if r.count == 2 && r.statement.unordered {
+		r.statement.connection.opts.Logger.Println("Warning: query", strconv.Quote(r.statement.query),
+			"has no ORDER BY but returns more than one row, so the row order is undefined")
 	}
 
 	for i := range dest {
 		switch cT := C.sqlite3_column_type(r.statement.cStatement, C.int(i)); cT {
 		case C.SQLITE_INTEGER:
-			dest[i] = int64(C.sqlite3_column_int64(r.statement.cStatement, C.int(i)))
+			v := int64(C.sqlite3_column_int64(r.statement.cStatement, C.int(i)))
+			dest[i] = v
+			switch {
+			case r.statement.boolColumns[i]:
+				dest[i] = v != 0
+			case r.statement.timeColumns[i] && r.statement.connection.opts.TimeEncoding == TimeEncodingUnix:
+				dest[i] = time.Unix(v, 0).UTC()
+			}
 
 		case C.SQLITE_FLOAT:
-			dest[i] = float64(C.sqlite3_column_double(r.statement.cStatement, C.int(i)))
+			v := float64(C.sqlite3_column_double(r.statement.cStatement, C.int(i)))
+			dest[i] = v
+			if r.statement.timeColumns[i] && r.statement.connection.opts.TimeEncoding == TimeEncodingJulian {
+				dest[i] = fromJulianDay(v)
+			}
 
-		case C.SQLITE_BLOB, C.SQLITE_TEXT:
-			var b []byte
+		case C.SQLITE_BLOB:
+			// A zero-length blob is returned as an empty, non-nil slice, to tell it apart from NULL
+			b := []byte{}
 			n := int(C.sqlite3_column_bytes(r.statement.cStatement, C.int(i)))
 			if n > 0 {
 				p := C.sqlite3_column_blob(r.statement.cStatement, C.int(i))
-				b = (*[maxSlice]byte)(unsafe.Pointer(p))[:n]
+				// SQLite reuses the memory on the next step, so the blob is copied unless asked not to
+				if r.statement.connection.opts.ZeroCopyBlobs {
+					b = (*[maxSlice]byte)(unsafe.Pointer(p))[:n:n]
+				} else {
+					b = C.GoBytes(p, C.int(n))
+				}
 			}
 			dest[i] = b
 
+		case C.SQLITE_TEXT:
+			// The string is copied, because database/sql doesn't copy strings when scanning
+			p := C.sqlite3_column_text(r.statement.cStatement, C.int(i))
+			n := C.sqlite3_column_bytes(r.statement.cStatement, C.int(i))
+			v := C.GoStringN((*C.char)(unsafe.Pointer(p)), n)
+			dest[i] = v
+
+			// Time values in time columns are returned as time.Time, so they can be scanned as such
+			if r.statement.timeColumns[i] {
+				if t, ok := r.statement.connection.opts.TimeEncoding.parse(v); ok {
+					dest[i] = t
+				}
+			}
+
 		case C.SQLITE_NULL:
 			dest[i] = nil
 
@@ -1,9 +1,21 @@
+//go:build cgo
+
 package sqlite_test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
 	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -30,6 +42,44 @@ func TestRegisterDriver(t *testing.T) {
 	})
 }
 
+func TestEffectiveOptions(t *testing.T) {
+	t.Run("returns the options with defaults filled in", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{Name: name, JournalMode: sqlite.JournalModeTruncate})
+
+		opts, ok := sqlite.EffectiveOptions(name)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, name, opts.Name)
+		assert.Equal(t, sqlite.JournalModeTruncate, opts.JournalMode)
+		assert.Equal(t, 5*time.Second, *opts.BusyTimeout)
+		assert.Equal(t, true, *opts.ForeignKeys)
+		assert.Equal(t, "select 1", opts.HealthCheckQuery)
+		assert.Equal(t, true, opts.Logger != nil)
+	})
+
+	t.Run("returns copies of pointer options", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		noLock, cellSizeCheck := true, true
+		sqlite.RegisterDriver(sqlite.Options{Name: name, NoLock: &noLock, CellSizeCheck: &cellSizeCheck})
+
+		opts, ok := sqlite.EffectiveOptions(name)
+		assert.Equal(t, true, ok)
+		*opts.NoLock = false
+		*opts.CellSizeCheck = false
+		*opts.BusyTimeout = 0
+
+		opts, _ = sqlite.EffectiveOptions(name)
+		assert.Equal(t, true, *opts.NoLock)
+		assert.Equal(t, true, *opts.CellSizeCheck)
+		assert.Equal(t, 5*time.Second, *opts.BusyTimeout)
+	})
+
+	t.Run("returns false for unregistered driver name", func(t *testing.T) {
+		_, ok := sqlite.EffectiveOptions("nope")
+		assert.Equal(t, false, ok)
+	})
+}
+
 func TestDB_Open(t *testing.T) {
 	t.Run("sets default pragmas", func(t *testing.T) {
 		db := open(t, sqlite.Options{})
@@ -63,78 +113,1642 @@ func TestDB_Open(t *testing.T) {
 		assert.NoErr(t, err)
 		assert.Equal(t, sqlite.JournalModeTruncate.String(), actual)
 	})
+
+	t.Run("calls journal fallback hook when WAL mode does not stick", func(t *testing.T) {
+		var requested, actual sqlite.JournalMode
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{
+			Name: name,
+			OnJournalFallback: func(r, a sqlite.JournalMode) error {
+				requested, actual = r, a
+				return nil
+			},
+		})
+
+		// In-memory databases only support the memory and off journal modes
+		db, err := sql.Open(name, ":memory:")
+		assert.NoErr(t, err)
+		assert.NoErr(t, db.Ping())
+		assert.Equal(t, sqlite.JournalModeWAL, requested)
+		assert.Equal(t, sqlite.JournalModeMemory, actual)
+	})
+
+	t.Run("does not call journal fallback hook when the requested mode differs only in case", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{
+			Name:        name,
+			JournalMode: sqlite.JournalMode("WAL"),
+			OnJournalFallback: func(requested, actual sqlite.JournalMode) error {
+				return errors.New("no WAL")
+			},
+		})
+
+		db, err := sql.Open(name, path.Join(t.TempDir(), "app.db"))
+		assert.NoErr(t, err)
+		assert.NoErr(t, db.Ping())
+	})
+
+	t.Run("errors on open if journal fallback hook returns error", func(t *testing.T) {
+		name := strconv.Itoa(int(time.Now().UnixNano()))
+		sqlite.RegisterDriver(sqlite.Options{
+			Name: name,
+			OnJournalFallback: func(requested, actual sqlite.JournalMode) error {
+				return errors.New("no WAL")
+			},
+		})
+
+		db, err := sql.Open(name, ":memory:")
+		assert.NoErr(t, err)
+		assert.Err(t, db.Ping())
+	})
 }
 
-func TestDB_QueryRow(t *testing.T) {
-	t.Run("select true, 1, 1.1, 'foo', 'foo'", func(t *testing.T) {
+func TestDB_Ping(t *testing.T) {
+	t.Run("runs the default health check", func(t *testing.T) {
 		db := open(t, sqlite.Options{})
+		assert.NoErr(t, db.Ping())
+	})
 
-		var b bool
-		var i int
-		var f float64
-		var s string
-		var d []byte
-		err := db.QueryRow(`select true, 1, 1.1, 'foo', 'foo'`).Scan(&b, &i, &f, &s, &d)
+	t.Run("runs a custom health check query", func(t *testing.T) {
+		db := open(t, sqlite.Options{HealthCheckQuery: `select count(*) from required`})
+
+		err := db.Ping()
+		assert.Err(t, err)
 
+		_, err = db.Exec(`create table required (v int)`)
 		assert.NoErr(t, err)
-		assert.Equal(t, true, b)
-		assert.Equal(t, 1, i)
-		assert.Equal(t, 1.1, f)
-		assert.Equal(t, "foo", s)
-		assert.EqualBytes(t, []byte("foo"), d)
+
+		assert.NoErr(t, db.Ping())
 	})
+}
 
-	t.Run("select true, 1, 1.1, 'foo', 'foo' with args", func(t *testing.T) {
+func TestDB_Prepare(t *testing.T) {
+	t.Run("rejects queries longer than max SQL length", func(t *testing.T) {
+		db := open(t, sqlite.Options{MaxSQLLength: 10})
+
+		_, err := db.Prepare(`select 1`)
+		assert.NoErr(t, err)
+
+		_, err = db.Prepare(`select 1, 2, 3`)
+		assert.Err(t, err)
+		assert.Equal(t, "query length 14 exceeds the maximum SQL length of 10", err.Error())
+	})
+
+	t.Run("rejects statement types not in allowed statement prefixes", func(t *testing.T) {
+		db := open(t, sqlite.Options{AllowedStatementPrefixes: []string{"SELECT"}})
+
+		_, err := db.Prepare(` /* comment */ select 1`)
+		assert.NoErr(t, err)
+
+		_, err = db.Prepare(`insert into t values (1)`)
+		assert.Err(t, err)
+		assert.Equal(t, `statement type "INSERT" is not allowed for query "insert into t values (1)"`, err.Error())
+
+		_, err = db.Exec(`create table t (v int)`)
+		assert.Err(t, err)
+
+		assert.NoErr(t, db.Ping())
+	})
+
+	t.Run("rejects queries with a later statement that is not allowed", func(t *testing.T) {
+		db := open(t, sqlite.Options{AllowedStatementPrefixes: []string{"select", "create"}})
+
+		_, err := db.Exec(`create table t (v int); delete from t`)
+		assert.Err(t, err)
+		assert.Equal(t, `statement type "DELETE" is not allowed for query "create table t (v int); delete from t"`, err.Error())
+
+		var count int
+		err = db.QueryRow(`select count(*) from sqlite_schema`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("allows CASE expressions in trigger bodies", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{AllowedStatementPrefixes: []string{"CREATE"}}, `create table t (v int)`)
+
+		_, err := db.Exec(`create temp trigger tr after insert on t begin
+			select case when new.v > 0 then 1 else 0 end;
+			delete from t;
+		end`)
+		assert.NoErr(t, err)
+	})
+
+	t.Run("rejects statements after a column named trigger", func(t *testing.T) {
+		db := open(t, sqlite.Options{AllowedStatementPrefixes: []string{"CREATE"}})
+
+		_, err := db.Exec(`create table t (trigger int); delete from t`)
+		assert.Err(t, err)
+		assert.Equal(t, `statement type "DELETE" is not allowed for query "create table t (trigger int); delete from t"`, err.Error())
+	})
+
+	t.Run("rejects writes after WITH if only read statements are allowed", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		setupDB := openPath(t, sqlite.Options{}, p)
+		_, err := setupDB.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+		_, err = setupDB.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		db := openPath(t, sqlite.Options{AllowedStatementPrefixes: []string{"SELECT", "WITH"}}, p)
+
+		_, err = db.Exec(`with x as (select 1) delete from t`)
+		assert.Err(t, err)
+		assert.Equal(t, `only read-only statements are allowed, for query "with x as (select 1) delete from t"`, err.Error())
+
+		var v int
+		err = db.QueryRow(`with x as (select v from t) select v from x`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, v)
+	})
+
+	t.Run("can run a prepared statement more than once", func(t *testing.T) {
 		db := open(t, sqlite.Options{})
 
-		var b bool
-		var i int
-		var f float64
-		var s string
-		var d []byte
-		err := db.QueryRow(`select ?, ?, ?, ?, ?`, true, 1, 1.1, "foo", []byte("foo")).
-			Scan(&b, &i, &f, &s, &d)
+		_, err := db.Exec(`create table t (v int not null)`)
+		assert.NoErr(t, err)
 
+		s, err := db.Prepare(`insert into t values (?)`)
 		assert.NoErr(t, err)
-		assert.Equal(t, true, b)
-		assert.Equal(t, 1, i)
-		assert.Equal(t, 1.1, f)
-		assert.Equal(t, "foo", s)
-		assert.EqualBytes(t, []byte("foo"), d)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		for i := 0; i < 3; i++ {
+			_, err = s.Exec(i)
+			assert.NoErr(t, err)
+		}
+
+		q, err := db.Prepare(`select count(*) from t where v >= ?`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = q.Close()
+		}()
+
+		var count int
+		err = q.QueryRow(0).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 3, count)
+		err = q.QueryRow(2).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
 	})
 
-	t.Run("queries an inserted and updated row from a table", func(t *testing.T) {
+	t.Run("interrupts a prepared statement when the context is canceled", func(t *testing.T) {
+		registerTestFunctions(t)
 		db := open(t, sqlite.Options{})
 
 		_, err := db.Exec(`create table t (v int not null)`)
 		assert.NoErr(t, err)
+		_, err = db.Exec(`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t select 0 from n`)
+		assert.NoErr(t, err)
 
-		_, err = db.Exec(`insert into t values (?)`, 1)
+		s, err := db.Prepare(`update t set v = sleep_ms(?)`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		_, err = s.ExecContext(ctx, 1)
+		assert.Equal(t, true, errors.Is(err, context.Canceled))
+
+		// The statement can be run again after the interrupt
+		_, err = s.ExecContext(context.Background(), 0)
+		assert.NoErr(t, err)
+	})
+
+	t.Run("interrupts reading rows of a prepared query when the context is canceled", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{})
+
+		q, err := db.Prepare(`with recursive n(i) as (select 1 union all select i + 1 from n where i < ?) select sleep_ms(1) from n`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = q.Close()
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rows, err := q.QueryContext(ctx, 1000)
+		assert.NoErr(t, err)
+
+		var count int
+		for rows.Next() {
+			count++
+			if count == 10 {
+				cancel()
+			}
+		}
+		assert.Equal(t, true, errors.Is(rows.Err(), context.Canceled))
+		assert.Equal(t, true, count < 1000)
+		assert.NoErr(t, rows.Close())
+
+		// The statement can be run again after the interrupt
+		rows, err = q.QueryContext(context.Background(), 3)
+		assert.NoErr(t, err)
+		count = 0
+		for rows.Next() {
+			count++
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, 3, count)
+	})
+}
+
+func TestDB_ExecContext(t *testing.T) {
+	t.Run("interrupts a multi-row update on context timeout and rolls it back", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t select 0 from n`)
+		assert.NoErr(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = db.ExecContext(ctx, `update t set v = sleep_ms(1)`)
+		assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+
+		var count int
+		err = db.QueryRow(`select count(*) from t where v = 0`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1000, count)
+	})
+
+	t.Run("returns errors other than interrupts as is when the context is done", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int check (v < 0))`)
+		assert.NoErr(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = db.ExecContext(ctx, `insert into t values (sleep_ms(50))`)
+		assert.Err(t, err)
+		assert.Equal(t, false, errors.Is(err, context.DeadlineExceeded))
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeConstraintCheck, sqliteErr.ExtendedCode)
+	})
+
+	t.Run("rolls back the whole explicit transaction on interrupt", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (0), (0), (0), (0), (0), (0), (0), (0), (0), (0)`)
+		assert.NoErr(t, err)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		_, err = conn.ExecContext(context.Background(), `begin`)
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = conn.ExecContext(ctx, `update t set v = sleep_ms(10)`)
+		assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+
+		_, err = conn.ExecContext(context.Background(), `commit`)
+		assert.Err(t, err)
+
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 10, count)
+	})
+
+	t.Run("does not run if context is already canceled", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := db.ExecContext(ctx, `create table t (v int)`)
+		assert.Equal(t, true, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("runs statements with and without args directly on the connection", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.ExecContext(context.Background(), `create table t (v int)`)
+		assert.NoErr(t, err)
+
+		result, err := db.ExecContext(context.Background(), `insert into t values (?), (?)`, 1, 2)
+		assert.NoErr(t, err)
+		rowsAffected, err := result.RowsAffected()
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(2), rowsAffected)
+	})
+
+	t.Run("errors on the wrong number of args", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.ExecContext(context.Background(), `create table t (v int)`)
+		assert.NoErr(t, err)
+
+		_, err = db.ExecContext(context.Background(), `insert into t values (?, ?)`, 1)
+		assert.Err(t, err)
+		_, err = db.ExecContext(context.Background(), `insert into t values (?)`, 1, 2)
+		assert.Err(t, err)
+	})
+
+	t.Run("enforces the allowed statement prefixes and maximum SQL length", func(t *testing.T) {
+		db := open(t, sqlite.Options{AllowedStatementPrefixes: []string{"select"}, MaxSQLLength: 20})
+
+		_, err := db.ExecContext(context.Background(), `create table t (v int)`)
+		assert.Err(t, err)
+
+		_, err = db.ExecContext(context.Background(), `select 1 where 1 = 1 and 2 = 2`)
+		assert.Err(t, err)
+	})
+}
+
+func TestDB_QueryContext(t *testing.T) {
+	t.Run("interrupts a slow query on context timeout", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{})
+
+		// Make sure the connection is open, so the timeout only covers the query
+		assert.NoErr(t, db.Ping())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		rows, err := db.QueryContext(ctx, `with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) select sleep_ms(1) from n`)
 		assert.NoErr(t, err)
 
+		var count int
+		for rows.Next() {
+			count++
+		}
+		assert.Equal(t, true, errors.Is(rows.Err(), context.DeadlineExceeded))
+		assert.Equal(t, true, count < 1000)
+		assert.NoErr(t, rows.Close())
+
+		// The connection is usable after the interrupt
 		var v int
-		err = db.QueryRow(`select * from t`).Scan(&v)
+		err = db.QueryRow(`select 1`).Scan(&v)
 		assert.NoErr(t, err)
 		assert.Equal(t, 1, v)
+	})
 
-		_, err = db.Exec(`update t set v = ?`, 2)
+	t.Run("does not run if context is already canceled", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := db.QueryContext(ctx, `select 1`)
+		assert.Equal(t, true, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("errors on the wrong number of args", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.QueryContext(context.Background(), `select ?, ?`, 1)
+		assert.Err(t, err)
+	})
+}
+
+func TestDB_ResetSession(t *testing.T) {
+	t.Run("restores verified pragmas changed mid-session", func(t *testing.T) {
+		db := open(t, sqlite.Options{VerifyPragmasOnReset: []string{"foreign_keys", "cache_size"}})
+		db.SetMaxOpenConns(1)
+
+		_, err := db.Exec(`pragma foreign_keys = off`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`pragma cache_size = 42`)
 		assert.NoErr(t, err)
 
-		err = db.QueryRow(`select * from t`).Scan(&v)
+		var foreignKeys, cacheSize int
+		err = db.QueryRow(`pragma foreign_keys`).Scan(&foreignKeys)
 		assert.NoErr(t, err)
-		assert.Equal(t, 2, v)
+		assert.Equal(t, 1, foreignKeys)
+
+		err = db.QueryRow(`pragma cache_size`).Scan(&cacheSize)
+		assert.NoErr(t, err)
+		assert.Equal(t, -2000, cacheSize)
 	})
-}
 
-func open(t *testing.T, opts sqlite.Options) *sql.DB {
-	t.Helper()
+	t.Run("errors on open with unknown pragma", func(t *testing.T) {
+		db := open(t, sqlite.Options{VerifyPragmasOnReset: []string{"nope"}})
+		assert.Err(t, db.Ping())
+	})
 
-	opts.Name = strconv.Itoa(int(time.Now().UnixNano()))
+	t.Run("drops temporary tables, views, and triggers", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		db.SetMaxOpenConns(1)
 
-	sqlite.RegisterDriver(opts)
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`create temp table "my ""temp"" table" (v int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`create temp view temp_view as select 1`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`create temp trigger temp_trigger after insert on t begin select 1; end`)
+		assert.NoErr(t, err)
 
-	db, err := sql.Open(opts.Name, path.Join(t.TempDir(), "app.db"))
-	assert.NoErr(t, err)
+		var count int
+		err = db.QueryRow(`select count(*) from temp.sqlite_master`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
 
-	return db
+		err = db.QueryRow(`select count(*) from sqlite_master`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("rolls back a transaction left open", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		db.SetMaxOpenConns(1)
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `begin`)
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `insert into t values (1)`)
+		assert.NoErr(t, err)
+		assert.NoErr(t, conn.Close())
+
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+
+		// The connection works normally after the rollback
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		assert.NoErr(t, tx.Commit())
+	})
+
+	t.Run("keeps temporary tables within a connection", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		_, err = conn.ExecContext(context.Background(), `create temp table t (v int)`)
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		var count int
+		err = conn.QueryRowContext(context.Background(), `select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestDB_IsValid(t *testing.T) {
+	t.Run("keeps the connection after a regular error", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+		db.SetMaxOpenConns(1)
+
+		_, err := db.Exec(`create table t (v int not null)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (null)`)
+		assert.Err(t, err)
+
+		assert.Equal(t, 1, db.Stats().OpenConnections)
+	})
+
+	t.Run("discards the connection after a corruption error", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		opts := sqlite.Options{JournalMode: sqlite.JournalModeDelete}
+		db := openPath(t, opts, p)
+
+		_, err := db.Exec(`create table t (v blob)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t select randomblob(100) from (with recursive n(i) as (select 1 union all select i + 1 from n where i < 500) select i from n)`)
+		assert.NoErr(t, err)
+
+		var pageSize int
+		err = db.QueryRow(`select page_size from pragma_page_size`).Scan(&pageSize)
+		assert.NoErr(t, err)
+		assert.NoErr(t, db.Close())
+
+		// Overwrite the b-tree page header of page 5, a leaf page of t
+		f, err := os.OpenFile(p, os.O_RDWR, 0)
+		assert.NoErr(t, err)
+		_, err = f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, int64(pageSize*4+3))
+		assert.NoErr(t, err)
+		assert.NoErr(t, f.Close())
+
+		db = openPath(t, opts, p)
+		db.SetMaxOpenConns(1)
+		assert.NoErr(t, db.Ping())
+		assert.Equal(t, 1, db.Stats().OpenConnections)
+
+		var sum int
+		err = db.QueryRow(`select sum(length(v)) from t`).Scan(&sum)
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeCorrupt, sqliteErr.Code)
+
+		assert.Equal(t, 0, db.Stats().OpenConnections)
+	})
+}
+
+func TestDB_QueryRow(t *testing.T) {
+	t.Run("select true, 1, 1.1, 'foo', 'foo'", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var b bool
+		var i int
+		var f float64
+		var s string
+		var d []byte
+		err := db.QueryRow(`select true, 1, 1.1, 'foo', 'foo'`).Scan(&b, &i, &f, &s, &d)
+
+		assert.NoErr(t, err)
+		assert.Equal(t, true, b)
+		assert.Equal(t, 1, i)
+		assert.Equal(t, 1.1, f)
+		assert.Equal(t, "foo", s)
+		assert.EqualBytes(t, []byte("foo"), d)
+	})
+
+	t.Run("select true, 1, 1.1, 'foo', 'foo' with args", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var b bool
+		var i int
+		var f float64
+		var s string
+		var d []byte
+		err := db.QueryRow(`select ?, ?, ?, ?, ?`, true, 1, 1.1, "foo", []byte("foo")).
+			Scan(&b, &i, &f, &s, &d)
+
+		assert.NoErr(t, err)
+		assert.Equal(t, true, b)
+		assert.Equal(t, 1, i)
+		assert.Equal(t, 1.1, f)
+		assert.Equal(t, "foo", s)
+		assert.EqualBytes(t, []byte("foo"), d)
+	})
+
+	t.Run("queries an inserted and updated row from a table", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v int not null)`)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t values (?)`, 1)
+		assert.NoErr(t, err)
+
+		var v int
+		err = db.QueryRow(`select * from t`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, v)
+
+		_, err = db.Exec(`update t set v = ?`, 2)
+		assert.NoErr(t, err)
+
+		err = db.QueryRow(`select * from t`).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("binds time.Time as text truncated to the configured precision", func(t *testing.T) {
+		db := open(t, sqlite.Options{TimeTruncate: time.Millisecond})
+
+		_, err := db.Exec(`create table t (v text not null)`)
+		assert.NoErr(t, err)
+
+		v := time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC)
+		_, err = db.Exec(`insert into t values (?)`, v)
+		assert.NoErr(t, err)
+
+		var s string
+		err = db.QueryRow(`select v from t`).Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "2023-01-02T03:04:05.123Z", s)
+
+		actual, err := time.Parse(time.RFC3339Nano, s)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, v.Truncate(time.Millisecond).Equal(actual))
+
+		var count int
+		err = db.QueryRow(`select count(*) from t where v = ?`, v).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("binds time.Time in the format of datetime with TimeEncodingDateTime", func(t *testing.T) {
+		db := open(t, sqlite.Options{TimeEncoding: sqlite.TimeEncodingDateTime})
+
+		_, err := db.Exec(`create table t (v datetime not null, created datetime not null default current_timestamp)`)
+		assert.NoErr(t, err)
+
+		v := time.Now().Truncate(time.Second)
+		_, err = db.Exec(`insert into t (v) values (?)`, v)
+		assert.NoErr(t, err)
+
+		var s string
+		err = db.QueryRow(`select cast(v as text) from t`).Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, v.UTC().Format("2006-01-02 15:04:05"), s)
+
+		var same, matches bool
+		err = db.QueryRow(`select v = datetime(v), abs(unixepoch(v) - unixepoch(created)) <= 1 and v <= current_timestamp from t`).
+			Scan(&same, &matches)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, same)
+		assert.Equal(t, true, matches)
+
+		var actual time.Time
+		err = db.QueryRow(`select v from t`).Scan(&actual)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, v.Equal(actual))
+	})
+
+	t.Run("binds and scans time.Time as an integer with TimeEncodingUnix", func(t *testing.T) {
+		db := open(t, sqlite.Options{TimeEncoding: sqlite.TimeEncodingUnix})
+
+		_, err := db.Exec(`create table t (v timestamp not null)`)
+		assert.NoErr(t, err)
+
+		v := time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC)
+		_, err = db.Exec(`insert into t values (?)`, v)
+		assert.NoErr(t, err)
+
+		var typ string
+		var same bool
+		err = db.QueryRow(`select typeof(v), v = unixepoch('2023-01-02 03:04:05') from t`).Scan(&typ, &same)
+		assert.NoErr(t, err)
+		assert.Equal(t, "integer", typ)
+		assert.Equal(t, true, same)
+
+		var actual time.Time
+		err = db.QueryRow(`select v from t`).Scan(&actual)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, v.Truncate(time.Second).Equal(actual))
+	})
+
+	t.Run("binds and scans time.Time as a real with TimeEncodingJulian", func(t *testing.T) {
+		db := open(t, sqlite.Options{TimeEncoding: sqlite.TimeEncodingJulian})
+
+		_, err := db.Exec(`create table t (v datetime not null)`)
+		assert.NoErr(t, err)
+
+		v := time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC)
+		_, err = db.Exec(`insert into t values (?)`, v)
+		assert.NoErr(t, err)
+
+		var typ, formatted string
+		err = db.QueryRow(`select typeof(v), strftime('%Y-%m-%d %H:%M:%f', v) from t`).Scan(&typ, &formatted)
+		assert.NoErr(t, err)
+		assert.Equal(t, "real", typ)
+		assert.Equal(t, "2023-01-02 03:04:05.123", formatted)
+
+		var actual time.Time
+		err = db.QueryRow(`select v from t`).Scan(&actual)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, v.Truncate(time.Millisecond).Equal(actual))
+	})
+
+	t.Run("scans time.Time from SQLite's own formats with the default encoding", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, d datetime not null default current_timestamp, day date)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t (day) values ('2023-01-02')`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t (d, day) values ('2023-01-02 03:04:05.123', '2023-01-02T03:04:05')`)
+		assert.NoErr(t, err)
+
+		var d, day time.Time
+		err = db.QueryRow(`select d, day from t where id = 1`).Scan(&d, &day)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, time.Since(d) < time.Minute)
+		assert.Equal(t, true, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC).Equal(day))
+
+		err = db.QueryRow(`select d, day from t where id = 2`).Scan(&d, &day)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, time.Date(2023, 1, 2, 3, 4, 5, 123000000, time.UTC).Equal(d))
+		assert.Equal(t, true, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC).Equal(day))
+	})
+
+	t.Run("scans text as string and blobs as []byte into any", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var text, blob, empty any
+		err := db.QueryRow(`select 'foo', x'626172', ''`).Scan(&text, &blob, &empty)
+		assert.NoErr(t, err)
+		assert.Equal(t, "foo", text.(string))
+		assert.EqualBytes(t, []byte("bar"), blob.([]byte))
+		assert.Equal(t, "", empty.(string))
+	})
+
+	t.Run("binds Text as text and []byte as blob", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v)`)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t values (?), (?), (?), (?)`,
+			sqlite.Text("foo"), []byte("foo"), sqlite.Text(""), sqlite.Text(nil))
+		assert.NoErr(t, err)
+
+		var types string
+		err = db.QueryRow(`select group_concat(typeof(v), ',') from (select v from t order by rowid)`).Scan(&types)
+		assert.NoErr(t, err)
+		assert.Equal(t, "text,blob,text,null", types)
+
+		var count int
+		err = db.QueryRow(`select count(*) from t where v like 'f%'`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, count)
+
+		err = db.QueryRow(`select count(*) from t where v = 'foo'`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestDB_Exec(t *testing.T) {
+	t.Run("errors binding bool to text column with strict bool columns", func(t *testing.T) {
+		db := open(t, sqlite.Options{StrictBoolColumns: true})
+
+		_, err := db.Exec(`create table t (b boolean, i integer, s text)`)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t (b, i, s) values (?, ?, ?)`, true, true, "foo")
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t (b, i, s) values (?, ?, ?)`, true, true, true)
+		assert.Err(t, err)
+
+		_, err = db.Exec(`insert into t values (?, ?, ?)`, true, true, true)
+		assert.Err(t, err)
+
+		_, err = db.Exec(`update t set s = ? where b = ?`, true, true)
+		assert.Err(t, err)
+
+		var count int
+		err = db.QueryRow(`select count(*) from t where i = ?`, true).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("allows binding bool to text column without strict bool columns", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (s text)`)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t (s) values (?)`, true)
+		assert.NoErr(t, err)
+	})
+}
+
+func TestOptions_WarnUnorderedQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		warns bool
+	}{
+		{name: "warns for select without order by", query: `select v from t`, warns: true},
+		{name: "warns for order by only in a subquery", query: `select v from (select v from t order by v)`, warns: true},
+		{name: "does not warn for select with order by", query: `select v from t order by v`},
+		{name: "does not warn for a single row", query: `select count(*) from t`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			db := open(t, sqlite.Options{WarnUnorderedQueries: true, Logger: log.New(&b, "", 0)})
+
+			_, err := db.Exec(`create table t (v int)`)
+			assert.NoErr(t, err)
+			_, err = db.Exec(`insert into t values (1), (2)`)
+			assert.NoErr(t, err)
+
+			rows, err := db.Query(test.query)
+			assert.NoErr(t, err)
+			for rows.Next() {
+			}
+			assert.NoErr(t, rows.Err())
+			assert.NoErr(t, rows.Close())
+
+			assert.Equal(t, test.warns, strings.Contains(b.String(), "has no ORDER BY"))
+		})
+	}
+}
+
+func TestOptions_LockingMode(t *testing.T) {
+	t.Run("sets exclusive locking mode", func(t *testing.T) {
+		dir := t.TempDir()
+		db := openPath(t, sqlite.Options{LockingMode: sqlite.LockingModeExclusive}, path.Join(dir, "app.db"))
+		db.SetMaxOpenConns(1)
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		var mode string
+		err = db.QueryRow(`pragma locking_mode`).Scan(&mode)
+		assert.NoErr(t, err)
+		assert.Equal(t, sqlite.LockingModeExclusive.String(), mode)
+
+		// WAL mode in exclusive locking mode uses heap memory instead of a shared-memory file
+		_, err = os.Stat(path.Join(dir, "app.db-shm"))
+		assert.Equal(t, true, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("defaults to normal locking mode", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var mode string
+		err := db.QueryRow(`pragma locking_mode`).Scan(&mode)
+		assert.NoErr(t, err)
+		assert.Equal(t, sqlite.LockingModeNormal.String(), mode)
+	})
+}
+
+func TestOptions_CellSizeCheck(t *testing.T) {
+	t.Run("enables cell size checks and keeps normal operations working", func(t *testing.T) {
+		cellSizeCheck := true
+		db := open(t, sqlite.Options{CellSizeCheck: &cellSizeCheck})
+
+		var enabled bool
+		err := db.QueryRow(`pragma cell_size_check`).Scan(&enabled)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, enabled)
+
+		_, err = db.Exec(`create table t (v text)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t select hex(randomblob(100)) from (with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) select i from n)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`delete from t where rowid % 2 = 0`)
+		assert.NoErr(t, err)
+
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 500, count)
+
+		problems, err := sqlite.IntegrityCheck(context.Background(), db)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, len(problems))
+	})
+
+	t.Run("is disabled by default", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var enabled bool
+		err := db.QueryRow(`pragma cell_size_check`).Scan(&enabled)
+		assert.NoErr(t, err)
+		assert.Equal(t, false, enabled)
+	})
+}
+
+func TestOptions_NoLock(t *testing.T) {
+	t.Run("ignores locks held by other connections", func(t *testing.T) {
+		p := path.Join(t.TempDir(), "app.db")
+		noBusyTimeout := time.Duration(0)
+
+		locking := openPath(t, sqlite.Options{JournalMode: sqlite.JournalModeDelete, BusyTimeout: &noBusyTimeout}, p)
+		_, err := locking.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		conn, err := locking.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+		_, err = conn.ExecContext(context.Background(), `begin exclusive`)
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		// A connection with locking can't read while the other connection holds an exclusive lock
+		other := openPath(t, sqlite.Options{JournalMode: sqlite.JournalModeDelete, BusyTimeout: &noBusyTimeout}, p)
+		var count int
+		err = other.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.Err(t, err)
+
+		// Without locking, it reads the committed state of the database file
+		noLockEnabled := true
+		noLock := openPath(t, sqlite.Options{JournalMode: sqlite.JournalModeDelete, NoLock: &noLockEnabled}, p)
+		err = noLock.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func TestOptions_WriteLockTimeout(t *testing.T) {
+	t.Run("warns with statement history when a write transaction is held too long", func(t *testing.T) {
+		var l lockedLogger
+		db := open(t, sqlite.Options{WriteLockTimeout: 20 * time.Millisecond, StatementHistory: 5, Logger: &l})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		_, err = tx.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.NoErr(t, tx.Commit())
+
+		logs := l.String()
+		assert.Equal(t, true, strings.Contains(logs, "write transaction held for more than 20ms"))
+		assert.Equal(t, true, strings.Contains(logs, `"insert into t values (1)"`))
+	})
+
+	t.Run("does not warn for short write transactions", func(t *testing.T) {
+		var l lockedLogger
+		db := open(t, sqlite.Options{WriteLockTimeout: 50 * time.Millisecond, Logger: &l})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		_, err = tx.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+		assert.NoErr(t, tx.Commit())
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, false, strings.Contains(l.String(), "write transaction held"))
+	})
+}
+
+func TestOptions_MaxWriters(t *testing.T) {
+	t.Run("serializes concurrent writers without busy errors", func(t *testing.T) {
+		busyTimeout := time.Millisecond
+		db := open(t, sqlite.Options{MaxWriters: 1, BusyTimeout: &busyTimeout})
+		db.SetMaxOpenConns(20)
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`create table counter (n int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into counter values (0)`)
+		assert.NoErr(t, err)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 20*20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					if _, err := db.Exec(`insert into t values (?)`, j); err != nil {
+						errs <- err
+						continue
+					}
+					tx, err := db.Begin()
+					if err != nil {
+						errs <- err
+						continue
+					}
+					_, err = tx.Exec(`update counter set n = n + 1`)
+					if err == nil {
+						_, err = tx.Exec(`insert into t values (?)`, -j)
+					}
+					if err != nil {
+						_ = tx.Rollback()
+						errs <- err
+						continue
+					}
+					if err := tx.Commit(); err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoErr(t, err)
+		}
+
+		var count, n int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 20*20*2, count)
+		err = db.QueryRow(`select n from counter`).Scan(&n)
+		assert.NoErr(t, err)
+		assert.Equal(t, 20*20, n)
+	})
+
+	t.Run("does not limit readers", func(t *testing.T) {
+		db := open(t, sqlite.Options{MaxWriters: 1})
+
+		_, err := db.Exec(`create table t (v int)`)
+		assert.NoErr(t, err)
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		_, err = tx.Exec(`insert into t values (1)`)
+		assert.NoErr(t, err)
+
+		// The transaction holds the only writer slot, but reads on other connections still run
+		var count int
+		err = db.QueryRow(`select count(*) from t`).Scan(&count)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, count)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = db.ExecContext(ctx, `insert into t values (2)`)
+		assert.Err(t, err)
+		assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+
+		assert.NoErr(t, tx.Commit())
+
+		_, err = db.Exec(`insert into t values (2)`)
+		assert.NoErr(t, err)
+	})
+}
+
+// lockedLogger is a logger safe for concurrent use, which keeps everything logged.
+type lockedLogger struct {
+	lock sync.Mutex
+	b    strings.Builder
+}
+
+func (l *lockedLogger) Println(v ...any) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.b.WriteString(fmt.Sprintln(v...))
+}
+
+func (l *lockedLogger) String() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.b.String()
+}
+
+var registerTestFunctionsOnce sync.Once
+
+// registerTestFunctions registers SQL functions useful in tests, on all new connections:
+//   - sleep_ms(n) sleeps for n milliseconds and returns n.
+func registerTestFunctions(t *testing.T) {
+	t.Helper()
+
+	registerTestFunctionsOnce.Do(func() {
+		err := sqlite.RegisterAutoExtension(func(e *sqlite.Extension) error {
+			if err := e.CreateFunction("sleep_ms", 1, false, func(args []driver.Value) (driver.Value, error) {
+				time.Sleep(time.Duration(args[0].(int64)) * time.Millisecond)
+				return args[0], nil
+			}); err != nil {
+				return err
+			}
+			return e.CreateFunction("append_nul", 1, true, func(args []driver.Value) (driver.Value, error) {
+				return args[0].(string) + "\x00", nil
+			})
+		})
+		assert.NoErr(t, err)
+	})
+}
+
+func open(t testing.TB, opts sqlite.Options) *sql.DB {
+	t.Helper()
+
+	opts.Name = strconv.Itoa(int(time.Now().UnixNano()))
+
+	sqlite.RegisterDriver(opts)
+
+	db, err := sql.Open(opts.Name, path.Join(t.TempDir(), "app.db"))
+	assert.NoErr(t, err)
+
+	return db
+}
+
+// openWith opens a database like open, and runs queries on it, such as to create tables and insert rows.
+func openWith(t testing.TB, opts sqlite.Options, queries ...string) *sql.DB {
+	t.Helper()
+
+	db := open(t, opts)
+	for _, query := range queries {
+		_, err := db.Exec(query)
+		assert.NoErr(t, err)
+	}
+
+	return db
+}
+
+func TestOptions_PlaceholderStyle(t *testing.T) {
+	t.Run("translates dollar placeholders and binds args by number", func(t *testing.T) {
+		db := open(t, sqlite.Options{PlaceholderStyle: sqlite.PlaceholderStyleDollar})
+
+		_, err := db.Exec(`create table t (a text, b int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t (b, a) values ($2, $1)`, "foo", 42)
+		assert.NoErr(t, err)
+
+		var a string
+		var b int
+		err = db.QueryRow(`select a, b from t where b = $1 and a = $2`, 42, "foo").Scan(&a, &b)
+		assert.NoErr(t, err)
+		assert.Equal(t, "foo", a)
+		assert.Equal(t, 42, b)
+
+		var s string
+		err = db.QueryRow(`select '$1' || $1 || "a" from t -- $2`, "bar").Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "$1barfoo", s)
+	})
+
+	t.Run("translates @p placeholders", func(t *testing.T) {
+		db := open(t, sqlite.Options{PlaceholderStyle: sqlite.PlaceholderStyleAtP})
+
+		var s string
+		err := db.QueryRow(`select @p2 || @P1 || @p2`, "a", "b").Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "bab", s)
+	})
+
+	t.Run("leaves dollar placeholders as SQLite's named parameters without a style", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		// Named parameters are numbered by first occurrence, so $2 gets the first argument
+		var s string
+		err := db.QueryRow(`select $2 || $1`, "a", "b").Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "ab", s)
+	})
+}
+
+func TestNamedParameters(t *testing.T) {
+	t.Run("binds named args to :name, @name, and $name parameters", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (a text, b int, c real)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t (a, b, c) values (:a, @b, $c)`, sql.Named("c", 1.5), sql.Named("a", "foo"), sql.Named("b", 42))
+		assert.NoErr(t, err)
+
+		var a string
+		var b int
+		var c float64
+		err = db.QueryRow(`select a, b, c from t where b = :b and a = :a`, sql.Named("a", "foo"), sql.Named("b", 42)).Scan(&a, &b, &c)
+		assert.NoErr(t, err)
+		assert.Equal(t, "foo", a)
+		assert.Equal(t, 42, b)
+		assert.Equal(t, 1.5, c)
+	})
+
+	t.Run("binds a named arg used more than once", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var s string
+		err := db.QueryRow(`select :v || :v`, sql.Named("v", "ab")).Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "abab", s)
+	})
+
+	t.Run("binds named args in prepared statements", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		q, err := db.Prepare(`select :a - :b`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = q.Close()
+		}()
+
+		var v int
+		err = q.QueryRow(sql.Named("b", 1), sql.Named("a", 3)).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("errors on a named arg without a matching parameter", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err := db.QueryRow(`select :a`, sql.Named("b", 1)).Scan(&v)
+		assert.Err(t, err)
+	})
+}
+
+func TestRows_ColumnTypeScanType(t *testing.T) {
+	t.Run("returns the Go type for the declared column type", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (i integer, r real, s text, b blob, d datetime)`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select i, r, s, b, d from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		types, err := rows.ColumnTypes()
+		assert.NoErr(t, err)
+		assert.Equal(t, 5, len(types))
+		assert.Equal(t, "int64", types[0].ScanType().String())
+		assert.Equal(t, "float64", types[1].ScanType().String())
+		assert.Equal(t, "string", types[2].ScanType().String())
+		assert.Equal(t, "[]uint8", types[3].ScanType().String())
+		assert.Equal(t, "time.Time", types[4].ScanType().String())
+	})
+
+	t.Run("returns the type of the current value for expressions", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query(`select 1, 1.5, 'a', x'01', null`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		types, err := rows.ColumnTypes()
+		assert.NoErr(t, err)
+		assert.Equal(t, "interface {}", types[0].ScanType().String())
+
+		assert.Equal(t, true, rows.Next())
+		types, err = rows.ColumnTypes()
+		assert.NoErr(t, err)
+		assert.Equal(t, "int64", types[0].ScanType().String())
+		assert.Equal(t, "float64", types[1].ScanType().String())
+		assert.Equal(t, "string", types[2].ScanType().String())
+		assert.Equal(t, "[]uint8", types[3].ScanType().String())
+		assert.Equal(t, "interface {}", types[4].ScanType().String())
+	})
+}
+
+func TestRows_ColumnTypeDatabaseTypeName(t *testing.T) {
+	t.Run("returns the declared column type without length", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (i integer, s varchar(10), d datetime, n decimal(10, 2), b blob, c)`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select i, s, d, n, b, c, 1 from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		types, err := rows.ColumnTypes()
+		assert.NoErr(t, err)
+		expected := []string{"INTEGER", "VARCHAR", "DATETIME", "DECIMAL", "BLOB", "", ""}
+		assert.Equal(t, len(expected), len(types))
+		for i := range expected {
+			assert.Equal(t, expected[i], types[i].DatabaseTypeName())
+		}
+	})
+}
+
+func TestRows_ColumnTypeNullable(t *testing.T) {
+	t.Run("returns nullability of table columns and unknown for expressions", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, a text not null, b text)`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select id, a, b as c, 1 from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		types, err := rows.ColumnTypes()
+		assert.NoErr(t, err)
+
+		expected := []struct{ nullable, ok bool }{{false, true}, {false, true}, {true, true}, {false, false}}
+		assert.Equal(t, len(expected), len(types))
+		for i, e := range expected {
+			nullable, ok := types[i].Nullable()
+			assert.Equal(t, e.ok, ok)
+			assert.Equal(t, e.nullable, nullable)
+		}
+	})
+
+	t.Run("returns unknown for not null columns that can still be null", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (id integer primary key, a text not null)`,
+			`create table u (id integer primary key, t_id int, b text not null)`,
+			`create view v as select t.a, u.b from t left join u on u.t_id = t.id`)
+
+		for _, query := range []string{
+			`select t.a, u.b from t left join u on u.t_id = t.id`,
+			`select a, b from v`,
+			`select a, a from t union all select null, null`,
+		} {
+			rows, err := db.Query(query)
+			assert.NoErr(t, err)
+
+			types, err := rows.ColumnTypes()
+			assert.NoErr(t, err)
+			for _, ct := range types {
+				_, ok := ct.Nullable()
+				assert.Equal(t, false, ok)
+			}
+			assert.NoErr(t, rows.Close())
+		}
+	})
+}
+
+func TestNumberedParameters(t *testing.T) {
+	t.Run("binds args to ?NNN parameters by number", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err := db.QueryRow(`select ?2 - ?1`, 1, 3).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("binds an arg to a parameter used more than once", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (a int, b int)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t (a, b) values (?1, ?1 * ?2)`, 3, 2)
+		assert.NoErr(t, err)
+
+		var a, b int
+		err = db.QueryRow(`select a, b from t where a = ?1 or b = ?1`, 3).Scan(&a, &b)
+		assert.NoErr(t, err)
+		assert.Equal(t, 3, a)
+		assert.Equal(t, 6, b)
+	})
+
+	t.Run("numbers a ? after a ?NNN parameter after it", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var s string
+		err := db.QueryRow(`select ?2 || ?1 || ?`, "a", "b", "c").Scan(&s)
+		assert.NoErr(t, err)
+		assert.Equal(t, "bac", s)
+	})
+
+	t.Run("binds numbered parameters in prepared statements and directly on the connection", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		q, err := db.Prepare(`select ?1 * ?1`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = q.Close()
+		}()
+
+		var v int
+		err = q.QueryRow(4).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 16, v)
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+		err = conn.QueryRowContext(context.Background(), `select ?1 * ?1`, 5).Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, 25, v)
+	})
+
+	t.Run("errors on the wrong number of args", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err := db.QueryRow(`select ?1 + ?1`, 1, 2).Scan(&v)
+		assert.Err(t, err)
+	})
+}
+
+func TestOptions_NoDeclTypeConversion(t *testing.T) {
+	t.Run("scans BOOLEAN columns as bool and time columns as time.Time by default", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (b boolean, d datetime, i int)`)
+		assert.NoErr(t, err)
+		v := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+		_, err = db.Exec(`insert into t values (?, ?, ?)`, true, v, 1)
+		assert.NoErr(t, err)
+
+		var b, d, i any
+		err = db.QueryRow(`select b, d, i from t`).Scan(&b, &d, &i)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, b.(bool))
+		assert.Equal(t, true, v.Equal(d.(time.Time)))
+		assert.Equal(t, int64(1), i.(int64))
+
+		rows, err := db.Query(`select b from t`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+		types, err := rows.ColumnTypes()
+		assert.NoErr(t, err)
+		assert.Equal(t, "bool", types[0].ScanType().String())
+	})
+
+	t.Run("scans values as their storage class if set", func(t *testing.T) {
+		db := open(t, sqlite.Options{NoDeclTypeConversion: true})
+
+		_, err := db.Exec(`create table t (b boolean, d datetime)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (?, ?)`, true, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+		assert.NoErr(t, err)
+
+		var b, d any
+		err = db.QueryRow(`select b, d from t`).Scan(&b, &d)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1), b.(int64))
+		assert.Equal(t, "2023-01-02T03:04:05Z", d.(string))
+	})
+}
+
+func TestOptions_ZeroCopyBlobs(t *testing.T) {
+	// readBlobs reads all rows of query through the driver directly, keeping the values from Next across rows
+	readBlobs := func(t *testing.T, db *sql.DB, query string) [][]byte {
+		t.Helper()
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		var blobs [][]byte
+		err = conn.Raw(func(driverConn any) error {
+			rows, err := driverConn.(driver.QueryerContext).QueryContext(context.Background(), query, nil)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = rows.Close()
+			}()
+
+			dest := make([]driver.Value, 1)
+			for {
+				if err := rows.Next(dest); err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return err
+				}
+				blobs = append(blobs, dest[0].([]byte))
+			}
+		})
+		assert.NoErr(t, err)
+		return blobs
+	}
+
+	t.Run("copies blobs by default, so they survive the next row", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v blob not null)`)
+		assert.NoErr(t, err)
+		for _, v := range []string{"aaaa", "bbbb", "cccc"} {
+			_, err = db.Exec(`insert into t values (?)`, []byte(v))
+			assert.NoErr(t, err)
+		}
+
+		blobs := readBlobs(t, db, `select v from t order by rowid`)
+		assert.Equal(t, 3, len(blobs))
+		assert.EqualBytes(t, []byte("aaaa"), blobs[0])
+		assert.EqualBytes(t, []byte("bbbb"), blobs[1])
+		assert.EqualBytes(t, []byte("cccc"), blobs[2])
+	})
+
+	t.Run("returns views of blobs if set, which are copied when scanning", func(t *testing.T) {
+		db := open(t, sqlite.Options{ZeroCopyBlobs: true})
+
+		_, err := db.Exec(`create table t (v blob not null)`)
+		assert.NoErr(t, err)
+		for _, v := range []string{"aaaa", "bbbb"} {
+			_, err = db.Exec(`insert into t values (?)`, []byte(v))
+			assert.NoErr(t, err)
+		}
+
+		rows, err := db.Query(`select v from t order by rowid`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var values [][]byte
+		for rows.Next() {
+			var v []byte
+			assert.NoErr(t, rows.Scan(&v))
+			values = append(values, v)
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, 2, len(values))
+		assert.EqualBytes(t, []byte("aaaa"), values[0])
+		assert.EqualBytes(t, []byte("bbbb"), values[1])
+	})
+}
+
+func TestDB_Exec_nulBytes(t *testing.T) {
+	t.Run("round-trips strings with embedded NUL bytes", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v string
+		var n int
+		err := db.QueryRow(`select ?, length(cast(? as blob))`, "a\x00b\x00", "a\x00b\x00").Scan(&v, &n)
+		assert.NoErr(t, err)
+		assert.Equal(t, "a\x00b\x00", v)
+		assert.Equal(t, 4, n)
+	})
+
+	t.Run("binds the empty string as text, not NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var typ string
+		err := db.QueryRow(`select typeof(?)`, "").Scan(&typ)
+		assert.NoErr(t, err)
+		assert.Equal(t, "text", typ)
+	})
+
+	t.Run("returns strings with embedded NUL bytes from functions", func(t *testing.T) {
+		registerTestFunctions(t)
+		db := open(t, sqlite.Options{})
+
+		var v string
+		err := db.QueryRow(`select append_nul(?)`, "a\x00b").Scan(&v)
+		assert.NoErr(t, err)
+		assert.Equal(t, "a\x00b\x00", v)
+	})
+}
+
+func FuzzDB_QueryRow_roundTrip(f *testing.F) {
+	db := open(f, sqlite.Options{})
+
+	for _, s := range []string{"", "a", "a\x00b", "\x00", "æøå", "\xff\xfe"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		var text string
+		var blob []byte
+		err := db.QueryRow(`select ?, ?`, s, []byte(s)).Scan(&text, &blob)
+		assert.NoErr(t, err)
+		assert.Equal(t, s, text)
+		assert.EqualBytes(t, []byte(s), blob)
+		assert.Equal(t, true, blob != nil)
+	})
+}
+
+func TestDB_QueryRow_emptyBlob(t *testing.T) {
+	t.Run("binds an empty slice as a zero-length blob and nil as NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var emptyType, nilType string
+		err := db.QueryRow(`select typeof(?), typeof(?)`, []byte{}, []byte(nil)).Scan(&emptyType, &nilType)
+		assert.NoErr(t, err)
+		assert.Equal(t, "blob", emptyType)
+		assert.Equal(t, "null", nilType)
+	})
+
+	t.Run("round-trips an empty blob and NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v blob)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (?), (?)`, []byte{}, []byte(nil))
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select v from t order by rowid`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var values [][]byte
+		for rows.Next() {
+			var v []byte
+			assert.NoErr(t, rows.Scan(&v))
+			values = append(values, v)
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, 2, len(values))
+		assert.Equal(t, true, values[0] != nil)
+		assert.Equal(t, 0, len(values[0]))
+		assert.Equal(t, true, values[1] == nil)
+	})
+
+	t.Run("scans an empty blob into any as an empty slice", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v any
+		err := db.QueryRow(`select x''`).Scan(&v)
+		assert.NoErr(t, err)
+		b, ok := v.([]byte)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, true, b != nil)
+		assert.Equal(t, 0, len(b))
+	})
 }
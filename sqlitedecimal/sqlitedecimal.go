@@ -0,0 +1,68 @@
+//go:build decimal
+
+// Package sqlitedecimal integrates github.com/shopspring/decimal with the sqlite driver, so decimal.Decimal values
+// round-trip exactly as text. It's behind the decimal build tag, so the dependency is only compiled in when needed:
+//
+//	go build -tags decimal
+//
+// Importing the package registers a bind converter for decimal.Decimal. Store decimals in TEXT columns,
+// because columns with NUMERIC or REAL affinity convert them to floating point and lose precision.
+package sqlitedecimal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/maragudk/sqlite"
+)
+
+func init() {
+	sqlite.RegisterBindConverter(func(d decimal.Decimal) (driver.Value, error) {
+		return d.String(), nil
+	})
+	sqlite.RegisterBindConverter(func(d decimal.NullDecimal) (driver.Value, error) {
+		if !d.Valid {
+			return nil, nil
+		}
+		return d.Decimal.String(), nil
+	})
+}
+
+// Scan returns a scanner that parses a column value into d exactly, for use with sql.Rows.Scan.
+// Unlike decimal.Decimal's own Scan, it fails on REAL values, because they have already lost precision.
+func Scan(d *decimal.Decimal) sql.Scanner {
+	return scanner{d: d}
+}
+
+type scanner struct {
+	d *decimal.Decimal
+}
+
+// Scan satisfies sql.Scanner.
+func (s scanner) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		return s.parse(string(src))
+	case string:
+		return s.parse(src)
+	case int64:
+		*s.d = decimal.NewFromInt(src)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into *decimal.Decimal")
+	default:
+		return fmt.Errorf("cannot scan %T into *decimal.Decimal exactly", src)
+	}
+	return nil
+}
+
+func (s scanner) parse(v string) error {
+	d, err := decimal.NewFromString(v)
+	if err != nil {
+		return fmt.Errorf("error parsing %q as decimal: %w", v, err)
+	}
+	*s.d = d
+	return nil
+}
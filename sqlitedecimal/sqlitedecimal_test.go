@@ -0,0 +1,70 @@
+//go:build decimal && cgo
+
+package sqlitedecimal_test
+
+import (
+	"database/sql"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+	"github.com/maragudk/sqlite/sqlitedecimal"
+)
+
+func TestScan(t *testing.T) {
+	t.Run("round-trips a decimal with many fractional digits through a text column", func(t *testing.T) {
+		db := open(t)
+
+		_, err := db.Exec(`create table t (v text not null)`)
+		assert.NoErr(t, err)
+
+		expected, err := decimal.NewFromString("12345678901234567890.123456789012345678901234567890")
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`insert into t values (?)`, expected)
+		assert.NoErr(t, err)
+
+		var stored string
+		err = db.QueryRow(`select v from t`).Scan(&stored)
+		assert.NoErr(t, err)
+		assert.Equal(t, "12345678901234567890.12345678901234567890123456789", stored)
+
+		var actual decimal.Decimal
+		err = db.QueryRow(`select v from t`).Scan(sqlitedecimal.Scan(&actual))
+		assert.NoErr(t, err)
+		assert.Equal(t, true, expected.Equal(actual))
+	})
+
+	t.Run("binds a null decimal as NULL", func(t *testing.T) {
+		db := open(t)
+
+		var isNull bool
+		err := db.QueryRow(`select ? is null`, decimal.NullDecimal{}).Scan(&isNull)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, isNull)
+	})
+
+	t.Run("errors on scanning a REAL", func(t *testing.T) {
+		db := open(t)
+
+		var actual decimal.Decimal
+		err := db.QueryRow(`select 1.5`).Scan(sqlitedecimal.Scan(&actual))
+		assert.Err(t, err)
+	})
+}
+
+func open(t *testing.T) *sql.DB {
+	t.Helper()
+
+	name := strconv.Itoa(int(time.Now().UnixNano()))
+	sqlite.RegisterDriver(sqlite.Options{Name: name})
+
+	db, err := sql.Open(name, path.Join(t.TempDir(), "app.db"))
+	assert.NoErr(t, err)
+	return db
+}
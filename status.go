@@ -0,0 +1,23 @@
+package sqlite
+
+// StatusOp is a process-wide status parameter for Status.
+// The values are the ones from sqlite3.h, so they're also available without cgo.
+// See https://www.sqlite.org/c3ref/c_status_malloc_count.html
+type StatusOp int
+
+const (
+	// StatusMemoryUsed is the number of bytes of memory currently allocated by SQLite.
+	StatusMemoryUsed = StatusOp(0)
+	// StatusMallocSize is the size in bytes of the largest memory allocation. Only the high-water value is meaningful.
+	StatusMallocSize = StatusOp(5)
+	// StatusMallocCount is the number of separate memory allocations currently outstanding.
+	StatusMallocCount = StatusOp(9)
+	// StatusPageCacheUsed is the number of pages used out of the page cache memory configured with SQLITE_CONFIG_PAGECACHE.
+	StatusPageCacheUsed = StatusOp(1)
+	// StatusPageCacheOverflow is the number of bytes of page cache allocations that didn't fit in SQLITE_CONFIG_PAGECACHE memory.
+	StatusPageCacheOverflow = StatusOp(2)
+	// StatusPageCacheSize is the size in bytes of the largest page cache allocation. Only the high-water value is meaningful.
+	StatusPageCacheSize = StatusOp(7)
+	// StatusParserStack is the deepest parser stack. Only the high-water value is meaningful.
+	StatusParserStack = StatusOp(6)
+)
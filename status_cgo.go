@@ -0,0 +1,61 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"database/sql"
+)
+
+// Status returns the current and high-water values of a process-wide status parameter,
+// and resets the high-water value to the current value if reset is true.
+// Like MemoryHighwater, the values cover all connections of all databases in the process.
+// See https://www.sqlite.org/c3ref/status.html
+func Status(op StatusOp, reset bool) (current, highwater int64, err error) {
+	var cCurrent, cHighwater C.sqlite3_int64
+	var cReset C.int
+	if reset {
+		cReset = 1
+	}
+	if cCode := C.sqlite3_status64(C.int(op), &cCurrent, &cHighwater, cReset); cCode != C.SQLITE_OK {
+		return 0, 0, wrapErrorCode("error getting status %v", cCode, int(op))
+	}
+	return int64(cCurrent), int64(cHighwater), nil
+}
+
+// MemoryHighwater returns the maximum number of bytes of memory SQLite has had allocated at any time,
+// and resets the high-water mark to the current usage if reset is true.
+//
+// Note that the high-water mark is process-wide and not per connection, so it includes memory used by all
+// connections of all databases. The conn is used to make sure the driver is in use.
+// See https://www.sqlite.org/c3ref/memory_highwater.html
+func MemoryHighwater(conn *sql.Conn, reset bool) (int64, error) {
+	var highwater int64
+	err := withConnection(conn, func(c *connection) error {
+		var cReset C.int
+		if reset {
+			cReset = 1
+		}
+		highwater = int64(C.sqlite3_memory_highwater(cReset))
+		return nil
+	})
+	return highwater, err
+}
+
+// StatementHistory returns the most recent statements run on conn, oldest first, with their durations.
+// It returns nil if Options.StatementHistory is not set. For queries returning rows, the duration is until the rows are closed,
+// and statements that fail while executing are included.
+func StatementHistory(conn *sql.Conn) ([]StatementRecord, error) {
+	var records []StatementRecord
+	err := withConnection(conn, func(c *connection) error {
+		if c.history != nil {
+			records = c.history.list()
+		}
+		return nil
+	})
+	return records, err
+}
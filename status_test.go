@@ -0,0 +1,111 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestMemoryHighwater(t *testing.T) {
+	t.Run("reports a positive high-water mark after a memory-heavy query", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		_, err = conn.ExecContext(context.Background(), `create table t (v blob)`)
+		assert.NoErr(t, err)
+		_, err = conn.ExecContext(context.Background(), `insert into t values (randomblob(10 * 1024 * 1024))`)
+		assert.NoErr(t, err)
+
+		before, err := sqlite.MemoryHighwater(conn, true)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, before > 10*1024*1024)
+
+		// After a reset, the high-water mark is the current usage, which is lower once the blob is freed.
+		// Other connections in the process may still use memory, so it's not compared to the blob size.
+		after, err := sqlite.MemoryHighwater(conn, false)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, after > 0)
+		assert.Equal(t, true, after < before)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("reports positive memory used after activity", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v blob)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t values (randomblob(1024 * 1024))`)
+		assert.NoErr(t, err)
+
+		current, highwater, err := sqlite.Status(sqlite.StatusMemoryUsed, false)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, current > 0)
+		assert.Equal(t, true, highwater >= current)
+
+		count, _, err := sqlite.Status(sqlite.StatusMallocCount, false)
+		assert.NoErr(t, err)
+		assert.Equal(t, true, count > 0)
+	})
+
+	t.Run("errors on unknown status op", func(t *testing.T) {
+		_, _, err := sqlite.Status(sqlite.StatusOp(-1), false)
+		assert.Err(t, err)
+	})
+}
+
+func TestStatementHistory(t *testing.T) {
+	t.Run("keeps the most recent statements in order", func(t *testing.T) {
+		db := open(t, sqlite.Options{StatementHistory: 3})
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		queries := []string{`create table t (v int)`, `insert into t values (1)`, `insert into t values (2)`, `select v from t`, `delete from t`}
+		for _, query := range queries {
+			rows, err := conn.QueryContext(context.Background(), query)
+			assert.NoErr(t, err)
+			for rows.Next() {
+			}
+			assert.NoErr(t, rows.Close())
+		}
+
+		history, err := sqlite.StatementHistory(conn)
+		assert.NoErr(t, err)
+		assert.Equal(t, 3, len(history))
+		for i, r := range history {
+			assert.Equal(t, queries[i+2], r.Query)
+			assert.Equal(t, true, r.Duration > 0)
+			assert.Equal(t, false, r.Start.IsZero())
+		}
+	})
+
+	t.Run("returns nil without statement history", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		conn, err := db.Conn(context.Background())
+		assert.NoErr(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		_, err = conn.ExecContext(context.Background(), `select 1`)
+		assert.NoErr(t, err)
+
+		history, err := sqlite.StatementHistory(conn)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, len(history))
+	})
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// ErrLossyScan is wrapped by errors from ScanStrict when a value doesn't fit the destination exactly.
+var ErrLossyScan = errors.New("lossy scan")
+
+// Number is the set of types ScanStrict can scan into.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// ScanStrict returns a scanner that reads a numeric column into v, for use with sql.Rows.Scan,
+// and fails with ErrLossyScan instead of losing information if the value doesn't fit T exactly.
+// That includes integers outside the range of T, such as a large int64 into an int on 32-bit platforms,
+// negative integers into unsigned types, reals with a fractional part into integer types,
+// and integers or reals that can't be represented exactly as a float32 or float64.
+// Text is parsed as an integer or real first.
+func ScanStrict[T Number](v *T) sql.Scanner {
+	return strictScanner[T]{v: v}
+}
+
+type strictScanner[T Number] struct {
+	v *T
+}
+
+// Scan satisfies sql.Scanner.
+func (s strictScanner[T]) Scan(src any) error {
+	switch src := src.(type) {
+	case int64:
+		return s.fromInt(src)
+	case float64:
+		return s.fromFloat(src)
+	case bool:
+		if src {
+			return s.fromInt(1)
+		}
+		return s.fromInt(0)
+	case []byte:
+		return s.parse(string(src))
+	case string:
+		return s.parse(src)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into %T", s.v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, s.v)
+	}
+}
+
+func (s strictScanner[T]) fromInt(src int64) error {
+	v := T(src)
+	if isFloat[T]() {
+		// Converting back is only defined inside the int64 range, and 2^63 is just outside it
+		f := float64(v)
+		if f < -math.MaxInt64-1 || f >= math.MaxInt64 || int64(f) != src {
+			return s.lossy(src)
+		}
+	} else if int64(v) != src || (v < 0) != (src < 0) {
+		return s.lossy(src)
+	}
+	*s.v = v
+	return nil
+}
+
+func (s strictScanner[T]) fromFloat(src float64) error {
+	if isFloat[T]() {
+		v := T(src)
+		if float64(v) != src && !math.IsNaN(src) {
+			return s.lossy(src)
+		}
+		*s.v = v
+		return nil
+	}
+
+	if src != math.Trunc(src) || src < -math.MaxInt64-1 || src >= math.MaxUint64 {
+		return s.lossy(src)
+	}
+	v := T(src)
+	if float64(v) != src || (v < 0) != (src < 0) {
+		return s.lossy(src)
+	}
+	*s.v = v
+	return nil
+}
+
+func (s strictScanner[T]) parse(src string) error {
+	if i, err := strconv.ParseInt(src, 10, 64); err == nil {
+		return s.fromInt(i)
+	}
+	if f, err := strconv.ParseFloat(src, 64); err == nil {
+		return s.fromFloat(f)
+	}
+	return fmt.Errorf("cannot scan %q into %T", src, s.v)
+}
+
+func (s strictScanner[T]) lossy(src any) error {
+	return fmt.Errorf("%w: %v doesn't fit %T exactly", ErrLossyScan, src, *s.v)
+}
+
+// isFloat returns whether T is a floating-point type.
+func isFloat[T Number]() bool {
+	var zero T
+	k := reflect.TypeOf(zero).Kind()
+	return k == reflect.Float32 || k == reflect.Float64
+}
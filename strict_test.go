@@ -0,0 +1,68 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestScanStrict(t *testing.T) {
+	t.Run("scans values that fit exactly", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var i32 int32
+		var u8 uint8
+		var i int
+		var f32 float32
+		var f64 float64
+		err := db.QueryRow(`select 2147483647, 255, 2.0, 0.5, 9007199254740992`).Scan(
+			sqlite.ScanStrict(&i32), sqlite.ScanStrict(&u8), sqlite.ScanStrict(&i), sqlite.ScanStrict(&f32), sqlite.ScanStrict(&f64))
+		assert.NoErr(t, err)
+		assert.Equal(t, int32(2147483647), i32)
+		assert.Equal(t, uint8(255), u8)
+		assert.Equal(t, 2, i)
+		assert.Equal(t, float32(0.5), f32)
+		assert.Equal(t, float64(9007199254740992), f64)
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		scan  func() any
+	}{
+		// An int32 is the size of int on 32-bit platforms
+		{"a large integer into an int32", `select 1 << 40`, func() any { var v int32; return sqlite.ScanStrict(&v) }},
+		{"a negative integer into a uint64", `select -1`, func() any { var v uint64; return sqlite.ScanStrict(&v) }},
+		{"an integer above the uint8 range", `select 256`, func() any { var v uint8; return sqlite.ScanStrict(&v) }},
+		{"a real with a fractional part into an int", `select 1.5`, func() any { var v int; return sqlite.ScanStrict(&v) }},
+		{"a real above the int64 range", `select 1e19`, func() any { var v int64; return sqlite.ScanStrict(&v) }},
+		{"an integer beyond float64 precision", `select 9007199254740993`, func() any { var v float64; return sqlite.ScanStrict(&v) }},
+		{"a real beyond float32 precision", `select 0.1`, func() any { var v float32; return sqlite.ScanStrict(&v) }},
+		{"text with a large integer into an int16", `select '100000'`, func() any { var v int16; return sqlite.ScanStrict(&v) }},
+	}
+	for _, test := range tests {
+		t.Run("errors on "+test.name, func(t *testing.T) {
+			db := open(t, sqlite.Options{})
+
+			err := db.QueryRow(test.query).Scan(test.scan())
+			assert.Err(t, err)
+			assert.Equal(t, true, errors.Is(err, sqlite.ErrLossyScan))
+		})
+	}
+
+	t.Run("errors on NULL and non-numeric text", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v int
+		err := db.QueryRow(`select null`).Scan(sqlite.ScanStrict(&v))
+		assert.Err(t, err)
+
+		err = db.QueryRow(`select 'nope'`).Scan(sqlite.ScanStrict(&v))
+		assert.Err(t, err)
+		assert.Equal(t, false, errors.Is(err, sqlite.ErrLossyScan))
+	})
+}
@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// structColumn is a struct field tagged with a column name.
+type structColumn struct {
+	name      string
+	value     any
+	omitEmpty bool
+	isZero    bool
+}
+
+// structColumns returns the columns of the exported fields of the struct v, or the struct v points to,
+// that have a db tag. Fields tagged with db:"-" are skipped.
+// The tag option omitempty, as in db:"id,omitempty", marks the column to be left out if the field has its zero value.
+func structColumns(v any) ([]structColumn, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, errors.New("value is a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("value must be a struct or pointer to struct, got %T", v)
+	}
+
+	var columns []structColumn
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, ok := f.Tag.Lookup("db")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		fv := rv.Field(i)
+		columns = append(columns, structColumn{
+			name:      name,
+			value:     fv.Interface(),
+			omitEmpty: options == "omitempty",
+			isZero:    fv.IsZero(),
+		})
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%T has no db-tagged fields", v)
+	}
+	return columns, nil
+}
+
+// Insert a row into table from the db-tagged fields of the struct v, or the struct v points to.
+// The tag is the column name, as in db:"name". Fields without a db tag, or tagged db:"-", are skipped.
+// Fields tagged with omitempty, as in db:"id,omitempty", are left out if they have their zero value,
+// so an INTEGER PRIMARY KEY column gets an automatically assigned rowid.
+// Values are bound like any other query argument.
+func Insert(ctx context.Context, q Querier, table string, v any) (sql.Result, error) {
+	columns, err := structColumns(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	var args []any
+	for _, c := range columns {
+		if c.omitEmpty && c.isZero {
+			continue
+		}
+		names = append(names, quoteIdentifier(c.name))
+		args = append(args, c.value)
+	}
+
+	var query string
+	if len(names) == 0 {
+		query = fmt.Sprintf("insert into %v default values", quoteIdentifier(table))
+	} else {
+		query = fmt.Sprintf("insert into %v (%v) values (%v)", quoteIdentifier(table), strings.Join(names, ", "),
+			strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("error inserting into %v", err, table)
+	}
+	return result, nil
+}
+
+// Update the rows in table matching the keyCols of the struct v, or the struct v points to,
+// setting all other db-tagged columns to the field values. See Insert for the tag format.
+// Fields tagged with omitempty are left out of the SET clause if they have their zero value.
+// At least one key column is required, so a missing key never updates the whole table.
+func Update(ctx context.Context, q Querier, table string, v any, keyCols ...string) (sql.Result, error) {
+	if len(keyCols) == 0 {
+		return nil, errors.New("at least one key column is required")
+	}
+
+	columns, err := structColumns(v)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]any{}
+	var sets []string
+	var args []any
+	for _, c := range columns {
+		if contains(keyCols, c.name) {
+			keys[c.name] = c.value
+			continue
+		}
+		if c.omitEmpty && c.isZero {
+			continue
+		}
+		sets = append(sets, quoteIdentifier(c.name)+" = ?")
+		args = append(args, c.value)
+	}
+	if len(sets) == 0 {
+		return nil, errors.New("no columns to update")
+	}
+
+	wheres := make([]string, len(keyCols))
+	for i, key := range keyCols {
+		value, ok := keys[key]
+		if !ok {
+			return nil, fmt.Errorf("key column %v is not a db-tagged field of %T", key, v)
+		}
+		wheres[i] = quoteIdentifier(key) + " = ?"
+		args = append(args, value)
+	}
+
+	query := fmt.Sprintf("update %v set %v where %v", quoteIdentifier(table), strings.Join(sets, ", "),
+		strings.Join(wheres, " and "))
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError("error updating %v", err, table)
+	}
+	return result, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
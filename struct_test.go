@@ -0,0 +1,110 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+type person struct {
+	ID       int64     `db:"id,omitempty"`
+	Name     string    `db:"name"`
+	Age      int       `db:"age"`
+	Created  time.Time `db:"created"`
+	Ignored  string    `db:"-"`
+	Untagged string
+}
+
+func TestInsert(t *testing.T) {
+	t.Run("inserts a struct and assigns the auto-increment primary key", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table people (id integer primary key, name text not null, age int not null, created text not null)`)
+		assert.NoErr(t, err)
+
+		created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		result, err := sqlite.Insert(context.Background(), db, "people", &person{Name: "Alice", Age: 30, Created: created,
+			Ignored: "nope", Untagged: "nope"})
+		assert.NoErr(t, err)
+
+		id, err := result.LastInsertId()
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1), id)
+
+		_, err = sqlite.Insert(context.Background(), db, "people", person{ID: 42, Name: "Bob", Age: 25, Created: created})
+		assert.NoErr(t, err)
+
+		var p person
+		var createdText string
+		err = db.QueryRow(`select id, name, age, created from people where id = 1`).Scan(&p.ID, &p.Name, &p.Age, &createdText)
+		assert.NoErr(t, err)
+		assert.Equal(t, "Alice", p.Name)
+		assert.Equal(t, 30, p.Age)
+		assert.Equal(t, "2024-01-02T03:04:05Z", createdText)
+
+		var name string
+		err = db.QueryRow(`select name from people where id = 42`).Scan(&name)
+		assert.NoErr(t, err)
+		assert.Equal(t, "Bob", name)
+	})
+
+	t.Run("errors on non-struct", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := sqlite.Insert(context.Background(), db, "people", 42)
+		assert.Err(t, err)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("updates the non-key fields of the row matching the key", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table people (id integer primary key, name text not null, age int not null, created text not null)`)
+		assert.NoErr(t, err)
+
+		created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		_, err = sqlite.Insert(context.Background(), db, "people", person{Name: "Alice", Age: 30, Created: created})
+		assert.NoErr(t, err)
+		_, err = sqlite.Insert(context.Background(), db, "people", person{Name: "Bob", Age: 25, Created: created})
+		assert.NoErr(t, err)
+
+		result, err := sqlite.Update(context.Background(), db, "people", person{ID: 1, Name: "Alicia", Age: 31, Created: created}, "id")
+		assert.NoErr(t, err)
+
+		n, err := result.RowsAffected()
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1), n)
+
+		var name string
+		var age int
+		err = db.QueryRow(`select name, age from people where id = 1`).Scan(&name, &age)
+		assert.NoErr(t, err)
+		assert.Equal(t, "Alicia", name)
+		assert.Equal(t, 31, age)
+
+		err = db.QueryRow(`select name, age from people where id = 2`).Scan(&name, &age)
+		assert.NoErr(t, err)
+		assert.Equal(t, "Bob", name)
+		assert.Equal(t, 25, age)
+	})
+
+	t.Run("errors without key columns", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := sqlite.Update(context.Background(), db, "people", person{ID: 1})
+		assert.Err(t, err)
+	})
+
+	t.Run("errors on unknown key column", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := sqlite.Update(context.Background(), db, "people", person{ID: 1}, "nope")
+		assert.Err(t, err)
+	})
+}
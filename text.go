@@ -0,0 +1,28 @@
+package sqlite
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Text is a byte slice that is bound as TEXT instead of BLOB, for byte slices holding UTF-8 text,
+// so the value gets text affinity and compares as text, for example with LIKE and text columns.
+// A nil Text is bound as NULL.
+//
+//	db.Exec(`insert into docs (body) values (?)`, sqlite.Text(b))
+type Text []byte
+
+// Scan satisfies sql.Scanner, so TEXT and BLOB values can be scanned into a *Text. NULL is scanned as nil.
+func (t *Text) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		*t = Text(src)
+	case []byte:
+		*t = Text(bytes.Clone(src))
+	case nil:
+		*t = nil
+	default:
+		return fmt.Errorf("cannot scan %T into *Text", src)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type txModeContextKey struct{}
+
+// withTxMode makes transactions begun with ctx use mode, if Options.TransactionMode is deferred.
+func withTxMode(ctx context.Context, mode TransactionMode) context.Context {
+	return context.WithValue(ctx, txModeContextKey{}, mode)
+}
+
+func txModeFromContext(ctx context.Context) (TransactionMode, bool) {
+	mode, ok := ctx.Value(txModeContextKey{}).(TransactionMode)
+	return mode, ok
+}
+
+// tx is a transaction on a connection.
+// tx satisfies driver.Tx.
+type tx struct {
+	connection *connection
+	// readOnly is true if the transaction has set PRAGMA query_only, which must be unset when it ends.
+	readOnly bool
+}
+
+// Commit the transaction.
+func (t *tx) Commit() error {
+	defer t.connection.inFlight.done()
+	defer t.endReadOnly()
+
+	if err := t.connection.exec("commit"); err != nil {
+		return wrapError("error committing transaction", err)
+	}
+	return nil
+}
+
+// Rollback the transaction. If SQLite has already rolled back the transaction,
+// for example because a statement was interrupted, this is a no-op.
+// See https://www.sqlite.org/c3ref/get_autocommit.html
+func (t *tx) Rollback() error {
+	defer t.connection.inFlight.done()
+	defer t.endReadOnly()
+
+	if C.sqlite3_get_autocommit(t.connection.cC) != 0 {
+		return nil
+	}
+	if err := t.connection.exec("rollback"); err != nil {
+		return wrapError("error rolling back transaction", err)
+	}
+	return nil
+}
+
+// endReadOnly unsets PRAGMA query_only for a read-only transaction, which isn't undone by ending the transaction.
+// See https://www.sqlite.org/pragma.html#pragma_query_only
+func (t *tx) endReadOnly() {
+	if t.readOnly {
+		_ = t.connection.exec("pragma query_only = 0")
+	}
+}
+
+// upgradeError is an SQLITE_BUSY error from a write statement in a transaction that has only read so far,
+// which is what SQLite returns when a DEFERRED transaction can't be upgraded to a write transaction.
+type upgradeError struct {
+	err error
+}
+
+func (e *upgradeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *upgradeError) Unwrap() error {
+	return e.err
+}
+
+// checkUpgrade returns err as an upgradeError if it's an SQLITE_BUSY error from running cStatement,
+// which writes, in a transaction that has only read so far.
+// See https://www.sqlite.org/c3ref/txn_state.html
+func (c *connection) checkUpgrade(cStatement *C.sqlite3_stmt, err error) error {
+	if isBusy(err) && C.sqlite3_stmt_readonly(cStatement) == 0 && C.sqlite3_txn_state(c.cC, nil) == C.SQLITE_TXN_READ {
+		return &upgradeError{err: err}
+	}
+	return err
+}
+
+// Transaction runs fn in a transaction, committing if fn returns nil and rolling back otherwise.
+//
+// The transaction starts in Options.TransactionMode, which is DEFERRED by default,
+// so read-only transactions don't take the write lock.
+// If a write in a DEFERRED transaction can't upgrade it to a write transaction because another connection
+// has written since the transaction started reading, SQLite returns SQLITE_BUSY without waiting for the busy timeout,
+// because waiting can't help. Transaction then rolls back and retries fn once in an IMMEDIATE transaction,
+// which takes the write lock up front, waiting for the busy timeout if necessary.
+// This means fn may be called twice, so it should not have side effects outside the transaction.
+// Other SQLITE_BUSY errors, such as when beginning or committing the transaction, are returned without retrying.
+// See https://www.sqlite.org/lang_transaction.html
+func Transaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	err := runTx(ctx, db, fn)
+	var upgradeErr *upgradeError
+	if !errors.As(err, &upgradeErr) {
+		return err
+	}
+	return runTx(withTxMode(ctx, TransactionModeImmediate), db, fn)
+}
+
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError("error beginning transaction", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return wrapError("error rolling back transaction after error %v", rollbackErr, err)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return wrapError("error committing transaction", err)
+	}
+	return nil
+}
+
+// isBusy reports whether err is an SQLITE_BUSY error.
+func isBusy(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == CodeBusy
+}
@@ -0,0 +1,247 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestTransaction(t *testing.T) {
+	t.Run("commits on success", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		err := sqlite.Transaction(context.Background(), db, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`update counter set n = n + 1`)
+			return err
+		})
+		assert.NoErr(t, err)
+
+		assert.Equal(t, 1, readCounter(t, db))
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		oops := errors.New("oops")
+		err := sqlite.Transaction(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`update counter set n = n + 1`); err != nil {
+				return err
+			}
+			return oops
+		})
+		assert.Equal(t, true, errors.Is(err, oops))
+
+		assert.Equal(t, 0, readCounter(t, db))
+	})
+
+	t.Run("retries as immediate after a read-then-write upgrade is busy", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		var attempts int
+		err := sqlite.Transaction(context.Background(), db, func(tx *sql.Tx) error {
+			attempts++
+
+			var n int
+			if err := tx.QueryRow(`select n from counter`).Scan(&n); err != nil {
+				return err
+			}
+
+			// Another connection writes after this transaction started reading
+			if attempts == 1 {
+				if _, err := db.Exec(`update counter set n = n + 10`); err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.Exec(`update counter set n = ?`, n+1)
+			return err
+		})
+		assert.NoErr(t, err)
+		assert.Equal(t, 2, attempts)
+
+		assert.Equal(t, 11, readCounter(t, db))
+	})
+
+	t.Run("does not retry if committing is busy", func(t *testing.T) {
+		var noBusyTimeout time.Duration
+		db := openWith(t, sqlite.Options{JournalMode: sqlite.JournalModeDelete, BusyTimeout: &noBusyTimeout},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		// A reader keeps the writer from committing in rollback journal mode
+		reader, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = reader.Rollback()
+		}()
+		var n int
+		err = reader.QueryRow(`select n from counter`).Scan(&n)
+		assert.NoErr(t, err)
+
+		var attempts int
+		err = sqlite.Transaction(context.Background(), db, func(tx *sql.Tx) error {
+			attempts++
+			_, err := tx.Exec(`update counter set n = n + 1`)
+			return err
+		})
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeBusy, sqliteErr.Code)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("plain deferred transaction gets busy on read-then-write upgrade", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		tx, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx.Rollback()
+		}()
+
+		var n int
+		err = tx.QueryRow(`select n from counter`).Scan(&n)
+		assert.NoErr(t, err)
+
+		_, err = db.Exec(`update counter set n = n + 10`)
+		assert.NoErr(t, err)
+
+		_, err = tx.Exec(`update counter set n = ?`, n+1)
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeBusy, sqliteErr.Code)
+	})
+}
+
+func TestDB_BeginTx(t *testing.T) {
+	t.Run("commits and rolls back", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		tx, err := db.BeginTx(context.Background(), nil)
+		assert.NoErr(t, err)
+		_, err = tx.Exec(`update counter set n = 1`)
+		assert.NoErr(t, err)
+		assert.NoErr(t, tx.Commit())
+		assert.Equal(t, 1, readCounter(t, db))
+
+		tx, err = db.BeginTx(context.Background(), nil)
+		assert.NoErr(t, err)
+		_, err = tx.Exec(`update counter set n = 2`)
+		assert.NoErr(t, err)
+		assert.NoErr(t, tx.Rollback())
+		assert.Equal(t, 1, readCounter(t, db))
+	})
+
+	t.Run("makes writes fail in a read-only transaction, and allows them again after", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+		db.SetMaxOpenConns(1)
+
+		tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		assert.NoErr(t, err)
+
+		var n int
+		err = tx.QueryRow(`select n from counter`).Scan(&n)
+		assert.NoErr(t, err)
+		assert.Equal(t, 0, n)
+
+		_, err = tx.Exec(`update counter set n = 1`)
+		var sqliteErr *sqlite.Error
+		assert.Equal(t, true, errors.As(err, &sqliteErr))
+		assert.Equal(t, sqlite.CodeReadOnly, sqliteErr.Code)
+		assert.NoErr(t, tx.Commit())
+
+		_, err = db.Exec(`update counter set n = 1`)
+		assert.NoErr(t, err)
+		assert.Equal(t, 1, readCounter(t, db))
+	})
+
+	t.Run("errors on an unsupported isolation level", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table counter (n integer not null)`,
+			`insert into counter values (0)`)
+
+		_, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		assert.Err(t, err)
+	})
+}
+
+func TestOptions_TransactionMode(t *testing.T) {
+	var noBusyTimeout time.Duration
+
+	t.Run("begins deferred transactions by default, without taking the write lock", func(t *testing.T) {
+		db := open(t, sqlite.Options{BusyTimeout: &noBusyTimeout})
+
+		tx1, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx1.Rollback()
+		}()
+
+		tx2, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx2.Rollback()
+		}()
+	})
+
+	for _, mode := range []sqlite.TransactionMode{sqlite.TransactionModeImmediate, sqlite.TransactionModeExclusive} {
+		t.Run("begins "+mode.String()+" transactions taking the write lock", func(t *testing.T) {
+			db := open(t, sqlite.Options{TransactionMode: mode, BusyTimeout: &noBusyTimeout})
+
+			tx1, err := db.Begin()
+			assert.NoErr(t, err)
+			defer func() {
+				_ = tx1.Rollback()
+			}()
+
+			_, err = db.Begin()
+			var sqliteErr *sqlite.Error
+			assert.Equal(t, true, errors.As(err, &sqliteErr))
+			assert.Equal(t, sqlite.CodeBusy, sqliteErr.Code)
+		})
+	}
+
+	t.Run("begins read-only transactions deferred", func(t *testing.T) {
+		db := open(t, sqlite.Options{TransactionMode: sqlite.TransactionModeImmediate, BusyTimeout: &noBusyTimeout})
+
+		tx1, err := db.Begin()
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx1.Rollback()
+		}()
+
+		tx2, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tx2.Rollback()
+		}()
+	})
+}
+
+func readCounter(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var n int
+	err := db.QueryRow(`select n from counter`).Scan(&n)
+	assert.NoErr(t, err)
+	return n
+}
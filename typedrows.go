@@ -0,0 +1,221 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TypedRows wraps sql.Rows and gives access to the current row's values by column name,
+// without having to pass positional Scan destinations.
+// Values are converted to the requested type on access. The first conversion error or
+// unknown column name is remembered and returned by Err, so a loop can check for errors once at the end.
+//
+//	tr, err := sqlite.NewTypedRows(rows)
+//	for tr.Next() {
+//		fmt.Println(tr.Int64("id"), tr.String("name"))
+//	}
+//	if err := tr.Err(); err != nil { … }
+type TypedRows struct {
+	rows    *sql.Rows
+	columns map[string]int
+	values  []any
+	ptrs    []any
+	err     error
+}
+
+// NewTypedRows wraps rows. The caller should still call Close, either on the TypedRows or the original sql.Rows.
+func NewTypedRows(rows *sql.Rows) (*TypedRows, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, wrapError("error getting columns", err)
+	}
+
+	r := &TypedRows{
+		rows:    rows,
+		columns: make(map[string]int, len(columns)),
+		values:  make([]any, len(columns)),
+		ptrs:    make([]any, len(columns)),
+	}
+	for i, name := range columns {
+		r.columns[name] = i
+		r.ptrs[i] = &r.values[i]
+	}
+	return r, nil
+}
+
+// Next advances to the next row and scans it into the internal buffer.
+// It returns false when there are no more rows or an error occurred.
+func (r *TypedRows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	if err := r.rows.Scan(r.ptrs...); err != nil {
+		r.err = wrapError("error scanning row", err)
+		return false
+	}
+	return true
+}
+
+// Err returns the first error encountered while iterating or converting values.
+func (r *TypedRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close closes the underlying rows.
+func (r *TypedRows) Close() error {
+	return r.rows.Close()
+}
+
+// IsNull reports whether the value of col is NULL.
+func (r *TypedRows) IsNull(col string) bool {
+	v, ok := r.value(col)
+	return ok && v == nil
+}
+
+// Int64 returns the value of col as an int64. NULL is returned as 0.
+func (r *TypedRows) Int64(col string) int64 {
+	v, _ := r.value(col)
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			r.setErr(wrapError("error converting column %v to int64", err, col))
+		}
+		return i
+	case []byte:
+		i, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			r.setErr(wrapError("error converting column %v to int64", err, col))
+		}
+		return i
+	case nil:
+		return 0
+	default:
+		r.setErr(fmt.Errorf("cannot convert column %v of type %T to int64", col, v))
+		return 0
+	}
+}
+
+// Float64 returns the value of col as a float64. NULL is returned as 0.
+func (r *TypedRows) Float64(col string) float64 {
+	v, _ := r.value(col)
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			r.setErr(wrapError("error converting column %v to float64", err, col))
+		}
+		return f
+	case []byte:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			r.setErr(wrapError("error converting column %v to float64", err, col))
+		}
+		return f
+	case nil:
+		return 0
+	default:
+		r.setErr(fmt.Errorf("cannot convert column %v of type %T to float64", col, v))
+		return 0
+	}
+}
+
+// String returns the value of col as a string. NULL is returned as the empty string.
+func (r *TypedRows) String(col string) string {
+	v, _ := r.value(col)
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// Bool returns the value of col as a bool, where any non-zero number is true. NULL is returned as false.
+func (r *TypedRows) Bool(col string) bool {
+	v, _ := r.value(col)
+	switch v := v.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			r.setErr(wrapError("error converting column %v to bool", err, col))
+		}
+		return b
+	case []byte:
+		b, err := strconv.ParseBool(string(v))
+		if err != nil {
+			r.setErr(wrapError("error converting column %v to bool", err, col))
+		}
+		return b
+	case nil:
+		return false
+	default:
+		r.setErr(fmt.Errorf("cannot convert column %v of type %T to bool", col, v))
+		return false
+	}
+}
+
+// Bytes returns the value of col as a byte slice. NULL is returned as nil.
+func (r *TypedRows) Bytes(col string) []byte {
+	v, _ := r.value(col)
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case nil:
+		return nil
+	}
+	return []byte(r.String(col))
+}
+
+func (r *TypedRows) value(col string) (any, bool) {
+	i, ok := r.columns[col]
+	if !ok {
+		r.setErr(fmt.Errorf("unknown column %v", col))
+		return nil, false
+	}
+	return r.values[i], true
+}
+
+func (r *TypedRows) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
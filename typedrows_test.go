@@ -0,0 +1,85 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestTypedRows(t *testing.T) {
+	t.Run("reads values by column name", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (id integer primary key, name text, score real, active int, data blob)`)
+		assert.NoErr(t, err)
+		_, err = db.Exec(`insert into t (name, score, active, data) values ('a', 1.5, 1, x'01'), (null, 2, 0, null)`)
+		assert.NoErr(t, err)
+
+		rows, err := db.Query(`select * from t order by id`)
+		assert.NoErr(t, err)
+		tr, err := sqlite.NewTypedRows(rows)
+		assert.NoErr(t, err)
+		defer func() {
+			assert.NoErr(t, tr.Close())
+		}()
+
+		assert.Equal(t, true, tr.Next())
+		assert.Equal(t, int64(1), tr.Int64("id"))
+		assert.Equal(t, "a", tr.String("name"))
+		assert.Equal(t, 1.5, tr.Float64("score"))
+		assert.Equal(t, true, tr.Bool("active"))
+		assert.EqualBytes(t, []byte{1}, tr.Bytes("data"))
+		assert.Equal(t, false, tr.IsNull("name"))
+
+		assert.Equal(t, true, tr.Next())
+		assert.Equal(t, int64(2), tr.Int64("id"))
+		assert.Equal(t, true, tr.IsNull("name"))
+		assert.Equal(t, "", tr.String("name"))
+		assert.Equal(t, 2.0, tr.Float64("score"))
+		assert.Equal(t, false, tr.Bool("active"))
+		assert.Equal(t, true, tr.Bytes("data") == nil)
+
+		assert.Equal(t, false, tr.Next())
+		assert.NoErr(t, tr.Err())
+	})
+
+	t.Run("remembers error on unknown column", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query(`select 1 as v`)
+		assert.NoErr(t, err)
+		tr, err := sqlite.NewTypedRows(rows)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tr.Close()
+		}()
+
+		assert.Equal(t, true, tr.Next())
+		assert.Equal(t, int64(0), tr.Int64("nope"))
+		assert.Err(t, tr.Err())
+		assert.Equal(t, false, tr.Next())
+	})
+
+	t.Run("remembers error on values that can't be converted", func(t *testing.T) {
+		db := openWith(t, sqlite.Options{},
+			`create table t (created datetime)`,
+			`insert into t values ('2024-01-02T03:04:05Z')`)
+
+		rows, err := db.Query(`select created from t`)
+		assert.NoErr(t, err)
+		tr, err := sqlite.NewTypedRows(rows)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = tr.Close()
+		}()
+
+		assert.Equal(t, true, tr.Next())
+		assert.Equal(t, "2024-01-02T03:04:05Z", tr.String("created"))
+		assert.NoErr(t, tr.Err())
+		assert.Equal(t, int64(0), tr.Int64("created"))
+		assert.Err(t, tr.Err())
+	})
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// UnixMilli returns a value that binds t as the integer number of milliseconds since the Unix epoch,
+// for schemas storing timestamps like JavaScript's Date.now. Sub-millisecond precision is truncated.
+// Without it, time.Time values are bound as text, see Options.TimeEncoding.
+func UnixMilli(t time.Time) driver.Valuer {
+	return unixMilli{t: t}
+}
+
+type unixMilli struct {
+	t time.Time
+}
+
+// Value satisfies driver.Valuer.
+func (u unixMilli) Value() (driver.Value, error) {
+	return u.t.UnixMilli(), nil
+}
+
+// ScanUnixMilli returns a scanner that parses an integer number of milliseconds since the Unix epoch into t,
+// in UTC, for use with sql.Rows.Scan.
+func ScanUnixMilli(t *time.Time) sql.Scanner {
+	return unixMilliScanner{t: t}
+}
+
+type unixMilliScanner struct {
+	t *time.Time
+}
+
+// Scan satisfies sql.Scanner.
+func (s unixMilliScanner) Scan(src any) error {
+	switch src := src.(type) {
+	case int64:
+		*s.t = time.UnixMilli(src).UTC()
+	case []byte:
+		return s.parse(string(src))
+	case string:
+		return s.parse(src)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into *time.Time")
+	default:
+		return fmt.Errorf("cannot scan %T as Unix milliseconds into *time.Time", src)
+	}
+	return nil
+}
+
+func (s unixMilliScanner) parse(v string) error {
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return wrapError("error parsing %q as Unix milliseconds", err, v)
+	}
+	*s.t = time.UnixMilli(ms).UTC()
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestUnixMilli(t *testing.T) {
+	t.Run("round-trips a time through integer milliseconds", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		_, err := db.Exec(`create table t (v integer not null)`)
+		assert.NoErr(t, err)
+
+		expected := time.Date(2024, 1, 2, 3, 4, 5, 678_900_000, time.UTC)
+		_, err = db.Exec(`insert into t values (?)`, sqlite.UnixMilli(expected))
+		assert.NoErr(t, err)
+
+		var stored int64
+		var typ string
+		err = db.QueryRow(`select v, typeof(v) from t`).Scan(&stored, &typ)
+		assert.NoErr(t, err)
+		assert.Equal(t, int64(1704164645678), stored)
+		assert.Equal(t, "integer", typ)
+
+		var actual time.Time
+		err = db.QueryRow(`select v from t`).Scan(sqlite.ScanUnixMilli(&actual))
+		assert.NoErr(t, err)
+		assert.Equal(t, expected.Truncate(time.Millisecond), actual)
+	})
+
+	t.Run("errors scanning NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var actual time.Time
+		err := db.QueryRow(`select null`).Scan(sqlite.ScanUnixMilli(&actual))
+		assert.Err(t, err)
+	})
+}
@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"unsafe"
+)
+
+// Unsafe returns a scanner that sets dest to a view of the BLOB column value, without copying it,
+// for use with sql.Rows.Scan where the caller uses or copies the value right away.
+// It's like sql.RawBytes, but can also be used in scanners of your own.
+// Only use it with *sql.Rows while the row is current, and never with sql.Row.Scan,
+// which closes the rows before returning, so the view may already point to freed memory.
+// The driver only returns views of BLOB values with Options.ZeroCopyBlobs, and otherwise copies them once.
+// TEXT values are returned by the driver as strings, which are already copies, so dest is set to a view of the string.
+//
+// The view is only valid until the next call to Next, NextResultSet, or Close on the rows,
+// after which SQLite reuses the memory, and it must never be modified.
+// Use it only where profiling shows that copying matters; by default, scanning into a *[]byte copies the value.
+// NULL sets dest to nil.
+func Unsafe(dest *[]byte) sql.Scanner {
+	return unsafeScanner{dest: dest}
+}
+
+type unsafeScanner struct {
+	dest *[]byte
+}
+
+// Scan satisfies sql.Scanner.
+func (s unsafeScanner) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		*s.dest = src
+	case string:
+		*s.dest = unsafe.Slice(unsafe.StringData(src), len(src))
+	case nil:
+		*s.dest = nil
+	default:
+		return fmt.Errorf("cannot scan %T into *[]byte without copying", src)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/maragudk/sqlite"
+	"github.com/maragudk/sqlite/internal/assert"
+)
+
+func TestUnsafe(t *testing.T) {
+	t.Run("scans text, blobs, and NULL", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		rows, err := db.Query(`select 'foo' union all select x'626172' union all select null`)
+		assert.NoErr(t, err)
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var expected = [][]byte{[]byte("foo"), []byte("bar"), nil}
+		var i int
+		var v []byte
+		for rows.Next() {
+			err := rows.Scan(sqlite.Unsafe(&v))
+			assert.NoErr(t, err)
+			assert.EqualBytes(t, expected[i], v)
+			assert.Equal(t, expected[i] == nil, v == nil)
+			i++
+		}
+		assert.NoErr(t, rows.Err())
+		assert.Equal(t, 3, i)
+	})
+
+	t.Run("errors on a non-text value", func(t *testing.T) {
+		db := open(t, sqlite.Options{})
+
+		var v []byte
+		err := db.QueryRow(`select 1`).Scan(sqlite.Unsafe(&v))
+		assert.Err(t, err)
+	})
+}
+
+func BenchmarkUnsafe(b *testing.B) {
+	db := openWith(b, sqlite.Options{},
+		`create table t (id integer primary key, name text not null)`,
+		`with recursive n(i) as (select 1 union all select i + 1 from n where i < 1000) insert into t (name) select 'name' from n`)
+
+	b.Run("copying into a byte slice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rows, err := db.Query(`select name from t`)
+			assert.NoErr(b, err)
+			var v []byte
+			for rows.Next() {
+				err := rows.Scan(&v)
+				assert.NoErr(b, err)
+			}
+			assert.NoErr(b, rows.Close())
+		}
+	})
+
+	b.Run("viewing with Unsafe", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rows, err := db.Query(`select name from t`)
+			assert.NoErr(b, err)
+			var v []byte
+			dest := sqlite.Unsafe(&v)
+			for rows.Next() {
+				err := rows.Scan(dest)
+				assert.NoErr(b, err)
+			}
+			assert.NoErr(b, rows.Close())
+		}
+	})
+}
@@ -0,0 +1,63 @@
+//go:build cgo
+
+package sqlite
+
+/*
+#include <sqlite3.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// checkWriteLock starts monitoring for Options.WriteLockTimeout when the connection has started a write transaction,
+// and stops when it has ended. It also releases the Options.MaxWriters slot when the write transaction has ended,
+// or never started. It's called after running statements.
+// See https://www.sqlite.org/c3ref/txn_state.html
+func (c *connection) checkWriteLock() {
+	if (c.opts.WriteLockTimeout <= 0 && !c.writer) || c.cC == nil {
+		return
+	}
+
+	writing := C.sqlite3_txn_state(c.cC, nil) == C.SQLITE_TXN_WRITE
+	if !writing {
+		c.releaseWriter()
+	}
+	if c.opts.WriteLockTimeout <= 0 {
+		return
+	}
+
+	switch {
+	case writing && c.writeLockTimer == nil:
+		start := time.Now()
+		c.writeLockTimer = time.AfterFunc(c.opts.WriteLockTimeout, func() {
+			c.warnWriteLock(start)
+		})
+	case !writing && c.writeLockTimer != nil:
+		c.stopWriteLockTimer()
+	}
+}
+
+func (c *connection) stopWriteLockTimer() {
+	if c.writeLockTimer != nil {
+		c.writeLockTimer.Stop()
+		c.writeLockTimer = nil
+	}
+}
+
+// warnWriteLock is called from the timer goroutine, so it must only use what's safe for concurrent use.
+func (c *connection) warnWriteLock(start time.Time) {
+	msg := fmt.Sprintf("Warning: write transaction held for more than %v, since %v",
+		c.opts.WriteLockTimeout, start.Format(time.RFC3339Nano))
+	if c.history != nil {
+		var b strings.Builder
+		for _, r := range c.history.list() {
+			fmt.Fprintf(&b, "\n  %v %q (%v)", r.Start.Format(time.RFC3339Nano), r.Query, r.Duration)
+		}
+		msg += ", recent statements:" + b.String()
+	}
+	c.opts.Logger.Println(msg)
+}